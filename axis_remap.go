@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/pointcloud"
+)
+
+// axisRemap maps the axes of a camera's points onto the axes of its frame. Entry i gives the input axis, 0 to 2
+// for x to z, and the sign of output axis i.
+type axisRemap [3]struct {
+	axis int
+	sign float64
+}
+
+// rosFrameRemap converts points from the optical convention, x right, y down and z forward, to the ROS body
+// convention, x forward, y left and z up, as the remap "z,-x,-y".
+var rosFrameRemap = axisRemap{{axis: 2, sign: 1}, {axis: 0, sign: -1}, {axis: 1, sign: -1}}
+
+// parseAxisRemap parses a remap such as "x,-z,y", which lists for each output axis the signed input axis it takes
+// its value from. Every input axis must be used exactly once.
+func parseAxisRemap(s string) (axisRemap, error) {
+	var remap axisRemap
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 {
+		return remap, errors.Errorf("axis remap %q must list three comma separated axes", s)
+	}
+	var used [3]bool
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		remap[i].sign = 1
+		switch {
+		case strings.HasPrefix(part, "-"):
+			remap[i].sign = -1
+			part = part[1:]
+		case strings.HasPrefix(part, "+"):
+			part = part[1:]
+		}
+		switch part {
+		case "x":
+			remap[i].axis = 0
+		case "y":
+			remap[i].axis = 1
+		case "z":
+			remap[i].axis = 2
+		default:
+			return remap, errors.Errorf("axis remap %q contains invalid axis %q, must be x, y or z with an optional sign", s, parts[i])
+		}
+		if used[remap[i].axis] {
+			return remap, errors.Errorf("axis remap %q uses axis %v more than once", s, part)
+		}
+		used[remap[i].axis] = true
+	}
+	return remap, nil
+}
+
+// apply returns the remapped point.
+func (remap axisRemap) apply(p r3.Vector) r3.Vector {
+	in := [3]float64{p.X, p.Y, p.Z}
+	return r3.Vector{
+		X: remap[0].sign * in[remap[0].axis],
+		Y: remap[1].sign * in[remap[1].axis],
+		Z: remap[2].sign * in[remap[2].axis],
+	}
+}
+
+// remapPointCloud returns a cloud with the axes of every point of the given cloud remapped.
+func remapPointCloud(pc pointcloud.PointCloud, remap axisRemap) (pointcloud.PointCloud, error) {
+	remapped := pointcloud.NewWithPrealloc(pc.Size())
+	var err error
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		err = remapped.Set(remap.apply(p), d)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return remapped, nil
+}
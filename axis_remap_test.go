@@ -0,0 +1,70 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/test"
+)
+
+func TestParseAxisRemap(t *testing.T) {
+	remap, err := parseAxisRemap("x,-z,y")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, remap.apply(r3.Vector{X: 1, Y: 2, Z: 3}), test.ShouldResemble, r3.Vector{X: 1, Y: -3, Z: 2})
+
+	remap, err = parseAxisRemap(" +y , x , -z ")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, remap.apply(r3.Vector{X: 1, Y: 2, Z: 3}), test.ShouldResemble, r3.Vector{X: 2, Y: 1, Z: -3})
+
+	for _, tc := range []struct {
+		remap    string
+		expected string
+	}{
+		{"x,y", "three comma separated axes"},
+		{"x,y,z,x", "three comma separated axes"},
+		{"x,y,w", "invalid axis"},
+		{"x,--y,z", "invalid axis"},
+		{"x,-x,z", "more than once"},
+	} {
+		_, err := parseAxisRemap(tc.remap)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, tc.expected)
+	}
+}
+
+func TestRemapPointCloud(t *testing.T) {
+	pc := pointcloud.New()
+	test.That(t, pc.Set(r3.Vector{X: 1, Y: 2, Z: 3}, pointcloud.NewValueData(4)), test.ShouldBeNil)
+	remap, err := parseAxisRemap("-x,z,y")
+	test.That(t, err, test.ShouldBeNil)
+
+	remapped, err := remapPointCloud(pc, remap)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, remapped.Size(), test.ShouldEqual, 1)
+	d, ok := remapped.At(-1, 3, 2)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.Value(), test.ShouldEqual, 4)
+}
+
+func TestROSFrameRemap(t *testing.T) {
+	remap, err := parseAxisRemap("z,-x,-y")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, rosFrameRemap, test.ShouldResemble, remap)
+
+	// forward, left and up in the optical convention become x, y and z
+	test.That(t, rosFrameRemap.apply(r3.Vector{Z: 1}), test.ShouldResemble, r3.Vector{X: 1})
+	test.That(t, rosFrameRemap.apply(r3.Vector{X: -1}), test.ShouldResemble, r3.Vector{Y: 1})
+	test.That(t, rosFrameRemap.apply(r3.Vector{Y: -1}), test.ShouldResemble, r3.Vector{Z: 1})
+
+	// the remap is -90 degrees about x followed by -90 degrees about z
+	rotation := spatialmath.Compose(
+		spatialmath.NewPoseFromOrientation(&spatialmath.R4AA{Theta: -math.Pi / 2, RZ: 1}),
+		spatialmath.NewPoseFromOrientation(&spatialmath.R4AA{Theta: -math.Pi / 2, RX: 1}),
+	)
+	p := r3.Vector{X: 1, Y: 2, Z: 3}
+	rotated := spatialmath.Compose(rotation, spatialmath.NewPoseFromPoint(p)).Point()
+	test.That(t, rotated.Distance(rosFrameRemap.apply(p)), test.ShouldBeLessThan, 1e-9)
+}
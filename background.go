@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/pointcloud"
+)
+
+// backgroundMerger merges point clouds on a timer and keeps the outcome of the most recent merge.
+type backgroundMerger struct {
+	cancel context.CancelFunc
+	// done is closed once the merge loop has exited.
+	done chan struct{}
+	// ready is closed once the first merge has finished.
+	ready chan struct{}
+
+	mu         sync.Mutex
+	pc         pointcloud.PointCloud
+	capturedAt time.Time
+	err        error
+}
+
+// startBackgroundMerges starts merging every interval until stopBackgroundMerges is called. The first merge starts
+// immediately.
+func (merged *mergedCamera) startBackgroundMerges(interval time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	bg := &backgroundMerger{cancel: cancel, done: make(chan struct{}), ready: make(chan struct{})}
+
+	merged.backgroundMu.Lock()
+	merged.background = bg
+	merged.backgroundMu.Unlock()
+
+	go func() {
+		defer close(bg.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for first := true; ; first = false {
+			pc, capturedAt, err := merged.nextMergedPointCloud(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				merged.logger.Debugf("background merge failed: %v", err)
+			}
+			bg.mu.Lock()
+			bg.pc, bg.capturedAt, bg.err = pc, capturedAt, err
+			bg.mu.Unlock()
+			if first {
+				close(bg.ready)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// stopBackgroundMerges stops background merging, if running, and waits for the merge in progress to return. It must
+// not be called while holding mu, which the merge needs.
+func (merged *mergedCamera) stopBackgroundMerges() {
+	merged.backgroundMu.Lock()
+	bg := merged.background
+	merged.background = nil
+	merged.backgroundMu.Unlock()
+	if bg == nil {
+		return
+	}
+	bg.cancel()
+	<-bg.done
+}
+
+// backgroundMerges returns the running background merger, nil when merges happen on demand.
+func (merged *mergedCamera) backgroundMerges() *backgroundMerger {
+	merged.backgroundMu.Lock()
+	defer merged.backgroundMu.Unlock()
+	return merged.background
+}
+
+// latest returns the outcome of the most recent background merge, waiting for the first merge to finish if needed.
+// The returned cloud is shared by every caller and must not be modified.
+func (bg *backgroundMerger) latest(ctx context.Context) (pointcloud.PointCloud, time.Time, error) {
+	select {
+	case <-bg.ready:
+	case <-bg.done:
+		select {
+		case <-bg.ready:
+		default:
+			return nil, time.Time{}, errors.New("background merging stopped before producing a point cloud")
+		}
+	case <-ctx.Done():
+		return nil, time.Time{}, ctx.Err()
+	}
+	bg.mu.Lock()
+	defer bg.mu.Unlock()
+	if bg.err != nil {
+		return nil, time.Time{}, errors.Wrap(bg.err, "latest background merge failed")
+	}
+	return bg.pc, bg.capturedAt, nil
+}
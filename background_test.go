@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/test"
+)
+
+func TestBackgroundMerges(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	var fetches int32
+	cam1 := createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 1}}).(*inject.Camera)
+	next := cam1.NextPointCloudFunc
+	cam1.NextPointCloudFunc = func(ctx context.Context) (pointcloud.PointCloud, error) {
+		atomic.AddInt32(&fetches, 1)
+		return next(ctx)
+	}
+	cam2 := createMockCamera("cam2", []r3.Vector{{X: 0, Y: 0, Z: 2}})
+	cameras := []camera.Camera{cam1, cam2}
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+	deps := createDependencies(cameras, fsService)
+
+	mergedCam := &mergedCamera{logger: logger}
+	// slow enough that only the first background merge happens during the test
+	conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}, BackgroundRateHz: 0.001}}
+	test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+
+	t.Run("serves the latest merge without merging again", func(t *testing.T) {
+		first, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, first.Size(), test.ShouldEqual, 2)
+		for i := 0; i < 3; i++ {
+			pc, err := mergedCam.NextPointCloud(ctx)
+			test.That(t, err, test.ShouldBeNil)
+			test.That(t, pc.Size(), test.ShouldEqual, 2)
+		}
+		test.That(t, atomic.LoadInt32(&fetches), test.ShouldEqual, 1)
+
+		// every caller gets its own copy
+		test.That(t, first.Set(r3.Vector{X: 5}, pointcloud.NewBasicData()), test.ShouldBeNil)
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+	})
+
+	t.Run("reconfiguring without a rate merges on demand", func(t *testing.T) {
+		onDemand := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, onDemand), test.ShouldBeNil)
+		test.That(t, mergedCam.backgroundMerges(), test.ShouldBeNil)
+		before := atomic.LoadInt32(&fetches)
+		for i := 0; i < 2; i++ {
+			_, err := mergedCam.NextPointCloud(ctx)
+			test.That(t, err, test.ShouldBeNil)
+		}
+		test.That(t, atomic.LoadInt32(&fetches), test.ShouldEqual, before+2)
+	})
+
+	t.Run("returns the error of the latest merge", func(t *testing.T) {
+		failing := []camera.Camera{cam1, createFailingCamera("cam2")}
+		failingDeps := createDependencies(failing, fsService)
+		failingCam := &mergedCamera{logger: logger}
+		test.That(t, failingCam.Reconfigure(ctx, failingDeps, conf), test.ShouldBeNil)
+		_, err := failingCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "latest background merge failed")
+		test.That(t, failingCam.Close(ctx), test.ShouldBeNil)
+	})
+
+	t.Run("close stops background merges", func(t *testing.T) {
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+		bg := mergedCam.backgroundMerges()
+		test.That(t, bg, test.ShouldNotBeNil)
+		test.That(t, mergedCam.Close(ctx), test.ShouldBeNil)
+		<-bg.done
+		test.That(t, mergedCam.backgroundMerges(), test.ShouldBeNil)
+		_, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeError)
+	})
+
+	t.Run("validate rejects negative rates", func(t *testing.T) {
+		cfg := &Config{Cameras: []string{"cam1", "cam2"}, BackgroundRateHz: -1}
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "background_rate_hz")
+	})
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/resource"
+)
+
+// parentResource looks a resource up on the robot running the module. It is set by main once the module is created
+// and is nil in tests, where every camera of a group is a dependency.
+var parentResource func(ctx context.Context, name resource.Name) (resource.Resource, error)
+
+// groupCameras returns the names of the cameras whose frames descend from groupFrame, in frame name order, along with
+// the dependencies extended by the cameras that had to be looked up on the robot. A frame is a camera when it is listed
+// in remotes, is a camera dependency or names a camera on the robot. Every other frame is skipped.
+func (merged *mergedCamera) groupCameras(
+	ctx context.Context,
+	deps resource.Dependencies,
+	groupFrame string,
+	remotes map[string]*RemoteCameraConfig,
+) ([]string, resource.Dependencies, error) {
+	fsService, err := frameSystemFromDependencies(deps)
+	if err != nil {
+		return nil, nil, err
+	}
+	fs, err := fsService.FrameSystem(ctx, nil)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error getting frame system")
+	}
+	frames, err := descendantFrames(fs, groupFrame)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	grouped := make(resource.Dependencies, len(deps))
+	for name, dep := range deps {
+		grouped[name] = dep
+	}
+	var cameras []string
+	for _, frame := range frames {
+		if remotes[frame] != nil {
+			cameras = append(cameras, frame)
+			continue
+		}
+		if _, err := camera.FromDependencies(deps, frame); err == nil {
+			cameras = append(cameras, frame)
+			continue
+		}
+		if parentResource == nil {
+			continue
+		}
+		res, err := parentResource(ctx, camera.Named(frame))
+		if err != nil {
+			if resource.IsNotFoundError(err) {
+				continue
+			}
+			return nil, nil, errors.Wrapf(err, "error looking up camera %v of camera_group_frame %v", frame, groupFrame)
+		}
+		if _, ok := res.(camera.Camera); !ok {
+			continue
+		}
+		grouped[camera.Named(frame)] = res
+		cameras = append(cameras, frame)
+	}
+	if len(cameras) < 2 {
+		return nil, nil, errors.Errorf("at least 2 cameras are required to merge point clouds, found %v under frame %v",
+			len(cameras), groupFrame)
+	}
+	merged.logger.Debugf("merging cameras %v under frame %v", cameras, groupFrame)
+	return cameras, grouped, nil
+}
+
+// descendantFrames returns the names of the frames below the named frame in the frame system, sorted by name.
+func descendantFrames(fs referenceframe.FrameSystem, name string) ([]string, error) {
+	if fs.Frame(name) == nil {
+		return nil, errors.Errorf("frame %v is not in the frame system", name)
+	}
+	var descendants []string
+	for _, frameName := range fs.FrameNames() {
+		if frameName == name {
+			continue
+		}
+		ancestors, err := fs.TracebackFrame(fs.Frame(frameName))
+		if err != nil {
+			return nil, errors.Wrapf(err, "error tracing frame %v", frameName)
+		}
+		for _, ancestor := range ancestors {
+			if ancestor.Name() == name {
+				descendants = append(descendants, frameName)
+				break
+			}
+		}
+	}
+	sort.Strings(descendants)
+	return descendants, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/test"
+)
+
+func TestCameraGroupFrameValidate(t *testing.T) {
+	cfg := &Config{CameraGroupFrame: "rig", Scale: map[string]float64{"cam1": 2}}
+	deps, err := cfg.Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, deps, test.ShouldResemble, []string{"rdk-internal:service:frame_system/builtin"})
+
+	cfg.Cameras = []string{"cam1", "cam2"}
+	_, err = cfg.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "cannot be combined with camera_group_frame")
+
+	cfg = &Config{CameraGroupFrame: "world"}
+	_, err = cfg.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "camera_group_frame cannot be the world frame")
+}
+
+func TestCameraGroupFrame(t *testing.T) {
+	ctx := context.Background()
+	cam1 := newFakeCamera("cam1", r3.Vector{Z: 10})
+	cam2 := newFakeCamera("cam2", r3.Vector{Z: 10})
+	cam3 := newFakeCamera("cam3", r3.Vector{Z: 10})
+	cam4 := newFakeCamera("cam4", r3.Vector{Z: 10})
+	poses := map[string]spatialmath.Pose{
+		"rig":     spatialmath.NewPoseFromPoint(r3.Vector{X: 1000}),
+		"mount":   spatialmath.NewZeroPose(),
+		"cam1":    spatialmath.NewZeroPose(),
+		"cam2":    spatialmath.NewPoseFromPoint(r3.Vector{X: 100}),
+		"cam3":    spatialmath.NewZeroPose(),
+		"cam4":    spatialmath.NewZeroPose(),
+		"gripper": spatialmath.NewZeroPose(),
+	}
+	parents := map[string]string{"mount": "rig", "cam1": "rig", "cam2": "mount", "gripper": "mount", "cam4": "rig"}
+	fsService, err := newFakeFrameSystemServiceWithParents(poses, parents)
+	test.That(t, err, test.ShouldBeNil)
+
+	t.Run("merges the cameras under the frame", func(t *testing.T) {
+		cfg := &Config{CameraGroupFrame: "rig"}
+		merged := newTestMergedCamera(t, cfg, []camera.Camera{cam1, cam2, cam3}, fsService)
+		defer merged.Close(ctx)
+
+		test.That(t, len(merged.cameras), test.ShouldEqual, 2)
+		test.That(t, merged.cameras[0].Name().ShortName(), test.ShouldEqual, "cam1")
+		test.That(t, merged.cameras[1].Name().ShortName(), test.ShouldEqual, "cam2")
+
+		pc, err := merged.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+		_, ok := pc.At(0, 0, 10)
+		test.That(t, ok, test.ShouldBeTrue)
+		_, ok = pc.At(100, 0, 10)
+		test.That(t, ok, test.ShouldBeTrue)
+	})
+
+	t.Run("looks up cameras that are not dependencies", func(t *testing.T) {
+		lookups := 0
+		parentResource = func(ctx context.Context, name resource.Name) (resource.Resource, error) {
+			lookups++
+			if name == cam4.Name() {
+				return cam4, nil
+			}
+			return nil, resource.NewNotFoundError(name)
+		}
+		defer func() { parentResource = nil }()
+
+		cfg := &Config{CameraGroupFrame: "rig"}
+		merged := newTestMergedCamera(t, cfg, []camera.Camera{cam1, cam2}, fsService)
+		defer merged.Close(ctx)
+
+		test.That(t, len(merged.cameras), test.ShouldEqual, 3)
+		test.That(t, merged.cameras[2].Name().ShortName(), test.ShouldEqual, "cam4")
+		// gripper, mount and cam4 are looked up
+		test.That(t, lookups, test.ShouldEqual, 3)
+	})
+
+	t.Run("requires two cameras under the frame", func(t *testing.T) {
+		cfg := &Config{CameraGroupFrame: "mount"}
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldBeNil)
+		merged := &mergedCamera{Named: resource.NewName(camera.API, "merged").AsNamed(), logger: logging.NewTestLogger(t)}
+		err = merged.Reconfigure(ctx, createDependencies([]camera.Camera{cam1, cam2}, fsService),
+			resource.Config{ConvertedAttributes: cfg})
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "found 1 under frame mount")
+
+		cfg.CameraGroupFrame = "table"
+		err = merged.Reconfigure(ctx, createDependencies([]camera.Camera{cam1, cam2}, fsService),
+			resource.Config{ConvertedAttributes: cfg})
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "frame table is not in the frame system")
+	})
+}
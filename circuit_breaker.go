@@ -0,0 +1,115 @@
+package main
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// defaultBreakerCooldown is how long an open circuit breaker skips its camera when no cooldown is configured.
+const defaultBreakerCooldown = 30 * time.Second
+
+const (
+	// breakerClosed is the state of a breaker whose camera is fetched as usual.
+	breakerClosed = "closed"
+	// breakerOpen is the state of a breaker whose camera is skipped until its cooldown has passed.
+	breakerOpen = "open"
+	// breakerHalfOpen is the state of a breaker whose cooldown has passed, so that the next merge probes its camera.
+	breakerHalfOpen = "half_open"
+)
+
+// CircuitBreakerConfig configures the circuit breaker of every camera. After FailureThreshold point cloud requests
+// in a row fail, the breaker opens and the camera is skipped, failing immediately, until Cooldown has passed. The
+// next merge then probes the camera once: a success closes the breaker and a failure opens it for another cooldown.
+type CircuitBreakerConfig struct {
+	FailureThreshold int `json:"failure_threshold"`
+	// Cooldown is how long an open breaker skips its camera, e.g. "10s". Defaults to 30s.
+	Cooldown string `json:"cooldown,omitempty"`
+}
+
+// Validate checks that the threshold and cooldown are positive.
+func (cfg *CircuitBreakerConfig) Validate() error {
+	if cfg.FailureThreshold <= 0 {
+		return errors.Errorf("failure_threshold must be positive, got %v", cfg.FailureThreshold)
+	}
+	_, err := cfg.cooldown()
+	return err
+}
+
+// cooldown returns the configured cooldown, or defaultBreakerCooldown when none is given.
+func (cfg *CircuitBreakerConfig) cooldown() (time.Duration, error) {
+	if cfg.Cooldown == "" {
+		return defaultBreakerCooldown, nil
+	}
+	cooldown, err := time.ParseDuration(cfg.Cooldown)
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid cooldown")
+	}
+	if cooldown <= 0 {
+		return 0, errors.Errorf("cooldown must be positive, got %v", cfg.Cooldown)
+	}
+	return cooldown, nil
+}
+
+// circuitBreaker holds the thresholds shared by the breakers of every camera. The state of each breaker is kept in
+// the cameraStatus of its camera.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+}
+
+// newCircuitBreaker returns the circuit breaker described by the config, nil when none is configured.
+func newCircuitBreaker(cfg *CircuitBreakerConfig) (*circuitBreaker, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	cooldown, err := cfg.cooldown()
+	if err != nil {
+		return nil, err
+	}
+	return &circuitBreaker{threshold: cfg.FailureThreshold, cooldown: cooldown}, nil
+}
+
+// state returns the state of the breaker of the camera with the given status at the given time.
+func (breaker *circuitBreaker) state(s *cameraStatus, now time.Time) string {
+	switch {
+	case s.consecutiveFailures < breaker.threshold:
+		return breakerClosed
+	case now.Before(s.breakerOpenUntil):
+		return breakerOpen
+	default:
+		return breakerHalfOpen
+	}
+}
+
+// record updates the breaker of the camera with the given status with the outcome of a point cloud request made at
+// the given time, reporting whether the breaker opened and whether it closed.
+func (breaker *circuitBreaker) record(s *cameraStatus, failed bool, now time.Time) (opened, closed bool) {
+	if !failed {
+		closed = s.consecutiveFailures >= breaker.threshold
+		s.consecutiveFailures = 0
+		s.breakerOpenUntil = time.Time{}
+		return false, closed
+	}
+	s.consecutiveFailures++
+	if s.consecutiveFailures < breaker.threshold {
+		return false, false
+	}
+	s.breakerOpenUntil = now.Add(breaker.cooldown)
+	return true, false
+}
+
+// breakerOpenFor returns until when the circuit breaker of the named camera skips it, or the zero time when it is
+// not open.
+func (merged *mergedCamera) breakerOpenFor(name string) time.Time {
+	if merged.breaker == nil {
+		return time.Time{}
+	}
+	merged.stateMu.Lock()
+	defer merged.stateMu.Unlock()
+	s, ok := merged.cameraStatuses[name]
+	if !ok || merged.breaker.state(s, time.Now()) != breakerOpen {
+		return time.Time{}
+	}
+	return s.breakerOpenUntil
+}
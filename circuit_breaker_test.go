@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/test"
+)
+
+func TestCircuitBreakerConfigValidate(t *testing.T) {
+	test.That(t, (&CircuitBreakerConfig{FailureThreshold: 3}).Validate(), test.ShouldBeNil)
+	test.That(t, (&CircuitBreakerConfig{FailureThreshold: 3, Cooldown: "5s"}).Validate(), test.ShouldBeNil)
+
+	err := (&CircuitBreakerConfig{}).Validate()
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "failure_threshold")
+	err = (&CircuitBreakerConfig{FailureThreshold: 3, Cooldown: "soon"}).Validate()
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "invalid cooldown")
+	err = (&CircuitBreakerConfig{FailureThreshold: 3, Cooldown: "-1s"}).Validate()
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "cooldown must be positive")
+
+	cfg := &Config{Cameras: []string{"cam1", "cam2"}, CircuitBreaker: &CircuitBreakerConfig{}}
+	_, err = cfg.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "invalid circuit_breaker")
+}
+
+func TestCircuitBreakerState(t *testing.T) {
+	breaker, err := newCircuitBreaker(&CircuitBreakerConfig{FailureThreshold: 2})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, breaker.cooldown, test.ShouldEqual, defaultBreakerCooldown)
+
+	now := time.Now()
+	s := &cameraStatus{}
+	opened, closed := breaker.record(s, true, now)
+	test.That(t, opened || closed, test.ShouldBeFalse)
+	test.That(t, breaker.state(s, now), test.ShouldEqual, breakerClosed)
+
+	opened, _ = breaker.record(s, true, now)
+	test.That(t, opened, test.ShouldBeTrue)
+	test.That(t, breaker.state(s, now), test.ShouldEqual, breakerOpen)
+	test.That(t, breaker.state(s, now.Add(defaultBreakerCooldown)), test.ShouldEqual, breakerHalfOpen)
+
+	// a failed probe opens the breaker for another cooldown
+	later := now.Add(defaultBreakerCooldown)
+	opened, _ = breaker.record(s, true, later)
+	test.That(t, opened, test.ShouldBeTrue)
+	test.That(t, breaker.state(s, later), test.ShouldEqual, breakerOpen)
+
+	_, closed = breaker.record(s, false, later)
+	test.That(t, closed, test.ShouldBeTrue)
+	test.That(t, breaker.state(s, later), test.ShouldEqual, breakerClosed)
+	test.That(t, s.consecutiveFailures, test.ShouldEqual, 0)
+
+	breaker, err = newCircuitBreaker(nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, breaker, test.ShouldBeNil)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	cam1 := newFakeCamera("cam1", r3.Vector{Z: 10})
+	cam2 := newFakeCamera("cam2", r3.Vector{X: 100, Z: 10})
+	cam2.setError(errors.New("unplugged"))
+	fsService, err := newFakeFrameSystemService(map[string]spatialmath.Pose{
+		"cam1": spatialmath.NewZeroPose(),
+		"cam2": spatialmath.NewZeroPose(),
+	})
+	test.That(t, err, test.ShouldBeNil)
+
+	cfg := &Config{
+		Cameras:        []string{"cam1", "cam2"},
+		ErrorPolicy:    errorPolicySkip,
+		CircuitBreaker: &CircuitBreakerConfig{FailureThreshold: 2, Cooldown: "100ms"},
+	}
+	merged := newTestMergedCamera(t, cfg, []camera.Camera{cam1, cam2}, fsService)
+	defer merged.Close(ctx)
+
+	cam2Status := func() map[string]interface{} {
+		resp, err := merged.DoCommand(ctx, map[string]interface{}{commandKey: statusCommand})
+		test.That(t, err, test.ShouldBeNil)
+		return resp["cameras"].([]interface{})[1].(map[string]interface{})
+	}
+
+	for i := 0; i < 2; i++ {
+		pc, err := merged.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 1)
+	}
+	test.That(t, cam2.fetchCount(), test.ShouldEqual, 2)
+	status := cam2Status()
+	test.That(t, status["circuit_breaker"], test.ShouldEqual, breakerOpen)
+	test.That(t, status["consecutive_failures"], test.ShouldEqual, 2)
+	test.That(t, status["breaker_open_until"], test.ShouldNotEqual, "")
+	test.That(t, status["last_error"], test.ShouldContainSubstring, "unplugged")
+
+	// while open the camera is not requested, so the merge does not wait on it
+	_, err = merged.NextPointCloud(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cam2.fetchCount(), test.ShouldEqual, 2)
+	test.That(t, cam2Status()["last_error"], test.ShouldContainSubstring, "unplugged")
+
+	// once the cooldown has passed the camera is probed and closes the breaker when it responds
+	time.Sleep(150 * time.Millisecond)
+	test.That(t, cam2Status()["circuit_breaker"], test.ShouldEqual, breakerHalfOpen)
+	cam2.setPoints(r3.Vector{X: 100, Z: 10})
+	pc, err := merged.NextPointCloud(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pc.Size(), test.ShouldEqual, 2)
+	test.That(t, cam2.fetchCount(), test.ShouldEqual, 3)
+	status = cam2Status()
+	test.That(t, status["circuit_breaker"], test.ShouldEqual, breakerClosed)
+	test.That(t, status["consecutive_failures"], test.ShouldEqual, 0)
+
+	// the strict policy fails merges while a breaker is open
+	cam2.setError(errors.New("unplugged"))
+	cfg.ErrorPolicy = errorPolicyStrict
+	strict := newTestMergedCamera(t, cfg, []camera.Camera{cam1, cam2}, fsService)
+	defer strict.Close(ctx)
+	for i := 0; i < 2; i++ {
+		_, err = strict.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+	}
+	_, err = strict.NextPointCloud(ctx)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "skipped by its circuit breaker")
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"image/color"
+	"sync"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/pointcloud"
+)
+
+// defaultCacheMaxPoints bounds the total number of points held by the merged cloud cache when no limit is
+// configured.
+const defaultCacheMaxPoints = 5000000
+
+// cachedCloud is a merged point cloud along with the time it was captured.
+type cachedCloud struct {
+	pc         pointcloud.PointCloud
+	capturedAt time.Time
+}
+
+// cloudCache keeps the most recent merged point clouds, evicting the oldest once either the number of clouds or
+// the total number of points exceeds its limits. It is safe for concurrent use.
+type cloudCache struct {
+	mu        sync.Mutex
+	maxClouds int
+	maxPoints int
+	clouds    []cachedCloud // oldest first
+	points    int
+}
+
+func newCloudCache(maxClouds, maxPoints int) *cloudCache {
+	return &cloudCache{maxClouds: maxClouds, maxPoints: maxPoints}
+}
+
+// add stores the cloud, evicting the oldest clouds as needed. A cloud larger than the point limit on its own is not
+// stored and false is returned.
+func (cache *cloudCache) add(pc pointcloud.PointCloud, capturedAt time.Time) bool {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if pc.Size() > cache.maxPoints {
+		return false
+	}
+	cache.clouds = append(cache.clouds, cachedCloud{pc: pc, capturedAt: capturedAt})
+	cache.points += pc.Size()
+	for len(cache.clouds) > cache.maxClouds || cache.points > cache.maxPoints {
+		cache.points -= cache.clouds[0].pc.Size()
+		cache.clouds[0] = cachedCloud{}
+		cache.clouds = cache.clouds[1:]
+	}
+	return true
+}
+
+// get returns the cloud at the given index, where zero is the most recent cloud.
+func (cache *cloudCache) get(index int) (cachedCloud, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if index < 0 || index >= len(cache.clouds) {
+		return cachedCloud{}, false
+	}
+	return cache.clouds[len(cache.clouds)-1-index], true
+}
+
+// closest returns the index and cloud whose capture time is closest to t.
+func (cache *cloudCache) closest(t time.Time) (int, cachedCloud, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	best := -1
+	var bestDiff time.Duration
+	for i, cloud := range cache.clouds {
+		diff := cloud.capturedAt.Sub(t)
+		if diff < 0 {
+			diff = -diff
+		}
+		if best < 0 || diff <= bestDiff {
+			best, bestDiff = i, diff
+		}
+	}
+	if best < 0 {
+		return 0, cachedCloud{}, false
+	}
+	return len(cache.clouds) - 1 - best, cache.clouds[best], true
+}
+
+// recent returns up to n of the most recent clouds captured within [since, until], oldest first. A zero since
+// leaves the start unbounded.
+func (cache *cloudCache) recent(n int, since, until time.Time) []cachedCloud {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	var clouds []cachedCloud
+	for i := len(cache.clouds) - 1; i >= 0 && len(clouds) < n; i-- {
+		cloud := cache.clouds[i]
+		// concurrent merges can cache clouds out of order, so clouds outside the window are skipped rather than
+		// ending the search
+		if cloud.capturedAt.After(until) || cloud.capturedAt.Before(since) {
+			continue
+		}
+		clouds = append(clouds, cloud)
+	}
+	for i, j := 0, len(clouds)-1; i < j; i, j = i+1, j-1 {
+		clouds[i], clouds[j] = clouds[j], clouds[i]
+	}
+	return clouds
+}
+
+// accumulateClouds returns the union of the clouds, given oldest first, so that where clouds share a position the
+// newest wins. The data of every point is copied so the result never shares data with the clouds.
+func accumulateClouds(clouds []cachedCloud) (pointcloud.PointCloud, error) {
+	total := 0
+	for _, cloud := range clouds {
+		total += cloud.pc.Size()
+	}
+	accumulated := pointcloud.NewWithPrealloc(total)
+	for _, cloud := range clouds {
+		var err error
+		cloud.pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+			err = accumulated.Set(p, copyData(d))
+			return err == nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return accumulated, nil
+}
+
+// len returns the number of cached clouds.
+func (cache *cloudCache) len() int {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	return len(cache.clouds)
+}
+
+// copyPointCloud returns a deep copy of the cloud, including the data of every point, so that changes made to
+// either cloud never show in the other. Octrees are copied into octrees.
+func copyPointCloud(pc pointcloud.PointCloud) (pointcloud.PointCloud, error) {
+	copied := pointcloud.NewWithPrealloc(pc.Size())
+	var err error
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		err = copied.Set(p, copyData(d))
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := pc.(*pointcloud.BasicOctree); ok {
+		return toOctree(copied, 0)
+	}
+	return copied, nil
+}
+
+// copyData returns a copy of the color, value and intensity of the point data.
+func copyData(d pointcloud.Data) pointcloud.Data {
+	if d == nil {
+		return nil
+	}
+	copied := pointcloud.NewBasicData()
+	if d.HasColor() {
+		r, g, b := d.RGB255()
+		copied.SetColor(color.NRGBA{R: r, G: g, B: b, A: 255})
+	}
+	if d.HasValue() {
+		copied.SetValue(d.Value())
+	}
+	copied.SetIntensity(d.Intensity())
+	return copied
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/test"
+)
+
+func TestCloudCache(t *testing.T) {
+	cloudOfSize := func(size int) pointcloud.PointCloud {
+		pc := pointcloud.New()
+		for i := 0; i < size; i++ {
+			test.That(t, pc.Set(r3.Vector{X: float64(i), Y: 0, Z: 0}, pointcloud.NewBasicData()), test.ShouldBeNil)
+		}
+		return pc
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("evicts the oldest clouds beyond the cloud limit", func(t *testing.T) {
+		cache := newCloudCache(2, 100)
+		for i := 1; i <= 3; i++ {
+			test.That(t, cache.add(cloudOfSize(i), start.Add(time.Duration(i)*time.Second)), test.ShouldBeTrue)
+		}
+		test.That(t, cache.len(), test.ShouldEqual, 2)
+		cloud, ok := cache.get(0)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, cloud.pc.Size(), test.ShouldEqual, 3)
+		cloud, ok = cache.get(1)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, cloud.pc.Size(), test.ShouldEqual, 2)
+		_, ok = cache.get(2)
+		test.That(t, ok, test.ShouldBeFalse)
+		_, ok = cache.get(-1)
+		test.That(t, ok, test.ShouldBeFalse)
+	})
+
+	t.Run("evicts the oldest clouds beyond the point limit", func(t *testing.T) {
+		cache := newCloudCache(10, 5)
+		test.That(t, cache.add(cloudOfSize(2), start), test.ShouldBeTrue)
+		test.That(t, cache.add(cloudOfSize(2), start), test.ShouldBeTrue)
+		test.That(t, cache.add(cloudOfSize(3), start), test.ShouldBeTrue)
+		test.That(t, cache.len(), test.ShouldEqual, 2)
+		test.That(t, cache.points, test.ShouldEqual, 5)
+
+		test.That(t, cache.add(cloudOfSize(6), start), test.ShouldBeFalse)
+		test.That(t, cache.len(), test.ShouldEqual, 2)
+	})
+
+	t.Run("finds the cloud closest to a time", func(t *testing.T) {
+		cache := newCloudCache(10, 100)
+		_, _, ok := cache.closest(start)
+		test.That(t, ok, test.ShouldBeFalse)
+		for i := 0; i < 3; i++ {
+			test.That(t, cache.add(cloudOfSize(i+1), start.Add(time.Duration(i)*time.Second)), test.ShouldBeTrue)
+		}
+		index, cloud, ok := cache.closest(start.Add(900 * time.Millisecond))
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, index, test.ShouldEqual, 1)
+		test.That(t, cloud.pc.Size(), test.ShouldEqual, 2)
+		index, _, ok = cache.closest(start.Add(time.Hour))
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, index, test.ShouldEqual, 0)
+	})
+
+	t.Run("returns the most recent clouds within a window", func(t *testing.T) {
+		cache := newCloudCache(10, 100)
+		for i := 0; i < 5; i++ {
+			test.That(t, cache.add(cloudOfSize(i+1), start.Add(time.Duration(i)*time.Second)), test.ShouldBeTrue)
+		}
+		sizes := func(clouds []cachedCloud) []int {
+			var sizes []int
+			for _, cloud := range clouds {
+				sizes = append(sizes, cloud.pc.Size())
+			}
+			return sizes
+		}
+		test.That(t, sizes(cache.recent(3, time.Time{}, start.Add(time.Hour))), test.ShouldResemble, []int{3, 4, 5})
+		test.That(t, sizes(cache.recent(3, time.Time{}, start.Add(2*time.Second))), test.ShouldResemble, []int{1, 2, 3})
+		test.That(t, sizes(cache.recent(10, start.Add(3*time.Second), start.Add(time.Hour))), test.ShouldResemble, []int{4, 5})
+		test.That(t, cache.recent(3, time.Time{}, start.Add(-time.Second)), test.ShouldBeEmpty)
+	})
+}
+
+func TestAccumulateClouds(t *testing.T) {
+	older := pointcloud.New()
+	test.That(t, older.Set(r3.Vector{X: 1}, pointcloud.NewValueData(1)), test.ShouldBeNil)
+	test.That(t, older.Set(r3.Vector{X: 2}, pointcloud.NewValueData(1)), test.ShouldBeNil)
+	newer := pointcloud.New()
+	test.That(t, newer.Set(r3.Vector{X: 2}, pointcloud.NewValueData(2)), test.ShouldBeNil)
+	test.That(t, newer.Set(r3.Vector{X: 3}, pointcloud.NewValueData(2)), test.ShouldBeNil)
+
+	accumulated, err := accumulateClouds([]cachedCloud{{pc: older}, {pc: newer}})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, accumulated.Size(), test.ShouldEqual, 3)
+	d, ok := accumulated.At(1, 0, 0)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.Value(), test.ShouldEqual, 1)
+	// the newest cloud wins a shared position
+	d, ok = accumulated.At(2, 0, 0)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.Value(), test.ShouldEqual, 2)
+
+	// the accumulated data is a copy
+	d.SetValue(5)
+	d, _ = newer.At(2, 0, 0)
+	test.That(t, d.Value(), test.ShouldEqual, 2)
+}
+
+func TestCopyPointCloud(t *testing.T) {
+	pc := pointcloud.New()
+	colored := pointcloud.NewColoredData(color.NRGBA{R: 10, G: 20, B: 30, A: 255})
+	colored.SetIntensity(40)
+	test.That(t, pc.Set(r3.Vector{X: 1, Y: 2, Z: 3}, colored), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 4, Y: 5, Z: 6}, pointcloud.NewValueData(2)), test.ShouldBeNil)
+
+	copied, err := copyPointCloud(pc)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, copied.Size(), test.ShouldEqual, 2)
+	d, ok := copied.At(1, 2, 3)
+	test.That(t, ok, test.ShouldBeTrue)
+	r, g, b := d.RGB255()
+	test.That(t, []uint8{r, g, b}, test.ShouldResemble, []uint8{10, 20, 30})
+	test.That(t, d.Intensity(), test.ShouldEqual, 40)
+	test.That(t, d.HasValue(), test.ShouldBeFalse)
+	d, ok = copied.At(4, 5, 6)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.Value(), test.ShouldEqual, 2)
+
+	// changes to the original never show in the copy
+	test.That(t, pc.Set(r3.Vector{X: 7, Y: 8, Z: 9}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	colored.SetColor(color.NRGBA{A: 255})
+	test.That(t, copied.Size(), test.ShouldEqual, 2)
+	d, _ = copied.At(1, 2, 3)
+	r, _, _ = d.RGB255()
+	test.That(t, r, test.ShouldEqual, 10)
+
+	octree, err := toOctree(pc, 0)
+	test.That(t, err, test.ShouldBeNil)
+	copied, err = copyPointCloud(octree)
+	test.That(t, err, test.ShouldBeNil)
+	_, ok = copied.(*pointcloud.BasicOctree)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, copied.Size(), test.ShouldEqual, 3)
+}
@@ -0,0 +1,712 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"os"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+)
+
+const (
+	commandKey                 = "command"
+	statusCommand              = "status"
+	clearTransformCacheCommand = "clear_transform_cache"
+	geometriesCommand          = "geometries"
+	metricsCommand             = "metrics"
+	exportPLYCommand           = "export_ply"
+	pathKey                    = "path"
+	captureBatchCommand        = "capture_batch"
+	countKey                   = "count"
+	readyCommand               = "ready"
+	getCachedCommand           = "get_cached"
+	transformsCommand          = "transforms"
+	indexKey                   = "index"
+	capturedAtKey              = "captured_at"
+	boundsCommand              = "bounds"
+	setEnabledCommand          = "set_enabled"
+	cameraKey                  = "camera"
+	enabledKey                 = "enabled"
+	countCommand               = "count"
+	nextSubsetCommand          = "next_subset"
+	camerasKey                 = "cameras"
+	frameCommand               = "frame"
+	checkOverlapCommand        = "check_overlap"
+	maxDistanceKey             = "max_distance_mm"
+	fingerprintCommand         = "fingerprint"
+	voxelSizeKey               = "voxel_size_mm"
+	lastPipelineCommand        = "last_pipeline"
+	nextInFrameCommand         = "next_in_frame"
+	frameNameKey               = "frame"
+)
+
+// cameraStatus tracks the outcome of the most recent point cloud request made to a camera.
+type cameraStatus struct {
+	supportsPCD    bool
+	lastPointCount int
+	lastCapturedAt time.Time
+	lastError      error
+	// consecutiveEmpty counts the successful requests in a row that returned no points.
+	consecutiveEmpty int
+	// consecutiveFailures counts the failed requests in a row, and breakerOpenUntil is when the circuit breaker
+	// opened by them lets the camera be probed again. Both are only tracked with a circuit breaker.
+	consecutiveFailures int
+	breakerOpenUntil    time.Time
+}
+
+// DoCommand supports the following commands:
+//   - "status" returns the state of each camera as observed during the most recent merge, including how many
+//     point clouds in a row it returned without any points, its configured latency offset and whether its point
+//     clouds are projected from depth images. With a circuit_breaker, it also reports the state of the camera's
+//     breaker, "closed", "open" or "half_open", its failures in a row and until when an open breaker skips it.
+//   - "clear_transform_cache" forces transforms to be resolved through the frame system on the next merge.
+//   - "metrics" returns point counts and timings of the most recent merge along with min/avg/max timings over
+//     recent merges. Timings are in milliseconds. It also estimates the bytes held by the points of the most recent
+//     merged cloud from the data they carry and reports the peak estimate over recent merges.
+//   - "export_ply" writes the most recent merged point cloud as a PLY file to the optional "path", or returns
+//     it base64 encoded under "ply" when no path is given.
+//   - "capture_batch" merges "count" point clouds back to back, at most maxCacheSize, and returns the capture
+//     time and size of each. The last one can then be exported with "export_ply".
+//   - "geometries" returns the geometries of the source camera frames expressed in the target frame.
+//   - "get_cached" returns a cached merged point cloud, encoded like "export_ply", when enable_cache is set. The
+//     cloud is selected by "index", zero being the most recent, or by the RFC3339 "captured_at" time closest to
+//     its capture. The most recent cloud is returned when neither is given.
+//   - "transforms" returns the pose of every camera in the target frame as used for merging, looked up without
+//     requesting point clouds or touching the transform cache, so it works before cameras produce data.
+//   - "bounds" returns the axis aligned min and max, point count and centroid of the most recent merged point
+//     cloud as returned by NextPointCloud. They come from the metadata kept by the cloud, so nothing is recomputed.
+//   - "set_enabled" enables or disables the "camera", given by the name reported by "status", according to the
+//     boolean "enabled". Disabled cameras are not merged until they are enabled again or the merged camera is
+//     reconfigured, which enables every camera.
+//   - "count" fetches the point cloud of every enabled camera, filters it like a merge would and returns the sum
+//     of their sizes under "points", alongside the size of each, capped at max_points. Nothing is transformed or
+//     merged, so the stages of the merged cloud that add or remove points are not applied: crop_box, priority,
+//     dedup_resolution, outlier_removal, min_cluster_size, voxel_size, target_density, ground_removal, the
+//     octree_resolution of an octree and accumulate_frames. The configured ones are listed under
+//     "excluded_stages", and when any is configured or the cap applies the total is only an estimate, which is
+//     flagged by "estimate". Under the strict error policy, failed cameras fail the count with a *MergeError like
+//     a merge would.
+//   - "next_subset" merges only the "cameras", a list of names as reported by "status", and returns the merged
+//     cloud encoded like "export_ply" along with its "captured_at" time. The cloud is not kept for "export_ply",
+//     "bounds" or the cache, which only hold merges of every camera.
+//   - "next_in_frame" merges every enabled camera into the "frame", any frame of the frame system, instead of the
+//     target frame and returns the merged cloud encoded like "export_ply" along with its "captured_at" time. Only
+//     this merge uses the frame, and its transforms are never cached, so the frame can be a moving object. The crop
+//     box and ground are still configured in the target frame, and the cloud is not kept like "next_subset".
+//   - "frame" returns the name of the target frame the merged points are expressed in and whether an output_offset
+//     is applied on top of it, along with that offset, whether ros_frame converts the points to the ROS axis
+//     convention after the offset and the "output_units" of the points. Properties has no field for a frame, so
+//     this is the only way for a client to learn it.
+//   - "check_overlap" fetches and transforms the point cloud of every enabled camera and, for every pair of cameras,
+//     reports the mean distance in millimeters from the points where they overlap to their nearest neighbor in the
+//     other camera's cloud. Points with no neighbor within the optional "max_distance_mm", 50 by default, do not
+//     count as overlapping. Well calibrated cameras agree to within their noise, so a high mean flags drift.
+//   - "fingerprint" returns a fingerprint of the most recent merged point cloud, a hash of its point count and of
+//     which voxels of the optional "voxel_size_mm", 10 by default, hold points, along with those counts. Identical
+//     clouds always have the same fingerprint and a change in the occupied voxels changes it, so a client can skip
+//     scenes it has already processed. It is a heuristic rather than a hash of every point: points moving within
+//     their voxel or changing color go unnoticed.
+//   - "last_pipeline" returns the stages the most recent merged point cloud went through, from fetching and merging
+//     to every configured filter in the order they ran, with the points each stage received and kept and how long
+//     it took in milliseconds, along with the points and timings of every camera. A stage ends when the next starts,
+//     so the stage durations add up to "total_ms".
+//   - "ready" reports whether every camera responds and can be transformed into the target frame, without
+//     requesting point clouds or touching the transform cache.
+func (merged *mergedCamera) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	command, ok := cmd[commandKey].(string)
+	if !ok {
+		return nil, errors.Errorf("missing %q field of type string", commandKey)
+	}
+
+	switch command {
+	case statusCommand:
+		return merged.status(), nil
+	case clearTransformCacheCommand:
+		merged.mu.RLock()
+		defer merged.mu.RUnlock()
+		if merged.transformCache != nil {
+			merged.transformCache.clear()
+		}
+		return map[string]interface{}{}, nil
+	case metricsCommand:
+		return merged.metricsResponse(), nil
+	case exportPLYCommand:
+		return merged.exportPLY(cmd)
+	case captureBatchCommand:
+		return merged.captureBatch(ctx, cmd)
+	case getCachedCommand:
+		return merged.getCached(cmd)
+	case transformsCommand:
+		return merged.transforms(ctx)
+	case readyCommand:
+		return merged.ready(ctx), nil
+	case boundsCommand:
+		return merged.bounds()
+	case setEnabledCommand:
+		return merged.setEnabled(cmd)
+	case countCommand:
+		return merged.countPoints(ctx)
+	case nextSubsetCommand:
+		return merged.nextSubset(ctx, cmd)
+	case frameCommand:
+		return merged.frame()
+	case checkOverlapCommand:
+		return merged.checkOverlap(ctx, cmd)
+	case fingerprintCommand:
+		return merged.fingerprint(cmd)
+	case nextInFrameCommand:
+		return merged.nextInFrame(ctx, cmd)
+	case lastPipelineCommand:
+		return merged.lastPipeline()
+	case geometriesCommand:
+		geometries, err := merged.geometries(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return geometriesResponse(geometries)
+	default:
+		return nil, errors.Errorf("unknown command %q", command)
+	}
+}
+
+// status reports the state of every configured camera.
+func (merged *mergedCamera) status() map[string]interface{} {
+	merged.mu.RLock()
+	defer merged.mu.RUnlock()
+	merged.stateMu.Lock()
+	defer merged.stateMu.Unlock()
+
+	now := time.Now()
+	cameras := make([]interface{}, 0, len(merged.cameras))
+	for i, cam := range merged.cameras {
+		name := cam.Name().ShortName()
+		camStatus := map[string]interface{}{"name": name}
+		latencyOffset := time.Duration(0)
+		if merged.latencyOffsets != nil {
+			latencyOffset = merged.latencyOffsets[i]
+		}
+		camStatus["latency_offset_ms"] = milliseconds(latencyOffset)
+		camStatus["depth_projection"] = merged.depthIntrinsics != nil && merged.depthIntrinsics[i] != nil
+		camStatus["enabled"] = !merged.disabledCameras[name]
+		if s, ok := merged.cameraStatuses[name]; ok {
+			camStatus["supports_pcd"] = s.supportsPCD
+			camStatus["last_point_count"] = s.lastPointCount
+			lastError := ""
+			if s.lastError != nil {
+				lastError = s.lastError.Error()
+			}
+			camStatus["last_error"] = lastError
+			camStatus["last_captured_at"] = formatTime(s.lastCapturedAt)
+			camStatus["consecutive_empty"] = s.consecutiveEmpty
+			if merged.breaker != nil {
+				camStatus["circuit_breaker"] = merged.breaker.state(s, now)
+				camStatus["consecutive_failures"] = s.consecutiveFailures
+				camStatus["breaker_open_until"] = formatTime(s.breakerOpenUntil)
+			}
+		}
+		cameras = append(cameras, camStatus)
+	}
+	return map[string]interface{}{
+		"cameras":          cameras,
+		"last_captured_at": formatTime(merged.lastCapturedAt),
+	}
+}
+
+// ready checks that every camera responds to a properties request and that its pose in the target frame can be
+// resolved. Transforms are always looked up through the frame system so a stale cached transform cannot hide a
+// broken frame system.
+func (merged *mergedCamera) ready(ctx context.Context) map[string]interface{} {
+	merged.mu.RLock()
+	defer merged.mu.RUnlock()
+
+	notReady := func(reason string) map[string]interface{} {
+		return map[string]interface{}{"ready": false, "error": reason, "cameras": []interface{}{}}
+	}
+	if merged.closed {
+		return notReady(ErrClosed.Error())
+	}
+	if merged.fsService == nil {
+		return notReady("frame system service is not set")
+	}
+
+	allReady := true
+	cameras := make([]interface{}, 0, len(merged.cameras))
+	for _, cam := range merged.cameras {
+		name := cam.Name().ShortName()
+		err := merged.checkReady(ctx, cam)
+		errString := ""
+		if err != nil {
+			allReady = false
+			errString = err.Error()
+		}
+		cameras = append(cameras, map[string]interface{}{"name": name, "ready": err == nil, "error": errString})
+	}
+	return map[string]interface{}{"ready": allReady, "error": "", "cameras": cameras}
+}
+
+// checkReady returns why the camera cannot currently be merged, if anything.
+func (merged *mergedCamera) checkReady(ctx context.Context, cam camera.Camera) error {
+	name := cam.Name().ShortName()
+	if _, err := checkCamera(ctx, cam, name, merged.allowDepthProjection); err != nil {
+		return err
+	}
+	_, _, err := merged.lookupTransform(ctx, name)
+	return err
+}
+
+// transforms reports the pose of every camera's frame in the target frame. Cameras whose transform cannot be
+// resolved are reported with an error rather than failing the command.
+func (merged *mergedCamera) transforms(ctx context.Context) (map[string]interface{}, error) {
+	merged.mu.RLock()
+	defer merged.mu.RUnlock()
+	if merged.closed {
+		return nil, errors.Wrap(ErrClosed, "cannot get transforms")
+	}
+	if merged.fsService == nil {
+		return nil, errors.New("frame system service is not set")
+	}
+
+	cameras := make([]interface{}, 0, len(merged.cameras))
+	for _, cam := range merged.cameras {
+		name := cam.Name().ShortName()
+		camTransform := map[string]interface{}{"name": name, "error": ""}
+		pose, source, err := merged.lookupTransform(ctx, name)
+		if err != nil {
+			camTransform["error"] = err.Error()
+		} else {
+			camTransform["source"] = source
+			camTransform["translation"] = vectorMap(pose.Point())
+			camTransform["orientation"] = orientationMap(pose.Orientation())
+		}
+		cameras = append(cameras, camTransform)
+	}
+	return map[string]interface{}{"target_frame": merged.targetFrame, "cameras": cameras}, nil
+}
+
+// frame reports the target frame of the merged point cloud, the output offset applied to it, if any, whether the
+// points are converted to the ROS axis convention and their units.
+func (merged *mergedCamera) frame() (map[string]interface{}, error) {
+	merged.mu.RLock()
+	defer merged.mu.RUnlock()
+	if merged.closed {
+		return nil, errors.Wrap(ErrClosed, "cannot get frame")
+	}
+
+	resp := map[string]interface{}{
+		"frame":                 merged.targetFrame,
+		"output_offset_applied": merged.outputOffset != nil,
+		"ros_frame":             merged.rosFrame,
+		"output_units":          outputUnitsMillimeters,
+	}
+	if merged.outputScale > 0 {
+		resp["output_units"] = outputUnitsMeters
+	}
+	if merged.outputOffset != nil {
+		resp["output_offset"] = map[string]interface{}{
+			"translation": vectorMap(merged.outputOffset.Point()),
+			"orientation": orientationMap(merged.outputOffset.Orientation()),
+		}
+	}
+	return resp, nil
+}
+
+// lookupTransform resolves the pose of the camera frame in the target frame from its pose override or otherwise
+// the frame system, bypassing the transform cache. It also returns which of the two the pose came from.
+func (merged *mergedCamera) lookupTransform(ctx context.Context, name string) (spatialmath.Pose, string, error) {
+	if pose, ok := merged.poseOverrides[name]; ok {
+		return pose, "pose_override", nil
+	}
+	origin := referenceframe.NewPoseInFrame(name, spatialmath.NewZeroPose())
+	transformed, err := merged.fsService.TransformPose(ctx, origin, merged.targetFrame, nil)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "issue getting tranform from camera %v to target frame %v", name, merged.targetFrame)
+	}
+	return transformed.Pose(), "frame_system", nil
+}
+
+// formatTime formats the time for DoCommand responses, using an empty string for the zero time.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(timeFormat)
+}
+
+// metricsResponse reports the point counts and timings of every configured camera and of the merge itself.
+func (merged *mergedCamera) metricsResponse() map[string]interface{} {
+	merged.mu.RLock()
+	defer merged.mu.RUnlock()
+	merged.stateMu.Lock()
+	defer merged.stateMu.Unlock()
+
+	metrics := merged.metrics
+	if metrics == nil {
+		metrics = newMergeMetrics()
+	}
+	cameras := make([]interface{}, 0, len(merged.cameras))
+	for _, cam := range merged.cameras {
+		name := cam.Name().ShortName()
+		camMetrics, ok := metrics.cameras[name]
+		if !ok {
+			camMetrics = &cameraMetrics{}
+		}
+		cameras = append(cameras, map[string]interface{}{
+			"name":         name,
+			"point_count":  camMetrics.pointCount,
+			"fetch_ms":     camMetrics.fetch.summary(),
+			"transform_ms": camMetrics.transform.summary(),
+		})
+	}
+	return map[string]interface{}{
+		"cameras":              cameras,
+		"merge_ms":             metrics.merge.summary(),
+		"point_count":          metrics.pointCount,
+		"estimated_bytes":      metrics.estimatedBytes,
+		"peak_estimated_bytes": metrics.sizes.peak(),
+	}
+}
+
+// exportPLY encodes the most recent merged point cloud as PLY, writing it to the path given in the command if any.
+func (merged *mergedCamera) exportPLY(cmd map[string]interface{}) (map[string]interface{}, error) {
+	merged.mu.RLock()
+	merged.stateMu.Lock()
+	pc := merged.lastPointCloud
+	merged.stateMu.Unlock()
+	merged.mu.RUnlock()
+	if pc == nil {
+		return nil, errors.New("no merged point cloud has been produced yet")
+	}
+	return plyResponse(pc, cmd)
+}
+
+// setEnabled enables or disables merging of a camera.
+func (merged *mergedCamera) setEnabled(cmd map[string]interface{}) (map[string]interface{}, error) {
+	name, ok := cmd[cameraKey].(string)
+	if !ok {
+		return nil, errors.Errorf("missing %q field of type string", cameraKey)
+	}
+	enabled, ok := cmd[enabledKey].(bool)
+	if !ok {
+		return nil, errors.Errorf("missing %q field of type bool", enabledKey)
+	}
+
+	merged.mu.RLock()
+	defer merged.mu.RUnlock()
+	known := false
+	for _, cam := range merged.cameras {
+		known = known || cam.Name().ShortName() == name
+	}
+	if !known {
+		return nil, errors.Errorf("unknown camera %v", name)
+	}
+
+	merged.stateMu.Lock()
+	defer merged.stateMu.Unlock()
+	if enabled {
+		delete(merged.disabledCameras, name)
+		merged.logger.Infof("enabled camera %v", name)
+	} else {
+		if merged.disabledCameras == nil {
+			merged.disabledCameras = make(map[string]bool)
+		}
+		merged.disabledCameras[name] = true
+		merged.logger.Infof("disabled camera %v", name)
+	}
+	return map[string]interface{}{cameraKey: name, enabledKey: enabled}, nil
+}
+
+// bounds reports the extent of the most recent merged point cloud. The min, max and centroid are omitted when the
+// cloud is empty.
+func (merged *mergedCamera) bounds() (map[string]interface{}, error) {
+	merged.mu.RLock()
+	defer merged.mu.RUnlock()
+	merged.stateMu.Lock()
+	pc, capturedAt := merged.lastPointCloud, merged.lastCapturedAt
+	merged.stateMu.Unlock()
+	if pc == nil {
+		return nil, errors.New("no merged point cloud has been produced yet")
+	}
+
+	resp := map[string]interface{}{
+		"target_frame": merged.targetFrame,
+		"captured_at":  formatTime(capturedAt),
+		"points":       pc.Size(),
+	}
+	if pc.Size() == 0 {
+		return resp, nil
+	}
+	meta := pc.MetaData()
+	n := float64(pc.Size())
+	resp["min"] = vectorMap(r3.Vector{X: meta.MinX, Y: meta.MinY, Z: meta.MinZ})
+	resp["max"] = vectorMap(r3.Vector{X: meta.MaxX, Y: meta.MaxY, Z: meta.MaxZ})
+	resp["centroid"] = vectorMap(r3.Vector{X: meta.TotalX() / n, Y: meta.TotalY() / n, Z: meta.TotalZ() / n})
+	return resp, nil
+}
+
+// countPoints sums the sizes of the filtered source point clouds without merging them. Failing cameras are handled
+// according to the error policy.
+func (merged *mergedCamera) countPoints(ctx context.Context) (map[string]interface{}, error) {
+	merged.mu.RLock()
+	defer merged.mu.RUnlock()
+	if merged.closed {
+		return nil, errors.Wrap(ErrClosed, "cannot count points")
+	}
+
+	results := merged.fetchPointClouds(ctx, nil)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, errors.New("every camera is disabled")
+	}
+	if merged.errorPolicy != errorPolicySkip {
+		if mergeErr := newMergeError(results); mergeErr != nil {
+			return nil, mergeErr
+		}
+	}
+
+	total := 0
+	succeeded := 0
+	cameras := make([]interface{}, 0, len(results))
+	for _, result := range results {
+		camCount := map[string]interface{}{"name": result.name, "points": 0, "error": ""}
+		if result.err != nil {
+			camCount["error"] = result.err.Error()
+		} else {
+			camCount["points"] = result.pc.Size()
+			total += result.pc.Size()
+			succeeded++
+		}
+		cameras = append(cameras, camCount)
+	}
+	if succeeded == 0 {
+		return nil, errors.Wrap(ErrNoCameraData, "cannot count points")
+	}
+
+	excluded := merged.uncountedStages()
+	estimate := len(excluded) > 0
+	// max_points caps every merge before frames are accumulated
+	if merged.maxPoints > 0 && total > merged.maxPoints {
+		total = merged.maxPoints
+		estimate = true
+	}
+	return map[string]interface{}{
+		"points":          total,
+		"estimate":        estimate,
+		"excluded_stages": excluded,
+		"cameras":         cameras,
+	}, nil
+}
+
+// uncountedStages lists the configured stages of the merge that change the number of points but are not applied by
+// "count", in the order they run. The caller must hold mu.
+func (merged *mergedCamera) uncountedStages() []interface{} {
+	stages := []struct {
+		name       string
+		configured bool
+	}{
+		{"crop_box", merged.cropBox != nil},
+		{"priority", merged.priorities != nil},
+		{"dedup_resolution", merged.dedupResolution > 0},
+		{"outlier_removal", merged.outlierFilter != nil},
+		{"min_cluster_size", merged.minClusterSize > 0},
+		{"voxel_size", merged.voxelSize > 0},
+		{"target_density", merged.targetDensity > 0},
+		{"ground_removal", merged.groundRemoval != nil},
+		{"octree_resolution", merged.outputStructure == outputStructureOctree && merged.octreeResolution > 0},
+		{"accumulate_frames", merged.accumulateFrames > 1 && merged.cloudCache != nil},
+	}
+	excluded := make([]interface{}, 0, len(stages))
+	for _, stage := range stages {
+		if stage.configured {
+			excluded = append(excluded, stage.name)
+		}
+	}
+	return excluded
+}
+
+// nextSubset merges the cameras listed in the command and encodes the merged cloud as PLY.
+func (merged *mergedCamera) nextSubset(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	names, ok := cmd[camerasKey].([]interface{})
+	if !ok || len(names) == 0 {
+		return nil, errors.Errorf("missing %q field of type non-empty list", camerasKey)
+	}
+	merged.mu.RLock()
+	known := make(map[string]bool, len(merged.cameras))
+	for _, cam := range merged.cameras {
+		known[cam.Name().ShortName()] = true
+	}
+	merged.mu.RUnlock()
+	subset := make(map[string]bool, len(names))
+	for _, n := range names {
+		name, ok := n.(string)
+		if !ok {
+			return nil, errors.Errorf("%q field must only contain strings", camerasKey)
+		}
+		if !known[name] {
+			return nil, errors.Errorf("unknown camera %v", name)
+		}
+		subset[name] = true
+	}
+
+	pc, capturedAt, err := merged.mergeCameras(ctx, subset, "")
+	if err != nil {
+		return nil, err
+	}
+	resp, err := plyResponse(pc, cmd)
+	if err != nil {
+		return nil, err
+	}
+	resp[capturedAtKey] = formatTime(capturedAt)
+	return resp, nil
+}
+
+// nextInFrame encodes a merge of every enabled camera into the frame named by the command as PLY.
+func (merged *mergedCamera) nextInFrame(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	frame, ok := cmd[frameNameKey].(string)
+	if !ok || frame == "" {
+		return nil, errors.Errorf("missing %q field of type non-empty string", frameNameKey)
+	}
+	merged.mu.RLock()
+	closed := merged.closed
+	var err error
+	if !closed {
+		err = merged.checkFrameExists(ctx, frame)
+	}
+	merged.mu.RUnlock()
+	if closed {
+		return nil, errors.Wrap(ErrClosed, "cannot get next point cloud")
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid frame %v", frame)
+	}
+
+	pc, capturedAt, err := merged.mergeCameras(ctx, nil, frame)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := plyResponse(pc, cmd)
+	if err != nil {
+		return nil, err
+	}
+	resp[capturedAtKey] = formatTime(capturedAt)
+	resp[frameNameKey] = frame
+	return resp, nil
+}
+
+// getCached encodes the cached merged point cloud selected by the command as PLY.
+func (merged *mergedCamera) getCached(cmd map[string]interface{}) (map[string]interface{}, error) {
+	merged.mu.RLock()
+	cache := merged.cloudCache
+	merged.mu.RUnlock()
+	if cache == nil {
+		return nil, errors.New("caching is disabled, set enable_cache to cache merged point clouds")
+	}
+
+	var index int
+	var cloud cachedCloud
+	var ok bool
+	if capturedAt, hasTime := cmd[capturedAtKey]; hasTime {
+		capturedAtStr, isString := capturedAt.(string)
+		if !isString {
+			return nil, errors.Errorf("%q field must be a string", capturedAtKey)
+		}
+		t, err := time.Parse(timeFormat, capturedAtStr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %q", capturedAtKey)
+		}
+		index, cloud, ok = cache.closest(t)
+	} else {
+		switch i := cmd[indexKey].(type) {
+		case nil:
+		case float64:
+			index = int(i)
+		case int:
+			index = i
+		default:
+			return nil, errors.Errorf("%q field must be a number", indexKey)
+		}
+		cloud, ok = cache.get(index)
+	}
+	if !ok {
+		return nil, errors.Errorf("no cached point cloud at index %v, %v are cached", index, cache.len())
+	}
+
+	resp, err := plyResponse(cloud.pc, cmd)
+	if err != nil {
+		return nil, err
+	}
+	resp[indexKey] = index
+	resp[capturedAtKey] = formatTime(cloud.capturedAt)
+	return resp, nil
+}
+
+// plyResponse encodes the cloud as PLY, writing it to the path given in the command if any and otherwise returning
+// it base64 encoded.
+func plyResponse(pc pointcloud.PointCloud, cmd map[string]interface{}) (map[string]interface{}, error) {
+	path, hasPath := cmd[pathKey]
+	if !hasPath {
+		var buf bytes.Buffer
+		if err := writePLY(pc, &buf); err != nil {
+			return nil, errors.Wrap(err, "error encoding PLY")
+		}
+		return map[string]interface{}{"ply": base64.StdEncoding.EncodeToString(buf.Bytes()), "points": pc.Size()}, nil
+	}
+
+	pathStr, ok := path.(string)
+	if !ok || pathStr == "" {
+		return nil, errors.Errorf("%q field must be a non-empty string", pathKey)
+	}
+	f, err := os.Create(pathStr)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating PLY file")
+	}
+	if err := writePLY(pc, f); err != nil {
+		return nil, multierr.Append(errors.Wrap(err, "error writing PLY file"), f.Close())
+	}
+	if err := f.Close(); err != nil {
+		return nil, errors.Wrap(err, "error closing PLY file")
+	}
+	return map[string]interface{}{pathKey: pathStr, "points": pc.Size()}, nil
+}
+
+// captureBatch merges the requested number of point clouds in a row, stopping early if the context is cancelled.
+// Transforms cached by the first merge are reused by the rest of the batch.
+func (merged *mergedCamera) captureBatch(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	var count int
+	switch c := cmd[countKey].(type) {
+	case float64:
+		count = int(c)
+	case int:
+		count = c
+	default:
+		return nil, errors.Errorf("missing %q field of type number", countKey)
+	}
+	if count < 1 || count > maxCacheSize {
+		return nil, errors.Errorf("%q must be between 1 and %v, got %v", countKey, maxCacheSize, count)
+	}
+
+	captures := make([]interface{}, 0, count)
+	for i := 0; i < count; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, errors.Wrapf(err, "capture batch interrupted after %v of %v point clouds", i, count)
+		}
+		pc, capturedAt, err := merged.nextMergedPointCloud(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error capturing point cloud %v of %v", i+1, count)
+		}
+		captures = append(captures, map[string]interface{}{
+			"captured_at": formatTime(capturedAt),
+			"point_count": pc.Size(),
+		})
+	}
+	return map[string]interface{}{"captures": captures}, nil
+}
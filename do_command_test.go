@@ -0,0 +1,489 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/test"
+)
+
+func TestDoCommand(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	goodCam := createMockCamera("cam1", []r3.Vector{{X: 0, Y: 1, Z: 2}, {X: 0, Y: 0, Z: 2}})
+	badCam := createFailingCamera("cam2")
+	cameras := []camera.Camera{goodCam, badCam}
+
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+
+	mergedCam := &mergedCamera{logger: logger}
+	conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}, ErrorPolicy: errorPolicySkip}}
+	test.That(t, mergedCam.Reconfigure(ctx, createDependencies(cameras, fsService), conf), test.ShouldBeNil)
+
+	t.Run("status", func(t *testing.T) {
+		_, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+
+		resp, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: statusCommand})
+		test.That(t, err, test.ShouldBeNil)
+		statuses := resp["cameras"].([]interface{})
+		test.That(t, len(statuses), test.ShouldEqual, 2)
+
+		status1 := statuses[0].(map[string]interface{})
+		test.That(t, status1["name"], test.ShouldEqual, "cam1")
+		test.That(t, status1["supports_pcd"], test.ShouldBeTrue)
+		test.That(t, status1["last_point_count"], test.ShouldEqual, 2)
+		test.That(t, status1["last_error"], test.ShouldEqual, "")
+		test.That(t, status1["consecutive_empty"], test.ShouldEqual, 0)
+		_, err = time.Parse(timeFormat, status1["last_captured_at"].(string))
+		test.That(t, err, test.ShouldBeNil)
+
+		status2 := statuses[1].(map[string]interface{})
+		test.That(t, status2["name"], test.ShouldEqual, "cam2")
+		test.That(t, status2["last_point_count"], test.ShouldEqual, 0)
+		test.That(t, status2["last_error"], test.ShouldContainSubstring, "camera failure")
+		test.That(t, status2["last_captured_at"], test.ShouldEqual, "")
+
+		test.That(t, resp["last_captured_at"], test.ShouldEqual, status1["last_captured_at"])
+	})
+
+	t.Run("clear_transform_cache", func(t *testing.T) {
+		_, ok := mergedCam.transformCache.get("cam1", "cam1")
+		test.That(t, ok, test.ShouldBeTrue)
+
+		_, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: clearTransformCacheCommand})
+		test.That(t, err, test.ShouldBeNil)
+		_, ok = mergedCam.transformCache.get("cam1", "cam1")
+		test.That(t, ok, test.ShouldBeFalse)
+	})
+
+	t.Run("metrics", func(t *testing.T) {
+		_, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+
+		resp, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: metricsCommand})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp["point_count"], test.ShouldEqual, 2)
+		test.That(t, resp["merge_ms"].(map[string]interface{})["count"], test.ShouldEqual, 2)
+		// two uncolored points without values
+		test.That(t, resp["estimated_bytes"], test.ShouldEqual, 2*bytesPerPosition)
+		test.That(t, resp["peak_estimated_bytes"], test.ShouldEqual, 2*bytesPerPosition)
+
+		cameras := resp["cameras"].([]interface{})
+		test.That(t, len(cameras), test.ShouldEqual, 2)
+		metrics1 := cameras[0].(map[string]interface{})
+		test.That(t, metrics1["name"], test.ShouldEqual, "cam1")
+		test.That(t, metrics1["point_count"], test.ShouldEqual, 2)
+		test.That(t, metrics1["fetch_ms"].(map[string]interface{})["count"], test.ShouldEqual, 2)
+		test.That(t, metrics1["transform_ms"].(map[string]interface{})["count"], test.ShouldEqual, 2)
+
+		// the failing camera never contributed to a merge
+		metrics2 := cameras[1].(map[string]interface{})
+		test.That(t, metrics2["point_count"], test.ShouldEqual, 0)
+		test.That(t, metrics2["fetch_ms"].(map[string]interface{})["count"], test.ShouldEqual, 0)
+	})
+
+	t.Run("export_ply", func(t *testing.T) {
+		_, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+
+		resp, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: exportPLYCommand})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp["points"], test.ShouldEqual, 2)
+		ply, err := base64.StdEncoding.DecodeString(resp["ply"].(string))
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, string(ply), test.ShouldStartWith, "ply\n")
+
+		path := filepath.Join(t.TempDir(), "merged.ply")
+		resp, err = mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: exportPLYCommand, pathKey: path})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp[pathKey], test.ShouldEqual, path)
+		written, err := os.ReadFile(path)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, written, test.ShouldResemble, ply)
+
+		_, err = (&mergedCamera{logger: logger}).DoCommand(ctx, map[string]interface{}{commandKey: exportPLYCommand})
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "no merged point cloud")
+	})
+
+	t.Run("capture_batch", func(t *testing.T) {
+		resp, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: captureBatchCommand, countKey: 3.0})
+		test.That(t, err, test.ShouldBeNil)
+		captures := resp["captures"].([]interface{})
+		test.That(t, len(captures), test.ShouldEqual, 3)
+		for _, capture := range captures {
+			test.That(t, capture.(map[string]interface{})["point_count"], test.ShouldEqual, 2)
+		}
+
+		_, err = mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: captureBatchCommand, countKey: 0})
+		test.That(t, err, test.ShouldNotBeNil)
+
+		cancelCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err = mergedCam.DoCommand(cancelCtx, map[string]interface{}{commandKey: captureBatchCommand, countKey: 3})
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "interrupted")
+	})
+
+	t.Run("ready", func(t *testing.T) {
+		var pointCloudRequests, unplugged int32
+		cam1 := createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 1}}).(*inject.Camera)
+		cam1.NextPointCloudFunc = func(ctx context.Context) (pointcloud.PointCloud, error) {
+			atomic.AddInt32(&pointCloudRequests, 1)
+			return pointcloud.New(), nil
+		}
+		cam2 := createMockCamera("cam2", []r3.Vector{{X: 0, Y: 0, Z: 2}}).(*inject.Camera)
+		cam2.PropertiesFunc = func(ctx context.Context) (camera.Properties, error) {
+			if atomic.LoadInt32(&unplugged) == 1 {
+				return camera.Properties{}, errors.New("camera unplugged")
+			}
+			return camera.Properties{SupportsPCD: true}, nil
+		}
+		cameras := []camera.Camera{cam1, cam2}
+		fsService, err := createFrameSystemService(ctx, cameras, logger)
+		test.That(t, err, test.ShouldBeNil)
+
+		readyCam := &mergedCamera{logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}}}
+		test.That(t, readyCam.Reconfigure(ctx, createDependencies(cameras, fsService), conf), test.ShouldBeNil)
+
+		resp, err := readyCam.DoCommand(ctx, map[string]interface{}{commandKey: readyCommand})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp["ready"], test.ShouldBeTrue)
+		test.That(t, len(resp["cameras"].([]interface{})), test.ShouldEqual, 2)
+		test.That(t, atomic.LoadInt32(&pointCloudRequests), test.ShouldEqual, 0)
+		_, cached := readyCam.transformCache.get("cam2", "cam1")
+		test.That(t, cached, test.ShouldBeFalse)
+
+		atomic.StoreInt32(&unplugged, 1)
+		resp, err = readyCam.DoCommand(ctx, map[string]interface{}{commandKey: readyCommand})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp["ready"], test.ShouldBeFalse)
+		statuses := resp["cameras"].([]interface{})
+		test.That(t, statuses[0].(map[string]interface{})["ready"], test.ShouldBeTrue)
+		status2 := statuses[1].(map[string]interface{})
+		test.That(t, status2["ready"], test.ShouldBeFalse)
+		test.That(t, status2["error"], test.ShouldContainSubstring, "camera unplugged")
+
+		test.That(t, readyCam.Close(ctx), test.ShouldBeNil)
+		resp, err = readyCam.DoCommand(ctx, map[string]interface{}{commandKey: readyCommand})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp["ready"], test.ShouldBeFalse)
+		test.That(t, resp["error"], test.ShouldContainSubstring, "closed")
+	})
+
+	t.Run("get_cached", func(t *testing.T) {
+		_, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: getCachedCommand})
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "enable_cache")
+
+		cachingCam := &mergedCamera{logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{
+			Cameras: []string{"cam1", "cam2"}, ErrorPolicy: errorPolicySkip, EnableCache: true, CacheSize: 2,
+		}}
+		test.That(t, cachingCam.Reconfigure(ctx, createDependencies(cameras, fsService), conf), test.ShouldBeNil)
+		for i := 0; i < 3; i++ {
+			_, err := cachingCam.NextPointCloud(ctx)
+			test.That(t, err, test.ShouldBeNil)
+		}
+
+		resp, err := cachingCam.DoCommand(ctx, map[string]interface{}{commandKey: getCachedCommand, indexKey: 1.0})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp[indexKey], test.ShouldEqual, 1)
+		test.That(t, resp["points"], test.ShouldEqual, 2)
+		ply, err := base64.StdEncoding.DecodeString(resp["ply"].(string))
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, string(ply), test.ShouldContainSubstring, "element vertex 2")
+
+		resp, err = cachingCam.DoCommand(ctx, map[string]interface{}{
+			commandKey: getCachedCommand, capturedAtKey: resp[capturedAtKey],
+		})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp["points"], test.ShouldEqual, 2)
+
+		_, err = cachingCam.DoCommand(ctx, map[string]interface{}{commandKey: getCachedCommand, indexKey: 2})
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "2 are cached")
+
+		_, err = cachingCam.DoCommand(ctx, map[string]interface{}{commandKey: getCachedCommand, capturedAtKey: "yesterday"})
+		test.That(t, err, test.ShouldNotBeNil)
+
+		// modifying a returned cloud leaves the cached copy untouched
+		pc, err := cachingCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Set(r3.Vector{X: 5, Y: 5, Z: 5}, pointcloud.NewBasicData()), test.ShouldBeNil)
+		pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+			d.SetValue(7)
+			return true
+		})
+		cached, ok := cachingCam.cloudCache.get(0)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, cached.pc.Size(), test.ShouldEqual, 2)
+		cached.pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+			test.That(t, d.HasValue(), test.ShouldBeFalse)
+			return true
+		})
+	})
+
+	t.Run("transforms", func(t *testing.T) {
+		cameras := []camera.Camera{
+			createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 1}}),
+			createFailingCamera("cam2"),
+			createMockCamera("cam3", []r3.Vector{{X: 0, Y: 0, Z: 3}}),
+		}
+		poses := map[string]spatialmath.Pose{"cam2": spatialmath.NewPoseFromPoint(r3.Vector{X: 100, Y: 0, Z: 0})}
+		fsService, err := createFrameSystemServiceWithPoses(ctx, cameras, poses, logger)
+		test.That(t, err, test.ShouldBeNil)
+
+		transformsCam := &mergedCamera{logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{
+			Cameras:      []string{"cam1", "cam2", "cam3"},
+			PoseOverride: map[string]*PoseConfig{"cam3": {Translation: r3.Vector{X: 0, Y: 50, Z: 0}}},
+		}}
+		test.That(t, transformsCam.Reconfigure(ctx, createDependencies(cameras, fsService), conf), test.ShouldBeNil)
+
+		resp, err := transformsCam.DoCommand(ctx, map[string]interface{}{commandKey: transformsCommand})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp["target_frame"], test.ShouldEqual, "cam1")
+		transforms := resp["cameras"].([]interface{})
+		test.That(t, len(transforms), test.ShouldEqual, 3)
+
+		cam2 := transforms[1].(map[string]interface{})
+		test.That(t, cam2["name"], test.ShouldEqual, "cam2")
+		test.That(t, cam2["error"], test.ShouldEqual, "")
+		test.That(t, cam2["source"], test.ShouldEqual, "frame_system")
+		test.That(t, cam2["translation"].(map[string]interface{})["x"], test.ShouldAlmostEqual, 100)
+		test.That(t, cam2["orientation"].(map[string]interface{})["theta"], test.ShouldAlmostEqual, 0)
+
+		cam3 := transforms[2].(map[string]interface{})
+		test.That(t, cam3["source"], test.ShouldEqual, "pose_override")
+		test.That(t, cam3["translation"].(map[string]interface{})["y"], test.ShouldAlmostEqual, 50)
+
+		// an unknown frame is reported per camera
+		transformsCam.targetFrame = "missing"
+		resp, err = transformsCam.DoCommand(ctx, map[string]interface{}{commandKey: transformsCommand})
+		test.That(t, err, test.ShouldBeNil)
+		cam1 := resp["cameras"].([]interface{})[0].(map[string]interface{})
+		test.That(t, cam1["error"], test.ShouldContainSubstring, "issue getting tranform from camera cam1")
+		test.That(t, cam1["translation"], test.ShouldBeNil)
+	})
+
+	t.Run("bounds", func(t *testing.T) {
+		_, err := (&mergedCamera{logger: logger}).DoCommand(ctx, map[string]interface{}{commandKey: boundsCommand})
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "no merged point cloud")
+
+		_, err = mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		resp, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: boundsCommand})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp["target_frame"], test.ShouldEqual, "cam1")
+		test.That(t, resp["points"], test.ShouldEqual, 2)
+		test.That(t, resp["min"], test.ShouldResemble, map[string]interface{}{"x": 0.0, "y": 0.0, "z": 2.0})
+		test.That(t, resp["max"], test.ShouldResemble, map[string]interface{}{"x": 0.0, "y": 1.0, "z": 2.0})
+		test.That(t, resp["centroid"], test.ShouldResemble, map[string]interface{}{"x": 0.0, "y": 0.5, "z": 2.0})
+	})
+
+	t.Run("set_enabled", func(t *testing.T) {
+		setEnabled := func(name string, enabled bool) error {
+			_, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: setEnabledCommand, cameraKey: name, enabledKey: enabled})
+			return err
+		}
+		enabledStates := func() []interface{} {
+			resp, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: statusCommand})
+			test.That(t, err, test.ShouldBeNil)
+			var states []interface{}
+			for _, s := range resp["cameras"].([]interface{}) {
+				states = append(states, s.(map[string]interface{})["enabled"])
+			}
+			return states
+		}
+
+		test.That(t, setEnabled("cam2", false), test.ShouldBeNil)
+		test.That(t, enabledStates(), test.ShouldResemble, []interface{}{true, false})
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+
+		test.That(t, setEnabled("cam1", false), test.ShouldBeNil)
+		_, err = mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "every camera is disabled")
+
+		test.That(t, setEnabled("cam1", true), test.ShouldBeNil)
+		test.That(t, enabledStates(), test.ShouldResemble, []interface{}{true, false})
+
+		// reconfiguring enables every camera
+		test.That(t, mergedCam.Reconfigure(ctx, createDependencies(cameras, fsService), conf), test.ShouldBeNil)
+		test.That(t, enabledStates(), test.ShouldResemble, []interface{}{true, true})
+
+		err = setEnabled("cam3", false)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "unknown camera cam3")
+		_, err = mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: setEnabledCommand, cameraKey: "cam1"})
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+
+	t.Run("count", func(t *testing.T) {
+		resp, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: countCommand})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp["points"], test.ShouldEqual, 2)
+		test.That(t, resp["estimate"], test.ShouldBeFalse)
+		test.That(t, resp["excluded_stages"], test.ShouldResemble, []interface{}{})
+		counts := resp["cameras"].([]interface{})
+		test.That(t, len(counts), test.ShouldEqual, 2)
+		test.That(t, counts[0], test.ShouldResemble, map[string]interface{}{"name": "cam1", "points": 2, "error": ""})
+		test.That(t, counts[1].(map[string]interface{})["error"], test.ShouldContainSubstring, "camera failure")
+
+		dedupConf := resource.Config{ConvertedAttributes: &Config{
+			Cameras: []string{"cam1", "cam2"}, ErrorPolicy: errorPolicySkip, DedupResolution: 0.01, MinClusterSize: 2,
+		}}
+		test.That(t, mergedCam.Reconfigure(ctx, createDependencies(cameras, fsService), dedupConf), test.ShouldBeNil)
+		resp, err = mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: countCommand})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp["points"], test.ShouldEqual, 2)
+		test.That(t, resp["estimate"], test.ShouldBeTrue)
+		test.That(t, resp["excluded_stages"], test.ShouldResemble, []interface{}{"dedup_resolution", "min_cluster_size"})
+
+		strictConf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}}}
+		test.That(t, mergedCam.Reconfigure(ctx, createDependencies(cameras, fsService), strictConf), test.ShouldBeNil)
+		_, err = mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: countCommand})
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "camera failure")
+		var mergeErr *MergeError
+		test.That(t, errors.As(err, &mergeErr), test.ShouldBeTrue)
+		_, failed := mergeErr.Failed("cam2")
+		test.That(t, failed, test.ShouldBeTrue)
+		test.That(t, mergedCam.Reconfigure(ctx, createDependencies(cameras, fsService), conf), test.ShouldBeNil)
+	})
+
+	t.Run("next_subset", func(t *testing.T) {
+		mergedCam.stateMu.Lock()
+		last := mergedCam.lastPointCloud
+		mergedCam.stateMu.Unlock()
+
+		resp, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: nextSubsetCommand, camerasKey: []interface{}{"cam1"}})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp["points"], test.ShouldEqual, 2)
+		test.That(t, resp["ply"], test.ShouldNotBeEmpty)
+		_, err = time.Parse(timeFormat, resp[capturedAtKey].(string))
+		test.That(t, err, test.ShouldBeNil)
+		// subset merges are not kept
+		mergedCam.stateMu.Lock()
+		test.That(t, mergedCam.lastPointCloud, test.ShouldEqual, last)
+		mergedCam.stateMu.Unlock()
+
+		_, err = mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: nextSubsetCommand, camerasKey: []interface{}{"cam2"}})
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "all cameras failed")
+
+		_, err = mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: nextSubsetCommand, camerasKey: []interface{}{"cam1", "cam3"}})
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "unknown camera cam3")
+
+		_, err = mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: nextSubsetCommand, camerasKey: []interface{}{}})
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+
+	t.Run("frame", func(t *testing.T) {
+		resp, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: frameCommand})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp, test.ShouldResemble,
+			map[string]interface{}{
+				"frame": "cam1", "output_offset_applied": false, "ros_frame": false, "output_units": outputUnitsMillimeters,
+			})
+
+		offsetCam := newTestMergedCamera(t, &Config{
+			Cameras: []string{"cam1", "cam2"}, ErrorPolicy: errorPolicySkip, TargetFrame: "world",
+			OutputOffset: &PoseConfig{Translation: r3.Vector{X: 10}},
+		}, cameras, fsService)
+		resp, err = offsetCam.DoCommand(ctx, map[string]interface{}{commandKey: frameCommand})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp["frame"], test.ShouldEqual, "world")
+		test.That(t, resp["output_offset_applied"], test.ShouldBeTrue)
+		offset := resp["output_offset"].(map[string]interface{})
+		test.That(t, offset["translation"], test.ShouldResemble, map[string]interface{}{"x": 10.0, "y": 0.0, "z": 0.0})
+
+		test.That(t, offsetCam.Close(ctx), test.ShouldBeNil)
+		_, err = offsetCam.DoCommand(ctx, map[string]interface{}{commandKey: frameCommand})
+		test.That(t, errors.Is(err, ErrClosed), test.ShouldBeTrue)
+	})
+
+	t.Run("fingerprint", func(t *testing.T) {
+		unmerged := newTestMergedCamera(t, &Config{Cameras: []string{"cam1", "cam2"}, ErrorPolicy: errorPolicySkip}, cameras, fsService)
+		defer unmerged.Close(ctx)
+		_, err := unmerged.DoCommand(ctx, map[string]interface{}{commandKey: fingerprintCommand})
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "no merged point cloud")
+
+		_, err = mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		resp, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: fingerprintCommand})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp["points"], test.ShouldEqual, 2)
+		test.That(t, resp["occupied_voxels"], test.ShouldEqual, 1)
+		test.That(t, resp[voxelSizeKey], test.ShouldEqual, defaultFingerprintVoxelSize)
+		test.That(t, len(resp["fingerprint"].(string)), test.ShouldEqual, 16)
+
+		_, err = mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		again, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: fingerprintCommand})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, again["fingerprint"], test.ShouldEqual, resp["fingerprint"])
+
+		fine, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: fingerprintCommand, voxelSizeKey: 0.5})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, fine["occupied_voxels"], test.ShouldEqual, 2)
+		test.That(t, fine["fingerprint"], test.ShouldNotEqual, resp["fingerprint"])
+
+		_, err = mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: fingerprintCommand, voxelSizeKey: 0.0})
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, voxelSizeKey)
+	})
+
+	t.Run("next_in_frame", func(t *testing.T) {
+		mergedCam.stateMu.Lock()
+		last := mergedCam.lastPointCloud
+		mergedCam.stateMu.Unlock()
+
+		resp, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: nextInFrameCommand, frameNameKey: "cam2"})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp["points"], test.ShouldEqual, 2)
+		test.That(t, resp["ply"], test.ShouldNotBeEmpty)
+		test.That(t, resp[frameNameKey], test.ShouldEqual, "cam2")
+		_, err = time.Parse(timeFormat, resp[capturedAtKey].(string))
+		test.That(t, err, test.ShouldBeNil)
+		mergedCam.stateMu.Lock()
+		test.That(t, mergedCam.lastPointCloud, test.ShouldEqual, last)
+		mergedCam.stateMu.Unlock()
+
+		_, err = mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: nextInFrameCommand, frameNameKey: "table"})
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "frame table does not exist")
+
+		_, err = mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: nextInFrameCommand})
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, frameNameKey)
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		_, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: "fake"})
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"context"
+	"image/color"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/robot/framesystem"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/test"
+)
+
+func createMockCamera(name string, points []r3.Vector) camera.Camera {
+	pc := pointcloud.New()
+	for _, pt := range points {
+		pc.Set(pt, pointcloud.NewBasicData())
+	}
+
+	cam := inject.NewCamera(name)
+	cam.NextPointCloudFunc = func(ctx context.Context) (pointcloud.PointCloud, error) {
+		return pc, nil
+	}
+	cam.PropertiesFunc = func(ctx context.Context) (camera.Properties, error) {
+		return camera.Properties{SupportsPCD: true}, nil
+	}
+
+	return cam
+}
+
+func createFailingCamera(name string) camera.Camera {
+	cam := inject.NewCamera(name)
+	cam.NextPointCloudFunc = func(ctx context.Context) (pointcloud.PointCloud, error) {
+		return nil, errors.New("camera failure")
+	}
+	cam.PropertiesFunc = func(ctx context.Context) (camera.Properties, error) {
+		return camera.Properties{SupportsPCD: true}, nil
+	}
+
+	return cam
+}
+
+// createNilCamera returns a camera that returns neither a point cloud nor an error.
+func createNilCamera(name string) camera.Camera {
+	cam := inject.NewCamera(name)
+	cam.NextPointCloudFunc = func(ctx context.Context) (pointcloud.PointCloud, error) {
+		return nil, nil
+	}
+	cam.PropertiesFunc = func(ctx context.Context) (camera.Properties, error) {
+		return camera.Properties{SupportsPCD: true}, nil
+	}
+
+	return cam
+}
+
+// createBlockingCamera returns a camera that never returns a point cloud, ignoring its context.
+func createBlockingCamera(name string) camera.Camera {
+	cam := inject.NewCamera(name)
+	cam.NextPointCloudFunc = func(ctx context.Context) (pointcloud.PointCloud, error) {
+		select {}
+	}
+	cam.PropertiesFunc = func(ctx context.Context) (camera.Properties, error) {
+		return camera.Properties{SupportsPCD: true}, nil
+	}
+
+	return cam
+}
+
+// createColoredCamera returns a camera whose point cloud has every point colored with the given color.
+func createColoredCamera(name string, points []r3.Vector, c color.NRGBA) camera.Camera {
+	pc := pointcloud.New()
+	for _, pt := range points {
+		pc.Set(pt, pointcloud.NewColoredData(c))
+	}
+
+	cam := inject.NewCamera(name)
+	cam.NextPointCloudFunc = func(ctx context.Context) (pointcloud.PointCloud, error) {
+		return pc, nil
+	}
+	cam.PropertiesFunc = func(ctx context.Context) (camera.Properties, error) {
+		return camera.Properties{SupportsPCD: true}, nil
+	}
+
+	return cam
+}
+
+// createDelayedCamera returns a camera that returns its point cloud after the given delay.
+func createDelayedCamera(name string, points []r3.Vector, delay time.Duration) camera.Camera {
+	cam := createMockCamera(name, points).(*inject.Camera)
+	next := cam.NextPointCloudFunc
+	cam.NextPointCloudFunc = func(ctx context.Context) (pointcloud.PointCloud, error) {
+		time.Sleep(delay)
+		return next(ctx)
+	}
+
+	return cam
+}
+
+// createConfidenceCamera returns a camera whose points carry the given confidences in their intensity.
+func createConfidenceCamera(name string, points []r3.Vector, confidences []uint16) camera.Camera {
+	pc := pointcloud.New()
+	for i, pt := range points {
+		pc.Set(pt, pointcloud.NewBasicData().SetIntensity(confidences[i]))
+	}
+
+	cam := inject.NewCamera(name)
+	cam.NextPointCloudFunc = func(ctx context.Context) (pointcloud.PointCloud, error) {
+		return pc, nil
+	}
+	cam.PropertiesFunc = func(ctx context.Context) (camera.Properties, error) {
+		return camera.Properties{SupportsPCD: true}, nil
+	}
+
+	return cam
+}
+
+// createCameraLink instantiates the camera to base link for the frame system.
+func createCameraLink(camName, baseFrame string, pose spatialmath.Pose) (*referenceframe.LinkInFrame, error) {
+	camPose := spatialmath.NewPoseFromPoint(r3.Vector{X: 0, Y: 0, Z: 0})
+	camSphere, err := spatialmath.NewSphere(camPose, 5, "cam-sphere")
+	if err != nil {
+		return nil, err
+	}
+
+	camLink := referenceframe.NewLinkInFrame(
+		baseFrame,
+		pose,
+		camName,
+		camSphere,
+	)
+	return camLink, nil
+}
+
+// createFrameSystemService will create a basic frame service from the list of parts.
+func createFrameSystemService(
+	ctx context.Context,
+	cameras []camera.Camera,
+	logger logging.Logger,
+) (framesystem.Service, error) {
+	return createFrameSystemServiceWithPoses(ctx, cameras, nil, logger)
+}
+
+// createFrameSystemServiceWithPoses will create a frame service with each camera placed at the given pose
+// relative to the world frame. Cameras without a pose are placed at the world origin.
+func createFrameSystemServiceWithPoses(
+	ctx context.Context,
+	cameras []camera.Camera,
+	poses map[string]spatialmath.Pose,
+	logger logging.Logger,
+) (framesystem.Service, error) {
+	var fsParts []*referenceframe.FrameSystemPart
+	deps := make(resource.Dependencies)
+
+	// create camera link
+	for _, cam := range cameras {
+		pose, ok := poses[cam.Name().Name]
+		if !ok {
+			pose = spatialmath.NewZeroPose()
+		}
+		cameraLink, err := createCameraLink(cam.Name().Name, "world", pose)
+		if err != nil {
+			return nil, err
+		}
+		fsParts = append(fsParts, &referenceframe.FrameSystemPart{FrameConfig: cameraLink})
+		deps[cam.Name()] = cam
+	}
+
+	fsSvc, err := framesystem.New(ctx, deps, logger)
+	if err != nil {
+		return nil, err
+	}
+	conf := resource.Config{
+		ConvertedAttributes: &framesystem.Config{Parts: fsParts},
+	}
+	if err := fsSvc.Reconfigure(ctx, deps, conf); err != nil {
+		return nil, err
+	}
+
+	return fsSvc, nil
+}
+
+// createDependencies builds the dependencies needed to configure a merged camera.
+func createDependencies(cameras []camera.Camera, fsService framesystem.Service) resource.Dependencies {
+	deps := make(resource.Dependencies)
+	for _, cam := range cameras {
+		deps[cam.Name()] = cam
+	}
+	deps[framesystem.InternalServiceName] = fsService
+	return deps
+}
+
+// fakeCamera is a point cloud camera whose point cloud, error and properties can be changed while a test runs. It
+// counts the point clouds requested from it.
+type fakeCamera struct {
+	*inject.Camera
+
+	mu         sync.Mutex
+	pc         pointcloud.PointCloud
+	err        error
+	properties camera.Properties
+	fetches    int
+}
+
+// newFakeCamera returns a fake camera that supports point clouds and returns the given points.
+func newFakeCamera(name string, points ...r3.Vector) *fakeCamera {
+	cam := &fakeCamera{Camera: inject.NewCamera(name), properties: camera.Properties{SupportsPCD: true}}
+	cam.setPoints(points...)
+	cam.NextPointCloudFunc = func(ctx context.Context) (pointcloud.PointCloud, error) {
+		cam.mu.Lock()
+		defer cam.mu.Unlock()
+		cam.fetches++
+		if cam.err != nil {
+			return nil, cam.err
+		}
+		return cam.pc, nil
+	}
+	cam.PropertiesFunc = func(ctx context.Context) (camera.Properties, error) {
+		cam.mu.Lock()
+		defer cam.mu.Unlock()
+		return cam.properties, nil
+	}
+	return cam
+}
+
+// setPoints replaces the point cloud of the camera with one of the given points and clears its error.
+func (cam *fakeCamera) setPoints(points ...r3.Vector) {
+	pc := pointcloud.New()
+	for _, pt := range points {
+		if err := pc.Set(pt, pointcloud.NewBasicData()); err != nil {
+			panic(err)
+		}
+	}
+	cam.setPointCloud(pc)
+}
+
+// setPointCloud replaces the point cloud of the camera and clears its error.
+func (cam *fakeCamera) setPointCloud(pc pointcloud.PointCloud) {
+	cam.mu.Lock()
+	defer cam.mu.Unlock()
+	cam.pc, cam.err = pc, nil
+}
+
+// setError makes the camera fail to return a point cloud with the given error.
+func (cam *fakeCamera) setError(err error) {
+	cam.mu.Lock()
+	defer cam.mu.Unlock()
+	cam.err = err
+}
+
+// setProperties replaces the properties reported by the camera.
+func (cam *fakeCamera) setProperties(properties camera.Properties) {
+	cam.mu.Lock()
+	defer cam.mu.Unlock()
+	cam.properties = properties
+}
+
+// fetchCount returns the number of point clouds requested from the camera.
+func (cam *fakeCamera) fetchCount() int {
+	cam.mu.Lock()
+	defer cam.mu.Unlock()
+	return cam.fetches
+}
+
+// newFakeFrameSystemService returns a frame system service whose frames are static frames placed at the given poses
+// relative to the world frame. Unlike createFrameSystemService its frames need not belong to cameras, and it needs
+// no dependencies.
+func newFakeFrameSystemService(poses map[string]spatialmath.Pose) (*inject.FrameSystemService, error) {
+	return newFakeFrameSystemServiceWithParents(poses, nil)
+}
+
+// newFakeFrameSystemServiceWithParents is newFakeFrameSystemService with each frame placed relative to the frame
+// given in parents instead. Frames without a parent are placed relative to the world frame.
+func newFakeFrameSystemServiceWithParents(
+	poses map[string]spatialmath.Pose, parents map[string]string,
+) (*inject.FrameSystemService, error) {
+	fs := referenceframe.NewEmptyFrameSystem("fake")
+	names := make([]string, 0, len(poses))
+	for name := range poses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var addFrame func(name string) error
+	addFrame = func(name string) error {
+		if fs.Frame(name) != nil {
+			return nil
+		}
+		parent := fs.World()
+		if parentName, ok := parents[name]; ok {
+			if err := addFrame(parentName); err != nil {
+				return err
+			}
+			parent = fs.Frame(parentName)
+		}
+		frame, err := referenceframe.NewStaticFrame(name, poses[name])
+		if err != nil {
+			return err
+		}
+		return fs.AddFrame(frame, parent)
+	}
+	for _, name := range names {
+		if err := addFrame(name); err != nil {
+			return nil, err
+		}
+	}
+
+	fsService := inject.NewFrameSystemService("fake")
+	fsService.FrameSystemFunc = func(
+		ctx context.Context, additionalTransforms []*referenceframe.LinkInFrame,
+	) (referenceframe.FrameSystem, error) {
+		return fs, nil
+	}
+	fsService.CurrentInputsFunc = func(
+		ctx context.Context,
+	) (map[string][]referenceframe.Input, map[string]referenceframe.InputEnabled, error) {
+		return referenceframe.StartPositions(fs), nil, nil
+	}
+	fsService.TransformPoseFunc = func(
+		ctx context.Context,
+		pose *referenceframe.PoseInFrame,
+		dst string,
+		additionalTransforms []*referenceframe.LinkInFrame,
+	) (*referenceframe.PoseInFrame, error) {
+		transformed, err := fs.Transform(referenceframe.StartPositions(fs), pose, dst)
+		if err != nil {
+			return nil, err
+		}
+		return transformed.(*referenceframe.PoseInFrame), nil
+	}
+	return fsService, nil
+}
+
+// newTestMergedCamera returns a merged camera named "merged" configured with the given config, the cameras and the
+// frame system service, failing the test if the configuration is rejected.
+func newTestMergedCamera(
+	tb testing.TB, cfg *Config, cameras []camera.Camera, fsService framesystem.Service,
+) *mergedCamera {
+	tb.Helper()
+	return newTestMergedCameraWithLogger(tb, cfg, cameras, fsService, logging.NewTestLogger(tb))
+}
+
+// newTestMergedCameraWithLogger is newTestMergedCamera logging to the given logger, such as an observed logger
+// whose messages the test inspects.
+func newTestMergedCameraWithLogger(
+	tb testing.TB, cfg *Config, cameras []camera.Camera, fsService framesystem.Service, logger logging.Logger,
+) *mergedCamera {
+	tb.Helper()
+	_, err := cfg.Validate("path")
+	test.That(tb, err, test.ShouldBeNil)
+	mergedCam := &mergedCamera{Named: resource.NewName(camera.API, "merged").AsNamed(), logger: logger}
+	conf := resource.Config{ConvertedAttributes: cfg}
+	test.That(tb, mergedCam.Reconfigure(context.Background(), createDependencies(cameras, fsService), conf), test.ShouldBeNil)
+	return mergedCam
+}
+
+func TestFakeCamera(t *testing.T) {
+	ctx := context.Background()
+	cam := newFakeCamera("cam", r3.Vector{X: 1})
+
+	pc, err := cam.NextPointCloud(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pc.Size(), test.ShouldEqual, 1)
+
+	cam.setError(errors.New("camera failure"))
+	_, err = cam.NextPointCloud(ctx)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	cam.setPoints(r3.Vector{X: 1}, r3.Vector{X: 2})
+	pc, err = cam.NextPointCloud(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pc.Size(), test.ShouldEqual, 2)
+	test.That(t, cam.fetchCount(), test.ShouldEqual, 3)
+
+	props, err := cam.Properties(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, props.SupportsPCD, test.ShouldBeTrue)
+}
+
+func TestFakeFrameSystemService(t *testing.T) {
+	ctx := context.Background()
+	cam1 := newFakeCamera("cam1", r3.Vector{X: 0, Y: 0, Z: 10})
+	cam2 := newFakeCamera("cam2", r3.Vector{X: 0, Y: 0, Z: 10})
+	cameras := []camera.Camera{cam1, cam2}
+	fsService, err := newFakeFrameSystemService(map[string]spatialmath.Pose{
+		"cam1":  spatialmath.NewZeroPose(),
+		"cam2":  spatialmath.NewPoseFromPoint(r3.Vector{X: 100}),
+		"table": spatialmath.NewPoseFromPoint(r3.Vector{Z: -500}),
+	})
+	test.That(t, err, test.ShouldBeNil)
+
+	for _, dynamic := range []bool{false, true} {
+		mergedCam := newTestMergedCamera(t, &Config{Cameras: []string{"cam1", "cam2"}, DynamicFrames: dynamic}, cameras, fsService)
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+		_, ok := pc.At(100, 0, 10)
+		test.That(t, ok, test.ShouldBeTrue)
+	}
+
+	mergedCam := newTestMergedCamera(t, &Config{Cameras: []string{"cam1", "cam2"}, TargetFrame: "table"}, cameras, fsService)
+	pc, err := mergedCam.NextPointCloud(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	_, ok := pc.At(100, 0, 510)
+	test.That(t, ok, test.ShouldBeTrue)
+}
@@ -0,0 +1,1038 @@
+package main
+
+import (
+	"context"
+	"image/color"
+	"math"
+	"math/rand"
+	"sync"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// BoxConfig describes an axis-aligned box. Coordinates are in millimeters, like the point clouds themselves.
+type BoxConfig struct {
+	Min r3.Vector `json:"min"`
+	Max r3.Vector `json:"max"`
+}
+
+// Validate checks that the box has a non-negative extent along every axis.
+func (box *BoxConfig) Validate() error {
+	for _, axis := range []struct {
+		name     string
+		min, max float64
+	}{{"x", box.Min.X, box.Max.X}, {"y", box.Min.Y, box.Max.Y}, {"z", box.Min.Z, box.Max.Z}} {
+		if axis.min >= axis.max {
+			return errors.Errorf("min.%v (%v) must be less than max.%v (%v)", axis.name, axis.min, axis.name, axis.max)
+		}
+	}
+	return nil
+}
+
+// contains returns whether the point lies within the box, boundaries included.
+func (box *BoxConfig) contains(p r3.Vector) bool {
+	return p.X >= box.Min.X && p.X <= box.Max.X &&
+		p.Y >= box.Min.Y && p.Y <= box.Max.Y &&
+		p.Z >= box.Min.Z && p.Z <= box.Max.Z
+}
+
+// cropToBox returns a cloud of the points that lie within the box. The box is expressed in the frame whose origin
+// in the frame of the cloud is the given pose, or in the frame of the cloud itself when the pose is nil.
+func cropToBox(pc pointcloud.PointCloud, box *BoxConfig, frame spatialmath.Pose) (pointcloud.PointCloud, error) {
+	return filterPointsInFrame(pc, frame, box.contains)
+}
+
+// excludeBoxes returns a cloud of the points that lie outside of every box.
+func excludeBoxes(pc pointcloud.PointCloud, boxes []*BoxConfig) (pointcloud.PointCloud, error) {
+	return filterPoints(pc, func(p r3.Vector) bool {
+		for _, box := range boxes {
+			if box.contains(p) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// OutlierRemovalConfig configures statistical outlier removal. A point is removed when its mean distance to its
+// nearest neighbors exceeds the mean of that distance over the cloud by more than StdDevMultiplier standard
+// deviations.
+type OutlierRemovalConfig struct {
+	Neighbors        int     `json:"neighbors"`
+	StdDevMultiplier float64 `json:"std_dev_multiplier"`
+}
+
+// filter returns the outlier removal filter described by the config.
+func (cfg *OutlierRemovalConfig) filter() (func(pointcloud.PointCloud) (pointcloud.PointCloud, error), error) {
+	if cfg.Neighbors <= 0 {
+		return nil, errors.Errorf("neighbors must be positive, got %v", cfg.Neighbors)
+	}
+	if cfg.StdDevMultiplier <= 0 {
+		return nil, errors.Errorf("std_dev_multiplier must be positive, got %v", cfg.StdDevMultiplier)
+	}
+	return pointcloud.StatisticalOutlierFilter(cfg.Neighbors, cfg.StdDevMultiplier)
+}
+
+const (
+	// groundRemovalRANSAC removes the plane fit to the most points within the tilt limit.
+	groundRemovalRANSAC = "ransac"
+	// groundRemovalZThreshold removes every point at or below a height in the target frame.
+	groundRemovalZThreshold = "z_threshold"
+
+	// defaultGroundIterations is the number of planes sampled by RANSAC when max_iterations is unset.
+	defaultGroundIterations = 100
+	// maxGroundTilt is the largest angle in degrees between a candidate ground plane and the XY plane of the
+	// target frame, which keeps RANSAC from removing walls.
+	maxGroundTilt = 10.0
+)
+
+// GroundRemovalConfig configures removal of the ground from the merged point cloud, which is assumed to be roughly
+// parallel to the XY plane of the target frame.
+type GroundRemovalConfig struct {
+	// Method is either "ransac" (default) or "z_threshold".
+	Method string `json:"method,omitempty"`
+	// DistanceThreshold is in meters. For RANSAC it is the largest distance from the fit plane of a ground point,
+	// and for a Z threshold it is the height at or below which points are removed.
+	DistanceThreshold float64 `json:"distance_threshold"`
+	// MaxIterations is the number of planes sampled by RANSAC, 100 if unset.
+	MaxIterations int `json:"max_iterations,omitempty"`
+}
+
+// Validate checks that the config describes a valid ground removal.
+func (cfg *GroundRemovalConfig) Validate() error {
+	switch cfg.Method {
+	case "", groundRemovalRANSAC:
+		if cfg.DistanceThreshold <= 0 {
+			return errors.Errorf("distance_threshold must be positive, got %v", cfg.DistanceThreshold)
+		}
+	case groundRemovalZThreshold:
+	default:
+		return errors.Errorf("invalid method %q, must be %q or %q", cfg.Method, groundRemovalRANSAC, groundRemovalZThreshold)
+	}
+	if cfg.MaxIterations < 0 {
+		return errors.Errorf("max_iterations must be non-negative, got %v", cfg.MaxIterations)
+	}
+	return nil
+}
+
+// removeGround returns a cloud without the ground points described by the config. The cloud is in millimeters. The
+// ground is assumed to be roughly parallel to the XY plane of the frame whose origin in the frame of the cloud is the
+// given pose, or of the frame of the cloud itself when the pose is nil. RANSAC samples its planes with r.
+func removeGround(
+	pc pointcloud.PointCloud, cfg *GroundRemovalConfig, frame spatialmath.Pose, r *rand.Rand,
+) (pointcloud.PointCloud, error) {
+	threshold := cfg.DistanceThreshold * mmPerMeter
+	if cfg.Method == groundRemovalZThreshold {
+		return filterPointsInFrame(pc, frame, func(p r3.Vector) bool { return p.Z > threshold })
+	}
+
+	iterations := cfg.MaxIterations
+	if iterations == 0 {
+		iterations = defaultGroundIterations
+	}
+	fitted := pc
+	if frame != nil {
+		var err error
+		fitted, err = transformPointCloud(pc, spatialmath.PoseInverse(frame))
+		if err != nil {
+			return nil, err
+		}
+	}
+	normal, offset, ok := fitGroundPlane(fitted, iterations, threshold, r)
+	if !ok {
+		return pc, nil
+	}
+	return filterPointsInFrame(pc, frame, func(p r3.Vector) bool { return math.Abs(normal.Dot(p)+offset) >= threshold })
+}
+
+// fitGroundPlane samples planes through three points of the cloud and returns the unit normal and offset of the
+// plane within maxGroundTilt of horizontal that has the most points within threshold of it. The points are sampled
+// with r, so the same cloud yields the same plane for the same seed. It returns false if no such plane was sampled.
+func fitGroundPlane(pc pointcloud.PointCloud, iterations int, threshold float64, r *rand.Rand) (r3.Vector, float64, bool) {
+	pts := make([]r3.Vector, 0, pc.Size())
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		pts = append(pts, p)
+		return true
+	})
+	if len(pts) < 3 {
+		return r3.Vector{}, 0, false
+	}
+
+	minCos := math.Cos(maxGroundTilt * math.Pi / 180)
+	var bestNormal r3.Vector
+	var bestOffset float64
+	bestInliers := 0
+	for i := 0; i < iterations; i++ {
+		p1, p2, p3 := pts[r.Intn(len(pts))], pts[r.Intn(len(pts))], pts[r.Intn(len(pts))]
+		normal := p2.Sub(p1).Cross(p3.Sub(p1))
+		if normal.Norm() == 0 {
+			continue
+		}
+		normal = normal.Normalize()
+		if math.Abs(normal.Z) < minCos {
+			continue
+		}
+		offset := -normal.Dot(p1)
+		inliers := 0
+		for _, p := range pts {
+			if math.Abs(normal.Dot(p)+offset) < threshold {
+				inliers++
+			}
+		}
+		if inliers > bestInliers {
+			bestNormal, bestOffset, bestInliers = normal, offset, inliers
+		}
+	}
+	return bestNormal, bestOffset, bestInliers > 0
+}
+
+// filterPoints returns a cloud of the points for which keep returns true.
+func filterPoints(pc pointcloud.PointCloud, keep func(r3.Vector) bool) (pointcloud.PointCloud, error) {
+	filtered := pointcloud.New()
+	var err error
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		if keep(p) {
+			err = filtered.Set(p, d)
+		}
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return filtered, nil
+}
+
+// filterPointsInFrame returns a cloud of the points for which keep returns true when given the point expressed in the
+// frame whose origin in the frame of the cloud is the given pose. A nil pose expresses points in the frame of the
+// cloud. The returned points are left in the frame of the cloud.
+func filterPointsInFrame(
+	pc pointcloud.PointCloud, frame spatialmath.Pose, keep func(r3.Vector) bool,
+) (pointcloud.PointCloud, error) {
+	if frame == nil {
+		return filterPoints(pc, keep)
+	}
+	inverse := spatialmath.PoseInverse(frame)
+	rotation := rotationOf(inverse.Orientation())
+	translation := inverse.Point()
+	return filterPoints(pc, func(p r3.Vector) bool { return keep(translation.Add(rotation.apply(p))) })
+}
+
+// dropInvalidPoints returns a cloud of the points whose coordinates are all finite, along with the number of points
+// dropped. The cloud is returned as is when every point is finite.
+func dropInvalidPoints(pc pointcloud.PointCloud) (pointcloud.PointCloud, int, error) {
+	invalid := 0
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		if !isFinite(p) {
+			invalid++
+		}
+		return true
+	})
+	if invalid == 0 {
+		return pc, 0, nil
+	}
+
+	filtered := pointcloud.NewWithPrealloc(pc.Size() - invalid)
+	var err error
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		if isFinite(p) {
+			err = filtered.Set(p, d)
+		}
+		return err == nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return filtered, invalid, nil
+}
+
+// isFinite reports whether every coordinate of the point is neither NaN nor infinite.
+func isFinite(p r3.Vector) bool {
+	for _, v := range []float64{p.X, p.Y, p.Z} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterRange returns a cloud of the points whose distance from the origin is within [minRange, maxRange].
+// A maxRange of zero disables the upper bound.
+func filterRange(pc pointcloud.PointCloud, minRange, maxRange float64) (pointcloud.PointCloud, error) {
+	filtered := pointcloud.New()
+	var err error
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		dist := p.Norm()
+		if dist >= minRange && (maxRange == 0 || dist <= maxRange) {
+			err = filtered.Set(p, d)
+		}
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return filtered, nil
+}
+
+// voxelKey identifies a cell of a voxel grid.
+type voxelKey struct {
+	x, y, z int64
+}
+
+// newVoxelKey returns the key of the voxel of the given size that contains the point.
+func newVoxelKey(p r3.Vector, voxelSize float64) voxelKey {
+	return voxelKey{
+		x: int64(math.Floor(p.X / voxelSize)),
+		y: int64(math.Floor(p.Y / voxelSize)),
+		z: int64(math.Floor(p.Z / voxelSize)),
+	}
+}
+
+// voxelAccumulator sums the points and colors that fall into a single voxel.
+type voxelAccumulator struct {
+	sum        r3.Vector
+	count      int
+	r, g, b    int
+	colorCount int
+	intensity  int
+	dataCount  int
+	data       pointcloud.Data
+}
+
+// voxelDownsample buckets the points of the cloud into a voxel grid and returns a cloud with one point per
+// occupied voxel. The point is the centroid of the voxel's points, its color and intensity are the averages of their
+// colors and intensities, and its value is that of the first point.
+func voxelDownsample(pc pointcloud.PointCloud, voxelSize float64) (pointcloud.PointCloud, error) {
+	voxels := make(map[voxelKey]*voxelAccumulator)
+	var order []voxelKey
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		key := newVoxelKey(p, voxelSize)
+		acc, ok := voxels[key]
+		if !ok {
+			acc = &voxelAccumulator{data: d}
+			voxels[key] = acc
+			order = append(order, key)
+		}
+		acc.sum = acc.sum.Add(p)
+		acc.count++
+		if d != nil {
+			acc.intensity += int(d.Intensity())
+			acc.dataCount++
+		}
+		if d != nil && d.HasColor() {
+			r, g, b := d.RGB255()
+			acc.r += int(r)
+			acc.g += int(g)
+			acc.b += int(b)
+			acc.colorCount++
+		}
+		return true
+	})
+
+	downsampled := pointcloud.NewWithPrealloc(len(order))
+	for _, key := range order {
+		acc := voxels[key]
+		centroid := acc.sum.Mul(1 / float64(acc.count))
+
+		var d pointcloud.Data
+		if acc.dataCount > 0 {
+			d = pointcloud.NewBasicData()
+			if acc.colorCount > 0 {
+				d.SetColor(color.NRGBA{
+					R: uint8(acc.r / acc.colorCount),
+					G: uint8(acc.g / acc.colorCount),
+					B: uint8(acc.b / acc.colorCount),
+					A: 255,
+				})
+			}
+			if acc.data != nil && acc.data.HasValue() {
+				d.SetValue(acc.data.Value())
+			}
+			d.SetIntensity(uint16(acc.intensity / acc.dataCount))
+		}
+		if err := downsampled.Set(centroid, d); err != nil {
+			return nil, err
+		}
+	}
+	return downsampled, nil
+}
+
+// densityRegionLevels is the number of times resampleToDensity can halve a region before reaching the voxel of a
+// single point at the target density, so that regions start with an edge of 8 such voxels.
+const densityRegionLevels = 3
+
+// resampleToDensity thins the cloud toward the given density in points per cubic meter with an adaptive voxel size.
+// The voxel of a single point at the target density has an edge of the cube root of the inverse of the density. The
+// cloud is first bucketed into regions 2^densityRegionLevels such voxels on a side. A region whose points are at or
+// below the target density keeps all of them, while a denser region is split into eight octants, recursively, until
+// the octants reach the single point voxel, which keeps only the point closest to the centroid of its points. The
+// density of a region is measured over the bounding box of its points grown by a voxel along every axis, as each
+// point stands for the voxel it fills at the target density, so neither the empty part of a region nor two points
+// of a sparse region sharing a voxel cause any thinning. The kept points are neither moved nor blended, unlike with
+// voxelDownsample, and keep their order in the cloud.
+func resampleToDensity(pc pointcloud.PointCloud, density float64) (pointcloud.PointCloud, error) {
+	voxelSize := math.Cbrt(1/density) * mmPerMeter
+	regionSize := voxelSize * float64(int(1)<<densityRegionLevels)
+	points := make([]pointcloud.PointAndData, 0, pc.Size())
+	regions := make(map[voxelKey][]int)
+	var order []voxelKey
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		key := newVoxelKey(p, regionSize)
+		if _, ok := regions[key]; !ok {
+			order = append(order, key)
+		}
+		regions[key] = append(regions[key], len(points))
+		points = append(points, pointcloud.PointAndData{P: p, D: d})
+		return true
+	})
+
+	sampler := densitySampler{points: points, keep: make([]bool, len(points)), density: density, voxelSize: voxelSize}
+	for _, key := range order {
+		sampler.thin(regions[key], regionSize, densityRegionLevels)
+	}
+
+	resampled := pointcloud.NewWithPrealloc(sampler.kept)
+	for i, point := range points {
+		if !sampler.keep[i] {
+			continue
+		}
+		if err := resampled.Set(point.P, point.D); err != nil {
+			return nil, err
+		}
+	}
+	return resampled, nil
+}
+
+// densitySampler marks the points of a cloud that resampleToDensity keeps.
+type densitySampler struct {
+	points    []pointcloud.PointAndData
+	keep      []bool
+	kept      int
+	density   float64
+	voxelSize float64
+}
+
+// thin marks the points kept among the indices of a region with the given edge, which can be halved levels more
+// times before reaching the voxel of a single point.
+func (sampler *densitySampler) thin(indices []int, size float64, levels int) {
+	if len(indices) <= sampler.capacity(indices) {
+		for _, i := range indices {
+			sampler.mark(i)
+		}
+		return
+	}
+	if levels == 0 {
+		var sum r3.Vector
+		for _, i := range indices {
+			sum = sum.Add(sampler.points[i].P)
+		}
+		centroid := sum.Mul(1 / float64(len(indices)))
+		closest := indices[0]
+		for _, i := range indices[1:] {
+			if sampler.points[i].P.Sub(centroid).Norm2() < sampler.points[closest].P.Sub(centroid).Norm2() {
+				closest = i
+			}
+		}
+		sampler.mark(closest)
+		return
+	}
+
+	// halving a power of two multiple of the voxel size is exact, so the octants nest within the region
+	octants := make(map[voxelKey][]int, 8)
+	var order []voxelKey
+	for _, i := range indices {
+		key := newVoxelKey(sampler.points[i].P, size/2)
+		if _, ok := octants[key]; !ok {
+			order = append(order, key)
+		}
+		octants[key] = append(octants[key], i)
+	}
+	for _, key := range order {
+		sampler.thin(octants[key], size/2, levels-1)
+	}
+}
+
+// capacity returns the number of points the bounding box of the indexed points, grown by a voxel along every axis,
+// holds at the target density.
+func (sampler *densitySampler) capacity(indices []int) int {
+	lo, hi := sampler.points[indices[0]].P, sampler.points[indices[0]].P
+	for _, i := range indices[1:] {
+		p := sampler.points[i].P
+		lo = r3.Vector{X: math.Min(lo.X, p.X), Y: math.Min(lo.Y, p.Y), Z: math.Min(lo.Z, p.Z)}
+		hi = r3.Vector{X: math.Max(hi.X, p.X), Y: math.Max(hi.Y, p.Y), Z: math.Max(hi.Z, p.Z)}
+	}
+	voxel := r3.Vector{X: sampler.voxelSize, Y: sampler.voxelSize, Z: sampler.voxelSize}
+	extent := hi.Sub(lo).Add(voxel).Mul(1 / mmPerMeter)
+	return int(sampler.density * extent.X * extent.Y * extent.Z)
+}
+
+// mark keeps the point at index i.
+func (sampler *densitySampler) mark(i int) {
+	sampler.keep[i] = true
+	sampler.kept++
+}
+
+// dedupPoints keeps only the first point that falls into each cell of a spatial hash grid with the given
+// resolution. Unlike voxelDownsample the kept points are not moved or blended, so it is cheaper and preserves exact
+// positions, but every cell is collapsed to a single point, not only cells where cameras overlap. Choose a
+// resolution near the sensor noise so that only near-duplicates are merged.
+func dedupPoints(pc pointcloud.PointCloud, resolution float64) (pointcloud.PointCloud, error) {
+	seen := make(map[voxelKey]struct{}, pc.Size())
+	deduped := pointcloud.NewWithPrealloc(pc.Size())
+	var err error
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		key := newVoxelKey(p, resolution)
+		if _, ok := seen[key]; ok {
+			return true
+		}
+		seen[key] = struct{}{}
+		err = deduped.Set(p, d)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deduped, nil
+}
+
+// removeSmallClusters drops the points of every cluster of fewer than minSize points, where a cluster is a set of
+// points joined by chains of points each within tolerance of the next. Points are hashed into voxels of the
+// tolerance so that the neighbors of a point are only searched in the 27 voxels around it, yet every neighbor pair is
+// still compared, so the cost grows with the number of points times the number of points within tolerance of each.
+// The kept points stay in iteration order.
+func removeSmallClusters(pc pointcloud.PointCloud, tolerance float64, minSize int) (pointcloud.PointCloud, error) {
+	points := make([]pointcloud.PointAndData, 0, pc.Size())
+	voxels := make(map[voxelKey][]int)
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		key := newVoxelKey(p, tolerance)
+		voxels[key] = append(voxels[key], len(points))
+		points = append(points, pointcloud.PointAndData{P: p, D: d})
+		return true
+	})
+
+	parents := make([]int, len(points))
+	for i := range parents {
+		parents[i] = i
+	}
+	var root func(i int) int
+	root = func(i int) int {
+		if parents[i] != i {
+			parents[i] = root(parents[i])
+		}
+		return parents[i]
+	}
+	for i, point := range points {
+		center := newVoxelKey(point.P, tolerance)
+		for dx := int64(-1); dx <= 1; dx++ {
+			for dy := int64(-1); dy <= 1; dy++ {
+				for dz := int64(-1); dz <= 1; dz++ {
+					for _, j := range voxels[voxelKey{x: center.x + dx, y: center.y + dy, z: center.z + dz}] {
+						if j <= i || point.P.Distance(points[j].P) > tolerance {
+							continue
+						}
+						if a, b := root(i), root(j); a != b {
+							parents[a] = b
+						}
+					}
+				}
+			}
+		}
+	}
+
+	sizes := make(map[int]int)
+	for i := range points {
+		sizes[root(i)]++
+	}
+	kept := pointcloud.NewWithPrealloc(len(points))
+	for i, point := range points {
+		if sizes[root(i)] < minSize {
+			continue
+		}
+		if err := kept.Set(point.P, point.D); err != nil {
+			return nil, err
+		}
+	}
+	return kept, nil
+}
+
+// subsample returns a cloud of maxPoints points picked at even intervals from the cloud's iteration order. Whether
+// the merge interleaves the points of the cameras or appends them camera by camera, every camera keeps roughly the
+// same fraction of its points.
+func subsample(pc pointcloud.PointCloud, maxPoints int) (pointcloud.PointCloud, error) {
+	size := pc.Size()
+	if size <= maxPoints {
+		return pc, nil
+	}
+	sampled := pointcloud.NewWithPrealloc(maxPoints)
+	var err error
+	i, kept := 0, 0
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		// keep the point when it is the first to cross the next sampling boundary
+		if (i+1)*maxPoints/size > kept {
+			err = sampled.Set(p, d)
+			kept++
+		}
+		i++
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sampled, nil
+}
+
+// preferPriority keeps, within every cell of a grid with the given resolution, only the points of the sources with
+// the highest priority in that cell. The source of a point is the value set by tagSource and indexes priorities;
+// points with an unknown source have priority zero.
+func preferPriority(pc pointcloud.PointCloud, resolution float64, priorities []int) (pointcloud.PointCloud, error) {
+	priorityOf := func(d pointcloud.Data) int {
+		if d == nil || !d.HasValue() || d.Value() < 0 || d.Value() >= len(priorities) {
+			return 0
+		}
+		return priorities[d.Value()]
+	}
+
+	best := make(map[voxelKey]int)
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		key := newVoxelKey(p, resolution)
+		if current, ok := best[key]; !ok || priorityOf(d) > current {
+			best[key] = priorityOf(d)
+		}
+		return true
+	})
+
+	preferred := pointcloud.NewWithPrealloc(pc.Size())
+	var err error
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		if priorityOf(d) == best[newVoxelKey(p, resolution)] {
+			err = preferred.Set(p, d)
+		}
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return preferred, nil
+}
+
+// ColorConfig is an RGB color with 8 bit channels.
+type ColorConfig struct {
+	R int `json:"r"`
+	G int `json:"g"`
+	B int `json:"b"`
+}
+
+// Validate checks that every channel is between 0 and 255.
+func (cfg *ColorConfig) Validate() error {
+	for _, channel := range []struct {
+		name  string
+		value int
+	}{{"r", cfg.R}, {"g", cfg.G}, {"b", cfg.B}} {
+		if channel.value < 0 || channel.value > 255 {
+			return errors.Errorf("%v must be between 0 and 255, got %v", channel.name, channel.value)
+		}
+	}
+	return nil
+}
+
+// nrgba returns the color as an opaque color.NRGBA.
+func (cfg *ColorConfig) nrgba() color.NRGBA {
+	return color.NRGBA{R: uint8(cfg.R), G: uint8(cfg.G), B: uint8(cfg.B), A: 255}
+}
+
+// RGBConfig holds a value for each color channel.
+type RGBConfig struct {
+	R float64 `json:"r"`
+	G float64 `json:"g"`
+	B float64 `json:"b"`
+}
+
+// ColorCorrectionConfig describes a per channel correction of the colors of a camera, e.g. to white balance it
+// against the other cameras. Each channel is multiplied by its gain and then shifted by its offset, on the 0 to 255
+// scale of the channel, and the result is clamped to that scale.
+type ColorCorrectionConfig struct {
+	// Gain multiplies each channel. Defaults to 1 for every channel.
+	Gain *RGBConfig `json:"gain,omitempty"`
+	// Offset is added to each channel after the gain. Defaults to 0 for every channel.
+	Offset *RGBConfig `json:"offset,omitempty"`
+}
+
+// Validate checks that the gains are non-negative and the offsets between -255 and 255.
+func (cfg *ColorCorrectionConfig) Validate() error {
+	if cfg.Gain != nil {
+		for _, channel := range []struct {
+			name  string
+			value float64
+		}{{"r", cfg.Gain.R}, {"g", cfg.Gain.G}, {"b", cfg.Gain.B}} {
+			if channel.value < 0 {
+				return errors.Errorf("gain %v must be non-negative, got %v", channel.name, channel.value)
+			}
+		}
+	}
+	if cfg.Offset != nil {
+		for _, channel := range []struct {
+			name  string
+			value float64
+		}{{"r", cfg.Offset.R}, {"g", cfg.Offset.G}, {"b", cfg.Offset.B}} {
+			if channel.value < -255 || channel.value > 255 {
+				return errors.Errorf("offset %v must be between -255 and 255, got %v", channel.name, channel.value)
+			}
+		}
+	}
+	return nil
+}
+
+// colorCorrection is a ColorCorrectionConfig with its defaults applied, indexed by channel.
+type colorCorrection struct {
+	gain, offset [3]float64
+}
+
+// correction returns the correction described by the config.
+func (cfg *ColorCorrectionConfig) correction() *colorCorrection {
+	correction := &colorCorrection{gain: [3]float64{1, 1, 1}}
+	if cfg.Gain != nil {
+		correction.gain = [3]float64{cfg.Gain.R, cfg.Gain.G, cfg.Gain.B}
+	}
+	if cfg.Offset != nil {
+		correction.offset = [3]float64{cfg.Offset.R, cfg.Offset.G, cfg.Offset.B}
+	}
+	return correction
+}
+
+// apply returns the corrected color, rounding and clamping every channel to the 0 to 255 scale.
+func (correction *colorCorrection) apply(r, g, b uint8) color.NRGBA {
+	channels := [3]uint8{r, g, b}
+	for i, c := range channels {
+		v := math.Round(float64(c)*correction.gain[i] + correction.offset[i])
+		channels[i] = uint8(math.Max(0, math.Min(255, v)))
+	}
+	return color.NRGBA{R: channels[0], G: channels[1], B: channels[2], A: 255}
+}
+
+// correctColor returns a cloud with the colors of the given cloud corrected. Points without color are kept as they
+// are, and a cloud without any color is returned as is.
+func correctColor(pc pointcloud.PointCloud, correction *colorCorrection) (pointcloud.PointCloud, error) {
+	if !pc.MetaData().HasColor {
+		return pc, nil
+	}
+	corrected := pointcloud.NewWithPrealloc(pc.Size())
+	var err error
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		if d != nil && d.HasColor() {
+			d = copyData(d)
+			d.SetColor(correction.apply(d.RGB255()))
+		}
+		err = corrected.Set(p, d)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return corrected, nil
+}
+
+// cloudSchema is the set of channels carried by the points of a cloud besides their position and intensity, which
+// every point has.
+type cloudSchema struct {
+	color bool
+	value bool
+}
+
+// unionSchema returns the channels carried by any point of any of the clouds.
+func unionSchema(clouds []pointcloud.PointCloud) cloudSchema {
+	var schema cloudSchema
+	for _, pc := range clouds {
+		meta := pc.MetaData()
+		schema.color = schema.color || meta.HasColor
+		schema.value = schema.value || meta.HasValue
+	}
+	return schema
+}
+
+// fillSchema gives every point of the cloud the channels of the schema it lacks, black for color and defaultValue
+// for value. The cloud is returned as is when every point already has them.
+func fillSchema(pc pointcloud.PointCloud, schema cloudSchema, defaultValue int) (pointcloud.PointCloud, error) {
+	lacks := func(d pointcloud.Data) bool {
+		return d == nil || (schema.color && !d.HasColor()) || (schema.value && !d.HasValue())
+	}
+	needsCopy := false
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		needsCopy = lacks(d)
+		return !needsCopy
+	})
+	if !needsCopy {
+		return pc, nil
+	}
+
+	filled := pointcloud.NewWithPrealloc(pc.Size())
+	var err error
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		if lacks(d) {
+			if d == nil {
+				d = pointcloud.NewBasicData()
+			} else {
+				d = copyData(d)
+			}
+			if schema.color && !d.HasColor() {
+				d.SetColor(color.NRGBA{A: 255})
+			}
+			if schema.value && !d.HasValue() {
+				d.SetValue(defaultValue)
+			}
+		}
+		err = filled.Set(p, d)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return filled, nil
+}
+
+// normalizeColor applies the color mode to the cloud of a single camera, after giving every uncolored point the
+// default color if one is set. Points without data are always given empty data, since pointcloud.MergePointClouds,
+// which the "rdk" merge strategy uses, drops the data of every point once it sees a point without any.
+func normalizeColor(pc pointcloud.PointCloud, colorMode string, defaultColor *color.NRGBA) (pointcloud.PointCloud, error) {
+	needsCopy := colorMode == colorModeStrip && pc.MetaData().HasColor
+	var uncolored bool
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		if d == nil {
+			needsCopy = true
+		}
+		if d == nil || !d.HasColor() {
+			uncolored = true
+		}
+		return true
+	})
+	if defaultColor != nil && uncolored {
+		needsCopy, uncolored = true, false
+	}
+	if colorMode == colorModeRequire && uncolored {
+		return nil, errors.New("point cloud contains points without color")
+	}
+	if !needsCopy {
+		return pc, nil
+	}
+
+	normalized := pointcloud.NewWithPrealloc(pc.Size())
+	var err error
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		switch {
+		case d == nil:
+			d = pointcloud.NewBasicData()
+		case colorMode == colorModeStrip && d.HasColor():
+			stripped := pointcloud.NewBasicData()
+			if d.HasValue() {
+				stripped.SetValue(d.Value())
+			}
+			stripped.SetIntensity(d.Intensity())
+			d = stripped
+		}
+		if defaultColor != nil && !d.HasColor() {
+			colored := copyData(d)
+			colored.SetColor(*defaultColor)
+			d = colored
+		}
+		err = normalized.Set(p, d)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// scalePointCloud returns a cloud with every point of the given cloud multiplied by the scale.
+func scalePointCloud(pc pointcloud.PointCloud, scale float64) (pointcloud.PointCloud, error) {
+	scaled := pointcloud.NewWithPrealloc(pc.Size())
+	var err error
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		err = scaled.Set(p.Mul(scale), d)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return scaled, nil
+}
+
+// transformPointCloud returns a cloud with every point of the given cloud transformed by the pose.
+func transformPointCloud(pc pointcloud.PointCloud, pose spatialmath.Pose) (pointcloud.PointCloud, error) {
+	transformed := pointcloud.NewWithPrealloc(pc.Size())
+	var err error
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		err = transformed.Set(spatialmath.Compose(pose, spatialmath.NewPoseFromPoint(p)).Point(), d)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transformed, nil
+}
+
+// appendPointClouds merges the clouds of the results into a single cloud sized for all of their points, transforming
+// each point by the pose of its result.
+func appendPointClouds(results []cameraResult) (pointcloud.PointCloud, error) {
+	total := 0
+	for _, result := range results {
+		total += result.pc.Size()
+	}
+	merged := pointcloud.NewWithPrealloc(total)
+	for _, result := range results {
+		if err := appendTransformed(merged, result.pc, result.pose); err != nil {
+			return nil, err
+		}
+	}
+	return merged, nil
+}
+
+// mergeInChunks merges the clouds of the results like appendPointClouds, transforming them on a pool of workers. Each
+// cloud is split into one chunk of consecutive points per worker and the workers transform the chunks concurrently
+// into partial clouds, which are then reduced into the merged cloud in order of camera and chunk. The merged cloud
+// therefore holds the same points in the same order as appendPointClouds however the workers are scheduled.
+func mergeInChunks(ctx context.Context, results []cameraResult, workers int) (pointcloud.PointCloud, error) {
+	type chunk struct {
+		result *cameraResult
+		batch  int
+	}
+	var chunks []chunk
+	total := 0
+	for i := range results {
+		size := results[i].pc.Size()
+		total += size
+		for batch := 0; batch < workers && batch*((size+workers-1)/workers) < size; batch++ {
+			chunks = append(chunks, chunk{result: &results[i], batch: batch})
+		}
+	}
+
+	partials := make([]pointcloud.PointCloud, len(chunks))
+	errs := make([]error, len(chunks))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i := range chunks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if err := ctx.Err(); err != nil {
+				errs[i] = err
+				return
+			}
+			c := chunks[i]
+			rotation := rotationOf(c.result.pose.Orientation())
+			translation := c.result.pose.Point()
+			partial := pointcloud.NewWithPrealloc((c.result.pc.Size() + workers - 1) / workers)
+			c.result.pc.Iterate(workers, c.batch, func(p r3.Vector, d pointcloud.Data) bool {
+				errs[i] = partial.Set(translation.Add(rotation.apply(p)), d)
+				return errs[i] == nil
+			})
+			partials[i] = partial
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return nil, errors.Wrapf(err, "issue transforming chunk %v of camera %v", chunks[i].batch, chunks[i].result.name)
+		}
+	}
+
+	merged := pointcloud.NewWithPrealloc(total)
+	for _, partial := range partials {
+		var err error
+		partial.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+			err = merged.Set(p, d)
+			return err == nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return merged, nil
+}
+
+// appendTransformed sets every point of the cloud, transformed by the pose, in dst.
+func appendTransformed(dst, pc pointcloud.PointCloud, pose spatialmath.Pose) error {
+	rotation := rotationOf(pose.Orientation())
+	translation := pose.Point()
+	var err error
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		err = dst.Set(translation.Add(rotation.apply(p)), d)
+		return err == nil
+	})
+	return err
+}
+
+// filterConfidence returns a cloud of the points whose confidence, carried in the intensity of their data, is at
+// least minConfidence. Points without data have no confidence and are dropped.
+func filterConfidence(pc pointcloud.PointCloud, minConfidence uint16) (pointcloud.PointCloud, error) {
+	filtered := pointcloud.NewWithPrealloc(pc.Size())
+	var err error
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		if d != nil && d.Intensity() >= minConfidence {
+			err = filtered.Set(p, d)
+		}
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return filtered, nil
+}
+
+// tagSource returns a copy of the cloud with the value of every point set to the given source index. Colors and
+// intensities are preserved while any existing value is replaced.
+func tagSource(pc pointcloud.PointCloud, sourceIndex int) (pointcloud.PointCloud, error) {
+	tagged := pointcloud.NewWithPrealloc(pc.Size())
+	var err error
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		taggedData := pointcloud.NewValueData(sourceIndex)
+		if d != nil {
+			if d.HasColor() {
+				r, g, b := d.RGB255()
+				taggedData.SetColor(color.NRGBA{R: r, G: g, B: b, A: 255})
+			}
+			taggedData.SetIntensity(d.Intensity())
+		}
+		err = tagged.Set(p, taggedData)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tagged, nil
+}
+
+// toOctree returns an octree spanning the bounding box of the cloud holding all of its points. A positive
+// resolution first collapses the points within each cell of that size to the first one.
+func toOctree(pc pointcloud.PointCloud, resolution float64) (*pointcloud.BasicOctree, error) {
+	var err error
+	if resolution > 0 {
+		pc, err = dedupPoints(pc, resolution)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	meta := pc.MetaData()
+	center := r3.Vector{X: 0, Y: 0, Z: 0}
+	sideLength := 1.0
+	if pc.Size() > 0 {
+		center = r3.Vector{X: (meta.MaxX + meta.MinX) / 2, Y: (meta.MaxY + meta.MinY) / 2, Z: (meta.MaxZ + meta.MinZ) / 2}
+		sideLength = math.Max(meta.MaxX-meta.MinX, math.Max(meta.MaxY-meta.MinY, meta.MaxZ-meta.MinZ)) + 1
+	}
+	octree, err := pointcloud.NewBasicOctree(center, sideLength)
+	if err != nil {
+		return nil, err
+	}
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		err = octree.Set(p, d)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return octree, nil
+}
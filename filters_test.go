@@ -0,0 +1,765 @@
+package main
+
+import (
+	"context"
+	"image/color"
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/test"
+)
+
+func TestVoxelDownsample(t *testing.T) {
+	pc := pointcloud.New()
+	test.That(t, pc.Set(r3.Vector{X: 0.1, Y: 0.1, Z: 0.1}, pointcloud.NewColoredData(color.NRGBA{R: 100, A: 255}).SetIntensity(100)),
+		test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 0.3, Y: 0.3, Z: 0.3}, pointcloud.NewColoredData(color.NRGBA{R: 200, A: 255}).SetIntensity(300)),
+		test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 1.5, Y: 0.1, Z: 0.1}, pointcloud.NewColoredData(color.NRGBA{B: 50, A: 255})), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: -0.1, Y: 0.1, Z: 0.1}, pointcloud.NewBasicData()), test.ShouldBeNil)
+
+	downsampled, err := voxelDownsample(pc, 1)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, downsampled.Size(), test.ShouldEqual, 3)
+
+	d, ok := downsampled.At(0.2, 0.2, 0.2)
+	test.That(t, ok, test.ShouldBeTrue)
+	r, g, b := d.RGB255()
+	test.That(t, r, test.ShouldEqual, 150)
+	test.That(t, g, test.ShouldEqual, 0)
+	test.That(t, b, test.ShouldEqual, 0)
+	test.That(t, d.Intensity(), test.ShouldEqual, 200)
+
+	d, ok = downsampled.At(1.5, 0.1, 0.1)
+	test.That(t, ok, test.ShouldBeTrue)
+	_, _, b = d.RGB255()
+	test.That(t, b, test.ShouldEqual, 50)
+
+	d, ok = downsampled.At(-0.1, 0.1, 0.1)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.HasColor(), test.ShouldBeFalse)
+}
+
+func TestCropToBox(t *testing.T) {
+	box := &BoxConfig{Min: r3.Vector{X: -1, Y: -1, Z: 0}, Max: r3.Vector{X: 1, Y: 1, Z: 2}}
+
+	pc := pointcloud.New()
+	test.That(t, pc.Set(r3.Vector{X: 0, Y: 0, Z: 1}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 1, Y: -1, Z: 2}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: -1, Y: 1, Z: 0}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 1.01, Y: 0, Z: 1}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 0, Y: 0, Z: -0.01}, pointcloud.NewBasicData()), test.ShouldBeNil)
+
+	cropped, err := cropToBox(pc, box, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cropped.Size(), test.ShouldEqual, 3)
+
+	// points exactly on the boundary are retained
+	_, ok := cropped.At(1, -1, 2)
+	test.That(t, ok, test.ShouldBeTrue)
+	_, ok = cropped.At(-1, 1, 0)
+	test.That(t, ok, test.ShouldBeTrue)
+	_, ok = cropped.At(1.01, 0, 1)
+	test.That(t, ok, test.ShouldBeFalse)
+
+	// the box is expressed in a frame 10mm along X and rotated a quarter turn about Z
+	frame := spatialmath.NewPose(r3.Vector{X: 10}, &spatialmath.OrientationVectorDegrees{OZ: 1, Theta: 90})
+	framed := pointcloud.New()
+	test.That(t, framed.Set(r3.Vector{X: 10, Y: 0.5, Z: 1}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, framed.Set(r3.Vector{X: 10.9, Y: 0, Z: 1}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, framed.Set(r3.Vector{X: 10, Y: 1.5, Z: 1}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, framed.Set(r3.Vector{X: 0, Y: 0, Z: 1}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	cropped, err = cropToBox(framed, box, frame)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cropped.Size(), test.ShouldEqual, 2)
+	// kept points are left in the frame of the cloud
+	_, ok = cropped.At(10.9, 0, 1)
+	test.That(t, ok, test.ShouldBeTrue)
+
+	invalid := &BoxConfig{Min: r3.Vector{X: 1}, Max: r3.Vector{X: 0, Y: 1, Z: 1}}
+	test.That(t, invalid.Validate(), test.ShouldNotBeNil)
+	flat := &BoxConfig{Min: r3.Vector{X: -1, Y: -1, Z: 1}, Max: r3.Vector{X: 1, Y: 1, Z: 1}}
+	err = flat.Validate()
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "min.z (1) must be less than max.z (1)")
+}
+
+func TestExcludeBoxes(t *testing.T) {
+	boxes := []*BoxConfig{
+		{Min: r3.Vector{X: -1, Y: -1, Z: 0}, Max: r3.Vector{X: 1, Y: 1, Z: 2}},
+		{Min: r3.Vector{X: 5, Y: 5, Z: 5}, Max: r3.Vector{X: 6, Y: 6, Z: 6}},
+	}
+
+	pc := pointcloud.New()
+	test.That(t, pc.Set(r3.Vector{X: 0, Y: 0, Z: 1}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 1, Y: -1, Z: 2}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 5.5, Y: 5.5, Z: 5.5}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 1.01, Y: 0, Z: 1}, pointcloud.NewBasicData()), test.ShouldBeNil)
+
+	kept, err := excludeBoxes(pc, boxes)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, kept.Size(), test.ShouldEqual, 1)
+	_, ok := kept.At(1.01, 0, 1)
+	test.That(t, ok, test.ShouldBeTrue)
+}
+
+func TestDropInvalidPoints(t *testing.T) {
+	pc := pointcloud.New()
+	test.That(t, pc.Set(r3.Vector{X: 1, Y: 2, Z: 3}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: math.NaN(), Y: 2, Z: 3}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 4, Y: 5, Z: math.NaN()}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 4, Y: 5, Z: 6}, pointcloud.NewBasicData()), test.ShouldBeNil)
+
+	filtered, dropped, err := dropInvalidPoints(pc)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, dropped, test.ShouldEqual, 2)
+	test.That(t, filtered.Size(), test.ShouldEqual, 2)
+	filtered.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		test.That(t, isFinite(p), test.ShouldBeTrue)
+		return true
+	})
+
+	// a cloud without invalid points is returned as is
+	unchanged, dropped, err := dropInvalidPoints(filtered)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, dropped, test.ShouldEqual, 0)
+	test.That(t, unchanged, test.ShouldEqual, filtered)
+
+	test.That(t, isFinite(r3.Vector{Y: math.Inf(1)}), test.ShouldBeFalse)
+	test.That(t, isFinite(r3.Vector{Z: math.Inf(-1)}), test.ShouldBeFalse)
+}
+
+func TestFilterRange(t *testing.T) {
+	pc := pointcloud.New()
+	test.That(t, pc.Set(r3.Vector{X: 0, Y: 0, Z: 50}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 0, Y: 300, Z: 400}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 0, Y: 0, Z: 5000}, pointcloud.NewBasicData()), test.ShouldBeNil)
+
+	filtered, err := filterRange(pc, 100, 1000)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, filtered.Size(), test.ShouldEqual, 1)
+	_, ok := filtered.At(0, 300, 400)
+	test.That(t, ok, test.ShouldBeTrue)
+
+	filtered, err = filterRange(pc, 100, 0)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, filtered.Size(), test.ShouldEqual, 2)
+}
+
+func TestDedupPoints(t *testing.T) {
+	pc := pointcloud.New()
+	test.That(t, pc.Set(r3.Vector{X: 1, Y: 1, Z: 1}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 1.5, Y: 1.5, Z: 1.5}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 3, Y: 1, Z: 1}, pointcloud.NewBasicData()), test.ShouldBeNil)
+
+	deduped, err := dedupPoints(pc, 2)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, deduped.Size(), test.ShouldEqual, 2)
+
+	// the first point of each cell is kept unchanged
+	_, ok := deduped.At(1, 1, 1)
+	test.That(t, ok, test.ShouldBeTrue)
+	_, ok = deduped.At(3, 1, 1)
+	test.That(t, ok, test.ShouldBeTrue)
+}
+
+func TestRemoveSmallClusters(t *testing.T) {
+	pc := pointcloud.New()
+	// a line of points 5mm apart is one cluster even though its ends are far apart
+	for x := 0.0; x < 50; x += 5 {
+		test.That(t, pc.Set(r3.Vector{X: x}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	}
+	// a clump of three points and a lone point
+	for _, p := range []r3.Vector{{X: 500}, {X: 501}, {X: 500, Y: 1}, {Y: 500}} {
+		test.That(t, pc.Set(p, pointcloud.NewBasicData()), test.ShouldBeNil)
+	}
+
+	kept, err := removeSmallClusters(pc, 6, 5)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, kept.Size(), test.ShouldEqual, 10)
+	_, ok := kept.At(45, 0, 0)
+	test.That(t, ok, test.ShouldBeTrue)
+	_, ok = kept.At(500, 0, 0)
+	test.That(t, ok, test.ShouldBeFalse)
+
+	// the clump is kept once it is large enough, the lone point never is
+	kept, err = removeSmallClusters(pc, 6, 3)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, kept.Size(), test.ShouldEqual, 13)
+
+	// below the spacing of the line, every point of it is on its own
+	kept, err = removeSmallClusters(pc, 4, 2)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, kept.Size(), test.ShouldEqual, 3)
+
+	// neighbors are found across voxel boundaries
+	pair := pointcloud.New()
+	test.That(t, pair.Set(r3.Vector{X: 5.5, Y: -0.5}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, pair.Set(r3.Vector{X: 6.5, Y: 0.5}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	kept, err = removeSmallClusters(pair, 6, 2)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, kept.Size(), test.ShouldEqual, 2)
+}
+
+// createOverlappingClouds returns the union of two 100x100 grids of points with 1mm spacing that overlap over half
+// of their area and are offset from each other by a small amount of noise.
+func createOverlappingClouds(b *testing.B) pointcloud.PointCloud {
+	pc := pointcloud.New()
+	for i := 0; i < 100; i++ {
+		for j := 0; j < 100; j++ {
+			if err := pc.Set(r3.Vector{X: float64(i), Y: float64(j), Z: 0}, pointcloud.NewBasicData()); err != nil {
+				b.Fatal(err)
+			}
+			if err := pc.Set(r3.Vector{X: float64(i+50) + 0.1, Y: float64(j) + 0.1, Z: 0.1}, pointcloud.NewBasicData()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	return pc
+}
+
+func BenchmarkDedupPoints(b *testing.B) {
+	pc := createOverlappingClouds(b)
+	b.ResetTimer()
+
+	var deduped pointcloud.PointCloud
+	var err error
+	for i := 0; i < b.N; i++ {
+		deduped, err = dedupPoints(pc, 0.5)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(pc.Size()), "points_in")
+	b.ReportMetric(float64(deduped.Size()), "points_out")
+}
+
+func BenchmarkRemoveSmallClusters(b *testing.B) {
+	pc := createOverlappingClouds(b)
+	b.ResetTimer()
+
+	var kept pointcloud.PointCloud
+	var err error
+	for i := 0; i < b.N; i++ {
+		kept, err = removeSmallClusters(pc, 2, 10)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(pc.Size()), "points_in")
+	b.ReportMetric(float64(kept.Size()), "points_out")
+}
+
+func TestResampleToDensity(t *testing.T) {
+	// a uniform cube 200mm on a side with a point every 5mm, a density of 8,000,000 points per cubic meter
+	pc := pointcloud.New()
+	for x := 0.0; x < 200; x += 5 {
+		for y := 0.0; y < 200; y += 5 {
+			for z := 0.0; z < 200; z += 5 {
+				test.That(t, pc.Set(r3.Vector{X: x, Y: y, Z: z}, pointcloud.NewBasicData()), test.ShouldBeNil)
+			}
+		}
+	}
+
+	const density = 125000.0
+	resampled, err := resampleToDensity(pc, density)
+	test.That(t, err, test.ShouldBeNil)
+	volume := 0.2 * 0.2 * 0.2
+	test.That(t, float64(resampled.Size())/volume, test.ShouldAlmostEqual, density, 0.1*density)
+	// kept points are points of the input rather than centroids
+	resampled.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		_, ok := pc.At(p.X, p.Y, p.Z)
+		test.That(t, ok, test.ShouldBeTrue)
+		return true
+	})
+
+	// points sparser than the target are kept as they are
+	sparse := pointcloud.New()
+	colored := pointcloud.NewColoredData(color.NRGBA{R: 255, A: 255})
+	test.That(t, sparse.Set(r3.Vector{X: 0}, colored), test.ShouldBeNil)
+	test.That(t, sparse.Set(r3.Vector{X: 100}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	resampled, err = resampleToDensity(sparse, density)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resampled.Size(), test.ShouldEqual, 2)
+	d, ok := resampled.At(0, 0, 0)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d, test.ShouldEqual, colored)
+
+	// close points of a sparse region are kept even though they share the voxel of a single point at the target
+	sparse = pointcloud.New()
+	for _, p := range []r3.Vector{{X: 1}, {X: 2}, {X: 3}, {X: 100}} {
+		test.That(t, sparse.Set(p, pointcloud.NewBasicData()), test.ShouldBeNil)
+	}
+	resampled, err = resampleToDensity(sparse, density)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resampled.Size(), test.ShouldEqual, 4)
+}
+
+func TestFillSchema(t *testing.T) {
+	red := color.NRGBA{R: 255, A: 255}
+	colored := pointcloud.New()
+	d := pointcloud.NewColoredData(red)
+	d.SetValue(3)
+	test.That(t, colored.Set(r3.Vector{X: 1}, d), test.ShouldBeNil)
+	plain := pointcloud.New()
+	test.That(t, plain.Set(r3.Vector{X: 2}, nil), test.ShouldBeNil)
+	valued := pointcloud.New()
+	test.That(t, valued.Set(r3.Vector{X: 3}, pointcloud.NewValueData(7)), test.ShouldBeNil)
+
+	test.That(t, unionSchema([]pointcloud.PointCloud{plain}), test.ShouldResemble, cloudSchema{})
+	test.That(t, unionSchema([]pointcloud.PointCloud{plain, valued}), test.ShouldResemble, cloudSchema{value: true})
+	schema := unionSchema([]pointcloud.PointCloud{colored, plain, valued})
+	test.That(t, schema, test.ShouldResemble, cloudSchema{color: true, value: true})
+
+	// a cloud that already has every channel is returned as is
+	filled, err := fillSchema(colored, schema, -1)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, filled, test.ShouldEqual, colored)
+
+	filled, err = fillSchema(plain, schema, -1)
+	test.That(t, err, test.ShouldBeNil)
+	d, ok := filled.At(2, 0, 0)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.HasColor(), test.ShouldBeTrue)
+	test.That(t, d.Color(), test.ShouldResemble, &color.NRGBA{A: 255})
+	test.That(t, d.Value(), test.ShouldEqual, -1)
+
+	filled, err = fillSchema(valued, schema, -1)
+	test.That(t, err, test.ShouldBeNil)
+	d, ok = filled.At(3, 0, 0)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.HasColor(), test.ShouldBeTrue)
+	test.That(t, d.Value(), test.ShouldEqual, 7)
+	// the source cloud is left untouched
+	d, _ = valued.At(3, 0, 0)
+	test.That(t, d.HasColor(), test.ShouldBeFalse)
+}
+
+func TestSubsample(t *testing.T) {
+	pc := pointcloud.New()
+	for i := 0; i < 10; i++ {
+		test.That(t, pc.Set(r3.Vector{X: float64(i)}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	}
+
+	sampled, err := subsample(pc, 4)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, sampled.Size(), test.ShouldEqual, 4)
+	var xs []float64
+	sampled.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		xs = append(xs, p.X)
+		return true
+	})
+	test.That(t, xs, test.ShouldResemble, []float64{2, 4, 7, 9})
+
+	sampled, err = subsample(pc, 20)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, sampled.Size(), test.ShouldEqual, 10)
+}
+
+func TestPreferPriority(t *testing.T) {
+	pc := pointcloud.New()
+	// cameras 0 and 1 overlap in the first cell, camera 0 alone sees the second cell
+	test.That(t, pc.Set(r3.Vector{X: 1}, pointcloud.NewValueData(0)), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 2}, pointcloud.NewValueData(1)), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 3}, pointcloud.NewValueData(1)), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 11}, pointcloud.NewValueData(0)), test.ShouldBeNil)
+
+	preferred, err := preferPriority(pc, 10, []int{0, 5})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, preferred.Size(), test.ShouldEqual, 3)
+	_, ok := preferred.At(1, 0, 0)
+	test.That(t, ok, test.ShouldBeFalse)
+	_, ok = preferred.At(2, 0, 0)
+	test.That(t, ok, test.ShouldBeTrue)
+	_, ok = preferred.At(3, 0, 0)
+	test.That(t, ok, test.ShouldBeTrue)
+	_, ok = preferred.At(11, 0, 0)
+	test.That(t, ok, test.ShouldBeTrue)
+
+	// cameras with equal priority are both kept
+	preferred, err = preferPriority(pc, 10, []int{1, 1})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, preferred.Size(), test.ShouldEqual, 4)
+}
+
+func TestCorrectColor(t *testing.T) {
+	pc := pointcloud.New()
+	test.That(t, pc.Set(r3.Vector{X: 1}, pointcloud.NewColoredData(color.NRGBA{R: 100, G: 200, B: 10, A: 255}).SetValue(7)),
+		test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 2}, pointcloud.NewValueData(3)), test.ShouldBeNil)
+
+	correction := (&ColorCorrectionConfig{
+		Gain:   &RGBConfig{R: 1.5, G: 1.5, B: 0.5},
+		Offset: &RGBConfig{R: 10, G: 0, B: -20},
+	}).correction()
+	corrected, err := correctColor(pc, correction)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, corrected.Size(), test.ShouldEqual, 2)
+
+	// red is scaled and shifted, green saturates at 255 and blue clamps at 0
+	d, ok := corrected.At(1, 0, 0)
+	test.That(t, ok, test.ShouldBeTrue)
+	r, g, b := d.RGB255()
+	test.That(t, []uint8{r, g, b}, test.ShouldResemble, []uint8{160, 255, 0})
+	test.That(t, d.Value(), test.ShouldEqual, 7)
+	// the source cloud is unchanged
+	d, _ = pc.At(1, 0, 0)
+	r, _, _ = d.RGB255()
+	test.That(t, r, test.ShouldEqual, 100)
+
+	// uncolored points stay uncolored
+	d, ok = corrected.At(2, 0, 0)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.HasColor(), test.ShouldBeFalse)
+	test.That(t, d.Value(), test.ShouldEqual, 3)
+
+	// a cloud without color is returned as is
+	uncolored := pointcloud.New()
+	test.That(t, uncolored.Set(r3.Vector{X: 1}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	same, err := correctColor(uncolored, correction)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, same, test.ShouldEqual, uncolored)
+
+	// omitted gains and offsets leave colors unchanged
+	identity := (&ColorCorrectionConfig{}).correction()
+	test.That(t, identity.apply(1, 2, 3), test.ShouldResemble, color.NRGBA{R: 1, G: 2, B: 3, A: 255})
+
+	test.That(t, (&ColorCorrectionConfig{Gain: &RGBConfig{R: -1}}).Validate(), test.ShouldNotBeNil)
+	test.That(t, (&ColorCorrectionConfig{Offset: &RGBConfig{B: 300}}).Validate(), test.ShouldNotBeNil)
+}
+
+func TestNormalizeColor(t *testing.T) {
+	pc := pointcloud.New()
+	colored := pointcloud.NewColoredData(color.NRGBA{R: 10, G: 20, B: 30, A: 255})
+	colored.SetValue(7)
+	test.That(t, pc.Set(r3.Vector{X: 1}, colored), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 2}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 3}, nil), test.ShouldBeNil)
+
+	t.Run("preserve", func(t *testing.T) {
+		normalized, err := normalizeColor(pc, colorModePreserve, nil)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, normalized.Size(), test.ShouldEqual, 3)
+		d, ok := normalized.At(1, 0, 0)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, d.HasColor(), test.ShouldBeTrue)
+		d, ok = normalized.At(3, 0, 0)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, d, test.ShouldNotBeNil)
+	})
+
+	t.Run("strip", func(t *testing.T) {
+		normalized, err := normalizeColor(pc, colorModeStrip, nil)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, normalized.Size(), test.ShouldEqual, 3)
+		test.That(t, normalized.MetaData().HasColor, test.ShouldBeFalse)
+		d, ok := normalized.At(1, 0, 0)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, d.HasColor(), test.ShouldBeFalse)
+		test.That(t, d.Value(), test.ShouldEqual, 7)
+	})
+
+	t.Run("require", func(t *testing.T) {
+		_, err := normalizeColor(pc, colorModeRequire, nil)
+		test.That(t, err, test.ShouldNotBeNil)
+
+		allColored := pointcloud.New()
+		test.That(t, allColored.Set(r3.Vector{X: 1}, colored), test.ShouldBeNil)
+		normalized, err := normalizeColor(allColored, colorModeRequire, nil)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, normalized, test.ShouldEqual, allColored)
+	})
+
+	t.Run("default color", func(t *testing.T) {
+		gray := &color.NRGBA{R: 128, G: 128, B: 128, A: 255}
+		normalized, err := normalizeColor(pc, colorModePreserve, gray)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, normalized.Size(), test.ShouldEqual, 3)
+		for _, x := range []float64{2, 3} {
+			d, ok := normalized.At(x, 0, 0)
+			test.That(t, ok, test.ShouldBeTrue)
+			r, g, b := d.RGB255()
+			test.That(t, []uint8{r, g, b}, test.ShouldResemble, []uint8{128, 128, 128})
+		}
+		// colored points keep their color and the source data is not modified
+		d, _ := normalized.At(1, 0, 0)
+		r, _, _ := d.RGB255()
+		test.That(t, r, test.ShouldEqual, 10)
+		d, _ = pc.At(2, 0, 0)
+		test.That(t, d.HasColor(), test.ShouldBeFalse)
+	})
+}
+
+func TestColorConfig(t *testing.T) {
+	cfg := &ColorConfig{R: 255, G: 0, B: 10}
+	test.That(t, cfg.Validate(), test.ShouldBeNil)
+	test.That(t, cfg.nrgba(), test.ShouldResemble, color.NRGBA{R: 255, G: 0, B: 10, A: 255})
+
+	cfg.G = 256
+	err := cfg.Validate()
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "g must be between 0 and 255, got 256")
+	cfg.G, cfg.B = 0, -1
+	test.That(t, cfg.Validate(), test.ShouldNotBeNil)
+}
+
+func TestScalePointCloud(t *testing.T) {
+	pc := pointcloud.New()
+	test.That(t, pc.Set(r3.Vector{X: 1, Y: -2, Z: 0.5}, pointcloud.NewBasicData()), test.ShouldBeNil)
+
+	scaled, err := scalePointCloud(pc, 1000)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, scaled.Size(), test.ShouldEqual, 1)
+	_, ok := scaled.At(1000, -2000, 500)
+	test.That(t, ok, test.ShouldBeTrue)
+}
+
+func TestTransformPointCloud(t *testing.T) {
+	pc := pointcloud.New()
+	test.That(t, pc.Set(r3.Vector{X: 10, Y: 0, Z: 0}, pointcloud.NewBasicData()), test.ShouldBeNil)
+
+	pose := spatialmath.NewPose(r3.Vector{X: 0, Y: 0, Z: 5}, &spatialmath.OrientationVectorDegrees{OZ: 1, Theta: 90})
+	transformed, err := transformPointCloud(pc, pose)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, transformed.Size(), test.ShouldEqual, 1)
+	transformed.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		test.That(t, p.X, test.ShouldAlmostEqual, 0)
+		test.That(t, p.Y, test.ShouldAlmostEqual, 10)
+		test.That(t, p.Z, test.ShouldAlmostEqual, 5)
+		return true
+	})
+}
+
+func TestFilterConfidence(t *testing.T) {
+	pc := pointcloud.New()
+	test.That(t, pc.Set(r3.Vector{X: 0, Y: 0, Z: 1}, pointcloud.NewBasicData().SetIntensity(10)), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 0, Y: 0, Z: 2}, pointcloud.NewBasicData().SetIntensity(100)), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 0, Y: 0, Z: 3}, nil), test.ShouldBeNil)
+
+	filtered, err := filterConfidence(pc, 100)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, filtered.Size(), test.ShouldEqual, 1)
+	d, ok := filtered.At(0, 0, 2)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.Intensity(), test.ShouldEqual, 100)
+}
+
+func TestTagSource(t *testing.T) {
+	pc := pointcloud.New()
+	test.That(t, pc.Set(r3.Vector{X: 1}, pointcloud.NewColoredData(color.NRGBA{R: 10, G: 20, B: 30, A: 255})), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 2}, pointcloud.NewBasicData()), test.ShouldBeNil)
+
+	tagged, err := tagSource(pc, 3)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, tagged.Size(), test.ShouldEqual, 2)
+
+	d, ok := tagged.At(1, 0, 0)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.HasValue(), test.ShouldBeTrue)
+	test.That(t, d.Value(), test.ShouldEqual, 3)
+	test.That(t, d.HasColor(), test.ShouldBeTrue)
+	r, g, b := d.RGB255()
+	test.That(t, []uint8{r, g, b}, test.ShouldResemble, []uint8{10, 20, 30})
+
+	d, ok = tagged.At(2, 0, 0)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.Value(), test.ShouldEqual, 3)
+	test.That(t, d.HasColor(), test.ShouldBeFalse)
+
+	// the source cloud is left untouched
+	d, ok = pc.At(1, 0, 0)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.HasValue(), test.ShouldBeFalse)
+}
+
+func TestOutlierRemoval(t *testing.T) {
+	pc := pointcloud.New()
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 10; j++ {
+			test.That(t, pc.Set(r3.Vector{X: float64(i), Y: float64(j)}, pointcloud.NewBasicData()), test.ShouldBeNil)
+		}
+	}
+	test.That(t, pc.Set(r3.Vector{X: 100, Y: 100, Z: 100}, pointcloud.NewBasicData()), test.ShouldBeNil)
+
+	cfg := &OutlierRemovalConfig{Neighbors: 4, StdDevMultiplier: 1}
+	filter, err := cfg.filter()
+	test.That(t, err, test.ShouldBeNil)
+
+	filtered, err := filter(pc)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, filtered.Size(), test.ShouldEqual, 100)
+	_, ok := filtered.At(100, 100, 100)
+	test.That(t, ok, test.ShouldBeFalse)
+
+	_, err = (&OutlierRemovalConfig{Neighbors: 0, StdDevMultiplier: 1}).filter()
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "neighbors")
+	_, err = (&OutlierRemovalConfig{Neighbors: 4}).filter()
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "std_dev_multiplier")
+}
+
+func TestToOctree(t *testing.T) {
+	pc := pointcloud.New()
+	test.That(t, pc.Set(r3.Vector{X: 0, Y: 0, Z: 0}, pointcloud.NewColoredData(color.NRGBA{R: 255, A: 255})), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 0.5, Y: 0.5, Z: 0.5}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 100, Y: -50, Z: 20}, pointcloud.NewBasicData()), test.ShouldBeNil)
+
+	octree, err := toOctree(pc, 0)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, octree.Size(), test.ShouldEqual, 3)
+	d, ok := octree.At(0, 0, 0)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.HasColor(), test.ShouldBeTrue)
+	_, ok = octree.At(100, -50, 20)
+	test.That(t, ok, test.ShouldBeTrue)
+
+	octree, err = toOctree(pc, 10)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, octree.Size(), test.ShouldEqual, 2)
+
+	octree, err = toOctree(pointcloud.New(), 0)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, octree.Size(), test.ShouldEqual, 0)
+}
+
+func TestRemoveGround(t *testing.T) {
+	pc := pointcloud.New()
+	// a slightly tilted floor, a larger wall and a post standing on the floor
+	for x := 0.0; x < 1000; x += 100 {
+		for y := 0.0; y < 1000; y += 100 {
+			test.That(t, pc.Set(r3.Vector{X: x, Y: y, Z: 0.02 * x}, pointcloud.NewBasicData()), test.ShouldBeNil)
+		}
+	}
+	for y := 0.0; y < 1000; y += 100 {
+		for z := 100.0; z <= 1200; z += 100 {
+			test.That(t, pc.Set(r3.Vector{X: -100, Y: y, Z: z}, pointcloud.NewBasicData()), test.ShouldBeNil)
+		}
+	}
+	for z := 100.0; z <= 1000; z += 100 {
+		test.That(t, pc.Set(r3.Vector{X: 550, Y: 550, Z: z}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	}
+	seeded := func() *rand.Rand { return rand.New(rand.NewSource(1)) }
+
+	t.Run("ransac removes the floor but not the wall", func(t *testing.T) {
+		filtered, err := removeGround(pc, &GroundRemovalConfig{DistanceThreshold: 0.01, MaxIterations: 500}, nil, seeded())
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, filtered.Size(), test.ShouldEqual, 130)
+		_, ok := filtered.At(900, 900, 18)
+		test.That(t, ok, test.ShouldBeFalse)
+		_, ok = filtered.At(550, 550, 100)
+		test.That(t, ok, test.ShouldBeTrue)
+	})
+
+	t.Run("z threshold removes low points", func(t *testing.T) {
+		filtered, err := removeGround(pc, &GroundRemovalConfig{Method: groundRemovalZThreshold, DistanceThreshold: 0.01}, nil, seeded())
+		test.That(t, err, test.ShouldBeNil)
+		// floor points up to 10mm high are removed
+		test.That(t, filtered.Size(), test.ShouldEqual, pc.Size()-60)
+	})
+
+	t.Run("ground in another frame", func(t *testing.T) {
+		framed := pointcloud.New()
+		for _, z := range []float64{0, 55, 70} {
+			test.That(t, framed.Set(r3.Vector{Z: z}, pointcloud.NewBasicData()), test.ShouldBeNil)
+		}
+		frame := spatialmath.NewPoseFromPoint(r3.Vector{Z: 50})
+		filtered, err := removeGround(framed, &GroundRemovalConfig{Method: groundRemovalZThreshold, DistanceThreshold: 0.01}, frame, seeded())
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, filtered.Size(), test.ShouldEqual, 1)
+		_, ok := filtered.At(0, 0, 70)
+		test.That(t, ok, test.ShouldBeTrue)
+
+		// the floor is fit in the frame too, here one lifted and turned about Z so the floor stays parallel to its XY plane
+		tilted := spatialmath.NewPose(r3.Vector{Z: 300}, &spatialmath.OrientationVectorDegrees{OZ: 1, Theta: 45})
+		filtered, err = removeGround(pc, &GroundRemovalConfig{DistanceThreshold: 0.01, MaxIterations: 500}, tilted, seeded())
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, filtered.Size(), test.ShouldEqual, 130)
+	})
+
+	t.Run("small clouds are unchanged", func(t *testing.T) {
+		small := pointcloud.New()
+		test.That(t, small.Set(r3.Vector{X: 0, Y: 0, Z: 0}, pointcloud.NewBasicData()), test.ShouldBeNil)
+		filtered, err := removeGround(small, &GroundRemovalConfig{DistanceThreshold: 0.01}, nil, seeded())
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, filtered.Size(), test.ShouldEqual, 1)
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		test.That(t, (&GroundRemovalConfig{DistanceThreshold: 0.01}).Validate(), test.ShouldBeNil)
+		test.That(t, (&GroundRemovalConfig{Method: groundRemovalZThreshold, DistanceThreshold: -0.5}).Validate(), test.ShouldBeNil)
+		test.That(t, (&GroundRemovalConfig{}).Validate(), test.ShouldNotBeNil)
+		test.That(t, (&GroundRemovalConfig{Method: "hough", DistanceThreshold: 0.01}).Validate(), test.ShouldNotBeNil)
+		test.That(t, (&GroundRemovalConfig{DistanceThreshold: 0.01, MaxIterations: -1}).Validate(), test.ShouldNotBeNil)
+	})
+}
+
+func TestMergeInChunks(t *testing.T) {
+	cloud := func(n int, z float64) pointcloud.PointCloud {
+		pc := pointcloud.New()
+		for i := 0; i < n; i++ {
+			test.That(t, pc.Set(r3.Vector{X: float64(i), Z: z}, pointcloud.NewValueData(i)), test.ShouldBeNil)
+		}
+		return pc
+	}
+	// the second camera sees the first point of the first camera, and the third camera returns nothing
+	results := []cameraResult{
+		{name: "cam1", pc: cloud(101, 0), pose: spatialmath.NewZeroPose()},
+		{name: "cam2", pc: cloud(3, 10), pose: spatialmath.NewPoseFromPoint(r3.Vector{Z: -10})},
+		{name: "cam3", pc: pointcloud.New(), pose: spatialmath.NewZeroPose()},
+		{name: "cam4", pc: cloud(50, 0), pose: spatialmath.NewPose(r3.Vector{Y: 20}, &spatialmath.OrientationVectorDegrees{OZ: 1, Theta: 90})},
+	}
+	points := func(pc pointcloud.PointCloud) []pointcloud.PointAndData {
+		var points []pointcloud.PointAndData
+		pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+			points = append(points, pointcloud.PointAndData{P: p, D: d})
+			return true
+		})
+		return points
+	}
+
+	appended, err := appendPointClouds(results)
+	test.That(t, err, test.ShouldBeNil)
+	expected := points(appended)
+	for _, workers := range []int{1, 2, 3, 8, 200} {
+		merged, err := mergeInChunks(context.Background(), results, workers)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, points(merged), test.ShouldResemble, expected)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = mergeInChunks(ctx, results, 4)
+	test.That(t, errors.Cause(err), test.ShouldEqual, context.Canceled)
+}
+
+func BenchmarkMergeInChunks(b *testing.B) {
+	// eight cameras of 100,000 points each, rotated so that every point is transformed
+	results := make([]cameraResult, 8)
+	for i := range results {
+		pc := pointcloud.NewWithPrealloc(100000)
+		for j := 0; j < 100000; j++ {
+			if err := pc.Set(r3.Vector{X: float64(j % 400), Y: float64(j / 400), Z: float64(1000 + i)}, pointcloud.NewBasicData()); err != nil {
+				b.Fatal(err)
+			}
+		}
+		pose := spatialmath.NewPose(r3.Vector{X: float64(i)}, &spatialmath.OrientationVectorDegrees{OZ: 1, Theta: float64(10 * i)})
+		results[i] = cameraResult{name: "cam" + strconv.Itoa(i), pc: pc, pose: pose}
+	}
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(strconv.Itoa(workers)+"_workers", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := mergeInChunks(context.Background(), results, workers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"sort"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/pointcloud"
+)
+
+// defaultFingerprintVoxelSize is the size in millimeters of the voxels whose occupancy is hashed by "fingerprint".
+const defaultFingerprintVoxelSize = 10.0
+
+// cloudFingerprint hashes the point count of the cloud together with the set of voxels of the given size that hold at
+// least one point. The voxels are hashed in sorted order, so the fingerprint does not depend on the order in which
+// points were merged, and moving a point within its voxel or changing its color leaves the fingerprint unchanged.
+// It also returns the number of occupied voxels.
+func cloudFingerprint(pc pointcloud.PointCloud, voxelSize float64) (string, int) {
+	occupied := make(map[voxelKey]bool)
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		occupied[newVoxelKey(p, voxelSize)] = true
+		return true
+	})
+	keys := make([]voxelKey, 0, len(occupied))
+	for key := range occupied {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].x != keys[j].x {
+			return keys[i].x < keys[j].x
+		}
+		if keys[i].y != keys[j].y {
+			return keys[i].y < keys[j].y
+		}
+		return keys[i].z < keys[j].z
+	})
+
+	data := make([]byte, 0, 8*(1+3*len(keys)))
+	data = binary.LittleEndian.AppendUint64(data, uint64(pc.Size()))
+	for _, key := range keys {
+		data = binary.LittleEndian.AppendUint64(data, uint64(key.x))
+		data = binary.LittleEndian.AppendUint64(data, uint64(key.y))
+		data = binary.LittleEndian.AppendUint64(data, uint64(key.z))
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8]), len(keys)
+}
+
+// fingerprint reports a fingerprint of the most recent merged point cloud, computed by cloudFingerprint with voxels of
+// the optional "voxel_size_mm".
+func (merged *mergedCamera) fingerprint(cmd map[string]interface{}) (map[string]interface{}, error) {
+	voxelSize := defaultFingerprintVoxelSize
+	if raw, ok := cmd[voxelSizeKey]; ok {
+		value, ok := raw.(float64)
+		if !ok || value <= 0 {
+			return nil, errors.Errorf("%q must be a positive number", voxelSizeKey)
+		}
+		voxelSize = value
+	}
+
+	merged.mu.RLock()
+	defer merged.mu.RUnlock()
+	merged.stateMu.Lock()
+	pc, capturedAt := merged.lastPointCloud, merged.lastCapturedAt
+	merged.stateMu.Unlock()
+	if pc == nil {
+		return nil, errors.New("no merged point cloud has been produced yet")
+	}
+
+	fingerprint, voxels := cloudFingerprint(pc, voxelSize)
+	return map[string]interface{}{
+		"fingerprint":     fingerprint,
+		"points":          pc.Size(),
+		"occupied_voxels": voxels,
+		voxelSizeKey:      voxelSize,
+		"captured_at":     formatTime(capturedAt),
+	}, nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/test"
+)
+
+func TestCloudFingerprint(t *testing.T) {
+	cloudOf := func(points ...r3.Vector) pointcloud.PointCloud {
+		pc := pointcloud.New()
+		for _, p := range points {
+			test.That(t, pc.Set(p, nil), test.ShouldBeNil)
+		}
+		return pc
+	}
+	empty, voxels := cloudFingerprint(pointcloud.New(), 10)
+	test.That(t, voxels, test.ShouldEqual, 0)
+
+	base, voxels := cloudFingerprint(cloudOf(r3.Vector{X: 1}, r3.Vector{X: 25}, r3.Vector{Y: -15}), 10)
+	test.That(t, voxels, test.ShouldEqual, 3)
+	test.That(t, base, test.ShouldNotEqual, empty)
+
+	// the order of the points does not matter
+	fingerprint, _ := cloudFingerprint(cloudOf(r3.Vector{Y: -15}, r3.Vector{X: 25}, r3.Vector{X: 1}), 10)
+	test.That(t, fingerprint, test.ShouldEqual, base)
+
+	// neither does moving a point within its voxel or changing its color
+	moved := cloudOf(r3.Vector{X: 2}, r3.Vector{X: 25}, r3.Vector{Y: -15})
+	fingerprint, _ = cloudFingerprint(moved, 10)
+	test.That(t, fingerprint, test.ShouldEqual, base)
+	colored := cloudOf(r3.Vector{X: 1}, r3.Vector{X: 25})
+	test.That(t, colored.Set(r3.Vector{Y: -15}, pointcloud.NewColoredData(color.NRGBA{R: 128, G: 128, B: 128, A: 255})), test.ShouldBeNil)
+	fingerprint, _ = cloudFingerprint(colored, 10)
+	test.That(t, fingerprint, test.ShouldEqual, base)
+
+	// moving a point into another voxel, or adding a point to an occupied voxel, does
+	fingerprint, _ = cloudFingerprint(cloudOf(r3.Vector{X: 1}, r3.Vector{X: 35}, r3.Vector{Y: -15}), 10)
+	test.That(t, fingerprint, test.ShouldNotEqual, base)
+	fingerprint, voxels = cloudFingerprint(cloudOf(r3.Vector{X: 1}, r3.Vector{X: 3}, r3.Vector{X: 25}, r3.Vector{Y: -15}), 10)
+	test.That(t, voxels, test.ShouldEqual, 3)
+	test.That(t, fingerprint, test.ShouldNotEqual, base)
+}
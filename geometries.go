@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// geometries returns the geometries attached to the frames of the source cameras, expressed in the target frame.
+// Cameras whose frames have no geometry contribute nothing.
+func (merged *mergedCamera) geometries(ctx context.Context) (*referenceframe.GeometriesInFrame, error) {
+	merged.mu.RLock()
+	defer merged.mu.RUnlock()
+	if merged.fsService == nil {
+		return nil, errors.New("no frame system service available")
+	}
+
+	fs, err := merged.fsService.FrameSystem(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting frame system")
+	}
+	inputs, _, err := merged.fsService.CurrentInputs(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting frame system inputs")
+	}
+
+	var geometries []spatialmath.Geometry
+	for _, cam := range merged.cameras {
+		name := cam.Name().ShortName()
+		// a frame system part keeps the geometry given in its frame config on its "_origin" frame
+		for _, frameName := range []string{name, name + "_origin"} {
+			frame := fs.Frame(frameName)
+			if frame == nil {
+				continue
+			}
+			frameInputs, err := referenceframe.GetFrameInputs(frame, inputs)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error getting inputs of frame %v", frameName)
+			}
+			frameGeometries, err := frame.Geometries(frameInputs)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error getting geometries of frame %v", frameName)
+			}
+			if len(frameGeometries.Geometries()) == 0 {
+				continue
+			}
+			transformed, err := fs.Transform(inputs, frameGeometries, merged.targetFrame)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error transforming geometries of frame %v to target frame %v", frameName, merged.targetFrame)
+			}
+			geometries = append(geometries, transformed.(*referenceframe.GeometriesInFrame).Geometries()...)
+		}
+	}
+	return referenceframe.NewGeometriesInFrame(merged.targetFrame, geometries), nil
+}
+
+// geometriesResponse formats the geometries for a DoCommand response.
+func geometriesResponse(geometries *referenceframe.GeometriesInFrame) (map[string]interface{}, error) {
+	geometryMaps := make([]interface{}, 0, len(geometries.Geometries()))
+	for _, g := range geometries.Geometries() {
+		cfg, err := spatialmath.NewGeometryConfig(g)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error converting geometry %v", g.Label())
+		}
+		geometryMaps = append(geometryMaps, map[string]interface{}{
+			"label":       g.Label(),
+			"type":        string(cfg.Type),
+			"dimensions":  map[string]interface{}{"x": cfg.X, "y": cfg.Y, "z": cfg.Z, "r": cfg.R, "l": cfg.L},
+			"translation": vectorMap(g.Pose().Point()),
+			"orientation": orientationMap(g.Pose().Orientation()),
+		})
+	}
+	return map[string]interface{}{"reference_frame": geometries.Parent(), "geometries": geometryMaps}, nil
+}
+
+// vectorMap formats the vector for a DoCommand response.
+func vectorMap(v r3.Vector) map[string]interface{} {
+	return map[string]interface{}{"x": v.X, "y": v.Y, "z": v.Z}
+}
+
+// orientationMap formats the orientation for a DoCommand response as an orientation vector in degrees.
+func orientationMap(o spatialmath.Orientation) map[string]interface{} {
+	ov := o.OrientationVectorDegrees()
+	return map[string]interface{}{"o_x": ov.OX, "o_y": ov.OY, "o_z": ov.OZ, "theta": ov.Theta}
+}
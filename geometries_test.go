@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/test"
+)
+
+func TestGeometries(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	cam1 := createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 1}})
+	cam2 := createMockCamera("cam2", []r3.Vector{{X: 0, Y: 0, Z: 1}})
+	cameras := []camera.Camera{cam1, cam2}
+	conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}}}
+
+	t.Run("camera geometries in the target frame", func(t *testing.T) {
+		poses := map[string]spatialmath.Pose{"cam2": spatialmath.NewPoseFromPoint(r3.Vector{X: 100})}
+		fsService, err := createFrameSystemServiceWithPoses(ctx, cameras, poses, logger)
+		test.That(t, err, test.ShouldBeNil)
+
+		mergedCam := &mergedCamera{logger: logger}
+		test.That(t, mergedCam.Reconfigure(ctx, createDependencies(cameras, fsService), conf), test.ShouldBeNil)
+
+		geometries, err := mergedCam.geometries(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, geometries.Parent(), test.ShouldEqual, "cam1")
+		test.That(t, len(geometries.Geometries()), test.ShouldEqual, 2)
+		test.That(t, spatialmath.R3VectorAlmostEqual(geometries.Geometries()[0].Pose().Point(), r3.Vector{}, 1e-6), test.ShouldBeTrue)
+		test.That(t, spatialmath.R3VectorAlmostEqual(geometries.Geometries()[1].Pose().Point(), r3.Vector{X: 100}, 1e-6),
+			test.ShouldBeTrue)
+
+		resp, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: geometriesCommand})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp["reference_frame"], test.ShouldEqual, "cam1")
+		geometryMaps := resp["geometries"].([]interface{})
+		test.That(t, len(geometryMaps), test.ShouldEqual, 2)
+		sphere := geometryMaps[1].(map[string]interface{})
+		test.That(t, sphere["label"], test.ShouldContainSubstring, "cam2")
+		test.That(t, sphere["type"], test.ShouldEqual, "sphere")
+		test.That(t, sphere["dimensions"].(map[string]interface{})["r"], test.ShouldEqual, 5)
+		test.That(t, sphere["translation"].(map[string]interface{})["x"], test.ShouldAlmostEqual, 100)
+	})
+
+	t.Run("frames without geometry", func(t *testing.T) {
+		fs := referenceframe.NewEmptyFrameSystem("test")
+		for _, name := range []string{"cam1", "cam2"} {
+			test.That(t, fs.AddFrame(referenceframe.NewZeroStaticFrame(name), fs.World()), test.ShouldBeNil)
+		}
+		fsService := inject.NewFrameSystemService("no-geometry")
+		fsService.FrameSystemFunc = func(
+			ctx context.Context,
+			additionalTransforms []*referenceframe.LinkInFrame,
+		) (referenceframe.FrameSystem, error) {
+			return fs, nil
+		}
+		fsService.CurrentInputsFunc = func(
+			ctx context.Context,
+		) (map[string][]referenceframe.Input, map[string]referenceframe.InputEnabled, error) {
+			return referenceframe.StartPositions(fs), nil, nil
+		}
+
+		mergedCam := &mergedCamera{logger: logger}
+		test.That(t, mergedCam.Reconfigure(ctx, createDependencies(cameras, fsService), conf), test.ShouldBeNil)
+
+		geometries, err := mergedCam.geometries(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, geometries.Geometries(), test.ShouldBeEmpty)
+
+		resp, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: geometriesCommand})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp["geometries"], test.ShouldBeEmpty)
+	})
+}
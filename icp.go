@@ -0,0 +1,224 @@
+package main
+
+import (
+	"math"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/spatialmath"
+	"gonum.org/v1/gonum/mat"
+)
+
+const (
+	// defaultICPIterations is the number of ICP iterations run when max_iterations is unset.
+	defaultICPIterations = 20
+	// defaultICPConvergence is the improvement in mean correspondence distance, in meters, below which ICP stops
+	// when convergence_threshold is unset.
+	defaultICPConvergence = 0.0001
+	// defaultICPCorrespondenceDistance is the distance in meters beyond which points are not paired when
+	// max_correspondence_distance is unset.
+	defaultICPCorrespondenceDistance = 0.05
+)
+
+// ICPConfig configures the refinement of camera orientations by ICP (iterative closest point). Each camera's cloud,
+// after being transformed by the frame system, is rotated about the camera's origin to best fit the points of the
+// cameras listed before it. Only the orientation is corrected since small errors in mounting angles are what cause
+// double walls far from the cameras.
+//
+// Every iteration runs a nearest neighbor query for every point of the camera, and the clouds of earlier cameras are
+// built into a k-d tree on every merge, so refinement can easily cost more than the rest of the merge. Downsample the
+// sources with source_voxel_size to keep it affordable.
+type ICPConfig struct {
+	// MaxIterations bounds the ICP iterations per camera, 20 if unset.
+	MaxIterations int `json:"max_iterations,omitempty"`
+	// ConvergenceThreshold, in meters, stops ICP once the mean distance between paired points improves by less than
+	// it. It defaults to 0.0001.
+	ConvergenceThreshold float64 `json:"convergence_threshold,omitempty"`
+	// MaxCorrespondenceDistance, in meters, is the distance beyond which points are not paired, which limits the fit
+	// to where the cameras overlap. It defaults to 0.05.
+	MaxCorrespondenceDistance float64 `json:"max_correspondence_distance,omitempty"`
+}
+
+// Validate checks that the config describes a valid ICP refinement.
+func (cfg *ICPConfig) Validate() error {
+	if cfg.MaxIterations < 0 {
+		return errors.Errorf("max_iterations must be non-negative, got %v", cfg.MaxIterations)
+	}
+	if cfg.ConvergenceThreshold < 0 {
+		return errors.Errorf("convergence_threshold must be non-negative, got %v", cfg.ConvergenceThreshold)
+	}
+	if cfg.MaxCorrespondenceDistance < 0 {
+		return errors.Errorf("max_correspondence_distance must be non-negative, got %v", cfg.MaxCorrespondenceDistance)
+	}
+	return nil
+}
+
+// withDefaults returns a copy of the config with unset values replaced by their defaults, converted to millimeters.
+func (cfg *ICPConfig) withDefaults() ICPConfig {
+	filled := *cfg
+	if filled.MaxIterations == 0 {
+		filled.MaxIterations = defaultICPIterations
+	}
+	if filled.ConvergenceThreshold == 0 {
+		filled.ConvergenceThreshold = defaultICPConvergence
+	}
+	if filled.MaxCorrespondenceDistance == 0 {
+		filled.MaxCorrespondenceDistance = defaultICPCorrespondenceDistance
+	}
+	filled.ConvergenceThreshold *= mmPerMeter
+	filled.MaxCorrespondenceDistance *= mmPerMeter
+	return filled
+}
+
+// icpRotation finds the rotation about origin that best aligns the points with their nearest neighbors in the
+// target. The points, origin and target are all in the target frame and in millimeters. The config must have its
+// defaults filled. False is returned if too few points overlap the target to fit a rotation.
+func icpRotation(points []r3.Vector, origin r3.Vector, target *pointcloud.KDTree, cfg ICPConfig) (rotation3, bool) {
+	rotation := identityRotation
+	if target.Size() == 0 {
+		return rotation, false
+	}
+
+	prevMean := math.Inf(1)
+	fitted := false
+	for i := 0; i < cfg.MaxIterations; i++ {
+		// accumulate the cross-covariance between the points and their matches, both relative to the origin
+		cov := mat.NewDense(3, 3, nil)
+		matched := 0
+		total := 0.0
+		for _, p := range points {
+			rel := p.Sub(origin)
+			nearest, _, dist, ok := target.NearestNeighbor(origin.Add(rotation.apply(rel)))
+			if !ok || dist > cfg.MaxCorrespondenceDistance {
+				continue
+			}
+			match := nearest.Sub(origin)
+			a, b := [3]float64{rel.X, rel.Y, rel.Z}, [3]float64{match.X, match.Y, match.Z}
+			for r := 0; r < 3; r++ {
+				for c := 0; c < 3; c++ {
+					cov.Set(r, c, cov.At(r, c)+a[r]*b[c])
+				}
+			}
+			matched++
+			total += dist
+		}
+		if matched < 3 {
+			break
+		}
+		mean := total / float64(matched)
+		if prevMean-mean < cfg.ConvergenceThreshold {
+			break
+		}
+		prevMean = mean
+
+		next, ok := kabsch(cov)
+		if !ok {
+			break
+		}
+		rotation = next
+		fitted = true
+	}
+	return rotation, fitted
+}
+
+// kabsch returns the rotation R maximizing the sum of b·Ra over the pairs whose cross-covariance sum(a bᵀ) is given.
+func kabsch(cov *mat.Dense) (rotation3, bool) {
+	var svd mat.SVD
+	if !svd.Factorize(cov, mat.SVDFull) {
+		return rotation3{}, false
+	}
+	var u, v mat.Dense
+	svd.UTo(&u)
+	svd.VTo(&v)
+
+	// flip the last axis if needed so the result is a rotation rather than a reflection
+	var vut mat.Dense
+	vut.Mul(&v, u.T())
+	d := mat.NewDiagDense(3, []float64{1, 1, 1})
+	if mat.Det(&vut) < 0 {
+		d.SetDiag(2, -1)
+	}
+	var r mat.Dense
+	r.Product(&v, d, u.T())
+
+	var rotation rotation3
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			rotation[3*row+col] = r.At(row, col)
+		}
+	}
+	return rotation, true
+}
+
+// rotation3 is a rotation matrix in row major order that rotates a vector v to Rv.
+type rotation3 [9]float64
+
+// identityRotation is the rotation matrix of no rotation.
+var identityRotation = rotation3{1, 0, 0, 0, 1, 0, 0, 0, 1}
+
+// rotationOf returns the rotation matrix of the orientation.
+func rotationOf(o spatialmath.Orientation) rotation3 {
+	q := o.Quaternion()
+	w, x, y, z := q.Real, q.Imag, q.Jmag, q.Kmag
+	return rotation3{
+		1 - 2*(y*y+z*z), 2 * (x*y - w*z), 2 * (x*z + w*y),
+		2 * (x*y + w*z), 1 - 2*(x*x+z*z), 2 * (y*z - w*x),
+		2 * (x*z - w*y), 2 * (y*z + w*x), 1 - 2*(x*x+y*y),
+	}
+}
+
+// apply returns the rotated vector.
+func (r rotation3) apply(v r3.Vector) r3.Vector {
+	return r3.Vector{
+		X: r[0]*v.X + r[1]*v.Y + r[2]*v.Z,
+		Y: r[3]*v.X + r[4]*v.Y + r[5]*v.Z,
+		Z: r[6]*v.X + r[7]*v.Y + r[8]*v.Z,
+	}
+}
+
+// orientation returns the rotation as an orientation. spatialmath.RotationMatrix stores the transpose of the
+// matrix that rotates vectors the way its orientation does, so the matrix is transposed.
+func (r rotation3) orientation() spatialmath.Orientation {
+	rm, err := spatialmath.NewRotationMatrix([]float64{r[0], r[3], r[6], r[1], r[4], r[7], r[2], r[5], r[8]})
+	if err != nil {
+		return spatialmath.NewZeroOrientation()
+	}
+	return rm
+}
+
+// refineAlignment corrects the orientation of every camera that returned a point cloud by ICP against the clouds of
+// the cameras before it, in config order. The first such camera is the reference and is left unchanged.
+func (merged *mergedCamera) refineAlignment(results []cameraResult) {
+	cfg := merged.icpRefine.withDefaults()
+	target := pointcloud.NewKDTree()
+	for i := range results {
+		result := &results[i]
+		if result.err != nil {
+			continue
+		}
+		points := make([]r3.Vector, 0, result.pc.Size())
+		result.pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+			points = append(points, spatialmath.Compose(result.pose, spatialmath.NewPoseFromPoint(p)).Point())
+			return true
+		})
+
+		origin := result.pose.Point()
+		if rotation, ok := icpRotation(points, origin, target, cfg); ok {
+			correction := rotation.orientation()
+			rotated := spatialmath.Compose(spatialmath.NewPoseFromOrientation(correction), result.pose)
+			result.pose = spatialmath.NewPose(origin, rotated.Orientation())
+			merged.logger.Debugf("icp corrected the orientation of camera %v by %v", result.name, correction.OrientationVectorDegrees())
+			for j, p := range points {
+				points[j] = origin.Add(rotation.apply(p.Sub(origin)))
+			}
+		}
+		for _, p := range points {
+			// the data of target points is never read
+			if err := target.Set(p, nil); err != nil {
+				merged.logger.Debugf("error adding points of camera %v to the icp target: %v", result.name, err)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/test"
+)
+
+// latticePoints returns points 100mm apart in front of the origin, far enough apart that the nearest neighbor of a
+// slightly rotated point is the point it came from.
+func latticePoints() []r3.Vector {
+	var points []r3.Vector
+	for x := 500.0; x <= 1000; x += 100 {
+		for y := -200.0; y <= 200; y += 100 {
+			for z := -200.0; z <= 200; z += 100 {
+				points = append(points, r3.Vector{X: x, Y: y, Z: z})
+			}
+		}
+	}
+	return points
+}
+
+func TestICPRotation(t *testing.T) {
+	target := pointcloud.NewKDTree()
+	for _, p := range latticePoints() {
+		test.That(t, target.Set(p, nil), test.ShouldBeNil)
+	}
+	cfg := (&ICPConfig{}).withDefaults()
+
+	t.Run("recovers a small rotation", func(t *testing.T) {
+		misalignment := &spatialmath.R4AA{Theta: math.Pi / 180, RX: 1 / math.Sqrt(3), RY: 1 / math.Sqrt(3), RZ: 1 / math.Sqrt(3)}
+		var points []r3.Vector
+		for _, p := range latticePoints() {
+			points = append(points, spatialmath.Compose(spatialmath.NewPoseFromOrientation(misalignment), spatialmath.NewPoseFromPoint(p)).Point())
+		}
+
+		rotation, ok := icpRotation(points, r3.Vector{}, target, cfg)
+		test.That(t, ok, test.ShouldBeTrue)
+		corrected := spatialmath.Compose(spatialmath.NewPoseFromOrientation(rotation.orientation()), spatialmath.NewPoseFromOrientation(misalignment))
+		test.That(t, corrected.Orientation().AxisAngles().Theta, test.ShouldBeLessThan, 1e-6)
+	})
+
+	t.Run("does not fit without overlap", func(t *testing.T) {
+		points := []r3.Vector{{X: -5000, Y: 0, Z: 0}, {X: -5000, Y: 10, Z: 0}, {X: -5000, Y: 0, Z: 10}}
+		_, ok := icpRotation(points, r3.Vector{}, target, cfg)
+		test.That(t, ok, test.ShouldBeFalse)
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		test.That(t, (&ICPConfig{}).Validate(), test.ShouldBeNil)
+		test.That(t, (&ICPConfig{MaxIterations: -1}).Validate(), test.ShouldNotBeNil)
+		test.That(t, (&ICPConfig{ConvergenceThreshold: -1}).Validate(), test.ShouldNotBeNil)
+		test.That(t, (&ICPConfig{MaxCorrespondenceDistance: -1}).Validate(), test.ShouldNotBeNil)
+	})
+}
+
+func TestRotationOf(t *testing.T) {
+	v := r3.Vector{X: 1, Y: 2, Z: 3}
+	for _, o := range []spatialmath.Orientation{
+		spatialmath.NewZeroOrientation(),
+		&spatialmath.R4AA{Theta: math.Pi / 2, RZ: 1},
+		&spatialmath.R4AA{Theta: 0.3, RX: 1, RY: -2, RZ: 0.5},
+		&spatialmath.OrientationVectorDegrees{OX: 1, OY: 1, OZ: 0, Theta: 45},
+	} {
+		expected := spatialmath.Compose(spatialmath.NewPoseFromOrientation(o), spatialmath.NewPoseFromPoint(v)).Point()
+		test.That(t, rotationOf(o).apply(v).Sub(expected).Norm(), test.ShouldBeLessThan, 1e-9)
+	}
+}
@@ -18,6 +18,7 @@ func mainWithArgs(ctx context.Context, args []string, logger logging.Logger) err
 	if err != nil {
 		return err
 	}
+	parentResource = mergedCameraModule.GetParentResource
 
 	mergedCameraModule.AddModelFromRegistry(ctx, camera.API, model)
 
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// stageFetch is the stage of a camera that failed to return or process its point cloud.
+	stageFetch = "fetch"
+	// stageTransform is the stage of a camera whose transform to the target frame could not be resolved.
+	stageTransform = "transform"
+	// stageSync is the stage of a camera captured outside the sync_window of the others.
+	stageSync = "sync"
+)
+
+// CameraError is the failure of a single camera during a merge.
+type CameraError struct {
+	// Name is the short name of the camera.
+	Name string
+	// Stage is where the camera failed: "fetch", "transform" or "sync".
+	Stage string
+	Err   error
+}
+
+// Error returns the error of the camera, which names the camera.
+func (e CameraError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the error of the camera.
+func (e CameraError) Unwrap() error {
+	return e.Err
+}
+
+// MergeError is returned by NextPointCloud when cameras fail under the strict error policy. It holds the failure of
+// every camera that failed, in config order, so callers can inspect each with errors.Is or by ranging over Cameras.
+type MergeError struct {
+	Cameras []CameraError
+}
+
+// newMergeError returns the failures of the results as a MergeError, nil when no camera failed.
+func newMergeError(results []cameraResult) *MergeError {
+	var failures []CameraError
+	for _, result := range results {
+		if result.err != nil {
+			failures = append(failures, CameraError{Name: result.name, Stage: result.stage(), Err: result.err})
+		}
+	}
+	if failures == nil {
+		return nil
+	}
+	return &MergeError{Cameras: failures}
+}
+
+// Error returns the error of the only failed camera, or lists the failures of every camera when several failed.
+func (e *MergeError) Error() string {
+	if len(e.Cameras) == 1 {
+		return e.Cameras[0].Error()
+	}
+	failures := make([]string, 0, len(e.Cameras))
+	for _, failure := range e.Cameras {
+		failures = append(failures, fmt.Sprintf("%v (%v): %v", failure.Name, failure.Stage, failure.Err))
+	}
+	return fmt.Sprintf("%v cameras failed: %v", len(e.Cameras), strings.Join(failures, "; "))
+}
+
+// Unwrap returns the error of the first failed camera. Is matches the errors of the other cameras too.
+func (e *MergeError) Unwrap() error {
+	return e.Cameras[0].Err
+}
+
+// Is reports whether the error of any failed camera matches the target.
+func (e *MergeError) Is(target error) bool {
+	for _, failure := range e.Cameras {
+		if errors.Is(failure.Err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Failed returns the failure of the named camera, if it failed.
+func (e *MergeError) Failed(name string) (CameraError, bool) {
+	for _, failure := range e.Cameras {
+		if failure.Name == name {
+			return failure, true
+		}
+	}
+	return CameraError{}, false
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/test"
+)
+
+func TestMergeError(t *testing.T) {
+	errBoom := errors.New("boom")
+	errFrame := errors.New("no frame")
+	results := []cameraResult{
+		{name: "cam1", err: errors.Wrap(errBoom, "error getting point cloud from camera cam1")},
+		{name: "cam2"},
+		{name: "cam3", err: errors.Wrap(errFrame, "issue getting tranform from camera cam3"), failedStage: stageTransform},
+	}
+
+	test.That(t, newMergeError(results[1:2]), test.ShouldBeNil)
+
+	single := newMergeError(results[:2])
+	test.That(t, single.Error(), test.ShouldEqual, "error getting point cloud from camera cam1: boom")
+
+	mergeErr := newMergeError(results)
+	test.That(t, mergeErr.Cameras, test.ShouldHaveLength, 2)
+	test.That(t, mergeErr.Error(), test.ShouldEqual, "2 cameras failed: "+
+		"cam1 (fetch): error getting point cloud from camera cam1: boom; "+
+		"cam3 (transform): issue getting tranform from camera cam3: no frame")
+	test.That(t, errors.Is(mergeErr, errBoom), test.ShouldBeTrue)
+	test.That(t, errors.Is(mergeErr, errFrame), test.ShouldBeTrue)
+	test.That(t, errors.Is(mergeErr, ErrClosed), test.ShouldBeFalse)
+	test.That(t, errors.Unwrap(mergeErr), test.ShouldEqual, results[0].err)
+
+	failure, ok := mergeErr.Failed("cam3")
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, failure.Stage, test.ShouldEqual, stageTransform)
+	test.That(t, errors.Is(failure, errFrame), test.ShouldBeTrue)
+	_, ok = mergeErr.Failed("cam2")
+	test.That(t, ok, test.ShouldBeFalse)
+}
+
+func TestNextPointCloudMergeError(t *testing.T) {
+	ctx := context.Background()
+	errBoom := errors.New("boom")
+	errFrame := errors.New("no frame")
+
+	cam1 := newFakeCamera("cam1", r3.Vector{Z: 1})
+	cam2 := newFakeCamera("cam2", r3.Vector{Z: 2})
+	cam3 := newFakeCamera("cam3", r3.Vector{Z: 3})
+	fsService, err := newFakeFrameSystemService(map[string]spatialmath.Pose{
+		"cam1": spatialmath.NewZeroPose(), "cam2": spatialmath.NewZeroPose(), "cam3": spatialmath.NewZeroPose(),
+	})
+	test.That(t, err, test.ShouldBeNil)
+	transformPose := fsService.TransformPoseFunc
+	fsService.TransformPoseFunc = func(
+		ctx context.Context,
+		pose *referenceframe.PoseInFrame,
+		dst string,
+		additionalTransforms []*referenceframe.LinkInFrame,
+	) (*referenceframe.PoseInFrame, error) {
+		if pose.Parent() == "cam3" {
+			return nil, errFrame
+		}
+		return transformPose(ctx, pose, dst, additionalTransforms)
+	}
+
+	cameras := []camera.Camera{cam1, cam2, cam3}
+	mergedCam := newTestMergedCamera(t, &Config{Cameras: []string{"cam1", "cam2", "cam3"}, TargetFrame: "world"},
+		cameras, fsService)
+	cam1.setError(errBoom)
+
+	_, err = mergedCam.NextPointCloud(ctx)
+	test.That(t, err, test.ShouldNotBeNil)
+	var mergeErr *MergeError
+	test.That(t, errors.As(err, &mergeErr), test.ShouldBeTrue)
+	test.That(t, mergeErr.Cameras, test.ShouldHaveLength, 2)
+	test.That(t, mergeErr.Cameras[0].Name, test.ShouldEqual, "cam1")
+	test.That(t, mergeErr.Cameras[0].Stage, test.ShouldEqual, stageFetch)
+	test.That(t, mergeErr.Cameras[1].Name, test.ShouldEqual, "cam3")
+	test.That(t, mergeErr.Cameras[1].Stage, test.ShouldEqual, stageTransform)
+	test.That(t, errors.Is(err, errBoom), test.ShouldBeTrue)
+	test.That(t, errors.Is(err, errFrame), test.ShouldBeTrue)
+
+	// the skip policy merges the cameras that succeeded instead
+	skipping := newTestMergedCamera(t, &Config{
+		Cameras: []string{"cam1", "cam2", "cam3"}, TargetFrame: "world", ErrorPolicy: errorPolicySkip,
+	}, cameras, fsService)
+	pc, err := skipping.NextPointCloud(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pc.Size(), test.ShouldEqual, 1)
+}
@@ -2,15 +2,23 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"image/color"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/golang/geo/r3"
 	"github.com/pkg/errors"
+	"go.uber.org/multierr"
 
 	"go.viam.com/rdk/components/camera"
-	"go.viam.com/rdk/gostream"
 	"go.viam.com/rdk/logging"
 	"go.viam.com/rdk/pointcloud"
 	"go.viam.com/rdk/referenceframe"
@@ -25,11 +33,77 @@ const (
 	grpcConnectionTimeout = 10 * time.Second
 	downloadTimeout       = 30 * time.Second
 	maxCacheSize          = 100
+	// maxConcurrentFetches bounds the number of cameras queried for point clouds at the same time.
+	maxConcurrentFetches = 8
+	// initialRetryBackoff is the delay before the first retry of a failed camera, doubling with every further retry.
+	initialRetryBackoff = 50 * time.Millisecond
+	// unitMismatchRatio is how much larger or smaller than the others a camera's point cloud must be before a unit
+	// mismatch is suspected.
+	unitMismatchRatio = 100.0
+	// waitForCamerasInterval is the delay between attempts to resolve cameras that are not yet available.
+	waitForCamerasInterval = 200 * time.Millisecond
+	// emptyCloudWarnThreshold is the number of consecutive empty point clouds from a camera after which a warning is
+	// logged.
+	emptyCloudWarnThreshold = 10
+	// defaultClusterTolerance is the distance in meters within which points belong to the same cluster when
+	// min_cluster_size is set without a cluster_tolerance.
+	defaultClusterTolerance = 0.02
+	// mmPerMeter converts distances given in meters to the millimeters used by point clouds and the frame system.
+	mmPerMeter = 1000.0
+)
+
+const (
+	// colorModePreserve keeps whatever color each camera provides.
+	colorModePreserve = "preserve"
+	// colorModeStrip discards all color so that the merged point cloud is uniformly uncolored.
+	colorModeStrip = "strip"
+	// colorModeRequire fails a camera that returns any point without color.
+	colorModeRequire = "require"
+)
+
+const (
+	// outputStructureBasic returns the merged point cloud as the basic point cloud produced by merging.
+	outputStructureBasic = "basic"
+	// outputStructureOctree returns the merged point cloud as an octree.
+	outputStructureOctree = "octree"
+
+	// mergeStrategyRDK merges point clouds with pointcloud.MergePointClouds, handing it the clouds in the order of the
+	// cameras in the config.
+	mergeStrategyRDK = "rdk"
+	// mergeStrategyAppend transforms the points of every camera straight into a single presized point cloud, in the
+	// order of the cameras in the config.
+	mergeStrategyAppend = "append"
+)
+
+const (
+	// outputUnitsMillimeters leaves the merged point cloud in the millimeters used by the frame system.
+	outputUnitsMillimeters = "mm"
+	// outputUnitsMeters converts the merged point cloud to meters.
+	outputUnitsMeters = "m"
+)
+
+const (
+	// timestampModeEarliest reports the capture time of the first camera to return a point cloud.
+	timestampModeEarliest = "earliest"
+	// timestampModeLatest reports the capture time of the last camera to return a point cloud.
+	timestampModeLatest = "latest"
+)
+
+const (
+	// errorPolicyStrict fails the merge if any camera fails.
+	errorPolicyStrict = "strict"
+	// errorPolicySkip omits failing cameras from the merge as long as one camera succeeds.
+	errorPolicySkip = "skip"
 )
 
 var (
 	// model is the model of a replay camera.
 	model = resource.DefaultModelFamily.WithModel("merged_camera")
+
+	// ErrClosed is returned, wrapped, by the methods of a merged camera that has been closed.
+	ErrClosed = errors.New("merged camera is closed")
+	// ErrNoCameraData is returned, wrapped, when no camera is left to merge after the failed cameras are skipped.
+	ErrNoCameraData = errors.New("all cameras failed or produced no data")
 )
 
 func init() {
@@ -40,11 +114,382 @@ func init() {
 
 // Validate checks that the config attributes are valid for a replay camera.
 func (cfg *Config) Validate(path string) ([]string, error) {
-	if cfg.Cameras == nil {
-		return nil, resource.NewConfigValidationFieldRequiredError(path, "camera")
+	if cfg.CameraGroupFrame != "" {
+		if cfg.Cameras != nil {
+			return nil, resource.NewConfigValidationError(path, errors.New("cameras cannot be combined with camera_group_frame"))
+		}
+		if cfg.CameraGroupFrame == referenceframe.World {
+			return nil, resource.NewConfigValidationError(path,
+				errors.Errorf("camera_group_frame cannot be the %v frame", referenceframe.World))
+		}
+	} else {
+		if cfg.Cameras == nil {
+			return nil, resource.NewConfigValidationFieldRequiredError(path, "camera")
+		}
+		if len(cfg.Cameras) < 2 {
+			return nil, resource.NewConfigValidationError(path,
+				errors.Errorf("at least 2 cameras are required to merge point clouds, got %v", len(cfg.Cameras)))
+		}
+	}
+	seen := make(map[string]bool, len(cfg.Cameras))
+	for _, name := range cfg.Cameras {
+		if seen[name] {
+			return nil, resource.NewConfigValidationError(path, errors.Errorf("camera %v is listed more than once", name))
+		}
+		seen[name] = true
+	}
+	// the cameras of a group are only known once the frame system is queried
+	known := func(name string) bool {
+		return cfg.CameraGroupFrame != "" || seen[name]
+	}
+	switch cfg.ErrorPolicy {
+	case "", errorPolicyStrict, errorPolicySkip:
+	default:
+		return nil, resource.NewConfigValidationError(path,
+			errors.Errorf("invalid error_policy %q, must be %q or %q", cfg.ErrorPolicy, errorPolicyStrict, errorPolicySkip))
+	}
+	if cfg.VoxelSize < 0 {
+		return nil, resource.NewConfigValidationError(path, errors.Errorf("voxel_size must be non-negative, got %v", cfg.VoxelSize))
+	}
+	if cfg.TargetDensity < 0 {
+		return nil, resource.NewConfigValidationError(path,
+			errors.Errorf("target_density must be non-negative, got %v", cfg.TargetDensity))
+	}
+	for name, scale := range cfg.Scale {
+		if !known(name) {
+			return nil, resource.NewConfigValidationError(path, errors.Errorf("scale given for unknown camera %v", name))
+		}
+		if scale <= 0 {
+			return nil, resource.NewConfigValidationError(path, errors.Errorf("scale of camera %v must be positive, got %v", name, scale))
+		}
+	}
+	for name, correction := range cfg.ColorCorrection {
+		if !known(name) {
+			return nil, resource.NewConfigValidationError(path, errors.Errorf("color_correction given for unknown camera %v", name))
+		}
+		if correction == nil {
+			return nil, resource.NewConfigValidationError(path, errors.Errorf("color_correction for camera %v is empty", name))
+		}
+		if err := correction.Validate(); err != nil {
+			return nil, resource.NewConfigValidationError(path, errors.Wrapf(err, "invalid color_correction for camera %v", name))
+		}
+	}
+	for name, remap := range cfg.AxisRemap {
+		if !known(name) {
+			return nil, resource.NewConfigValidationError(path, errors.Errorf("axis_remap given for unknown camera %v", name))
+		}
+		if _, err := parseAxisRemap(remap); err != nil {
+			return nil, resource.NewConfigValidationError(path, errors.Wrapf(err, "invalid axis_remap of camera %v", name))
+		}
+	}
+	for name, voxelSize := range cfg.SourceVoxelSize {
+		if !known(name) {
+			return nil, resource.NewConfigValidationError(path, errors.Errorf("source_voxel_size given for unknown camera %v", name))
+		}
+		if voxelSize < 0 {
+			return nil, resource.NewConfigValidationError(path,
+				errors.Errorf("source_voxel_size of camera %v must be non-negative, got %v", name, voxelSize))
+		}
+	}
+	if len(cfg.Priority) > 0 {
+		if !cfg.TagSource {
+			return nil, resource.NewConfigValidationError(path, errors.New("priority requires tag_source to be enabled"))
+		}
+		if cfg.PriorityResolution <= 0 {
+			return nil, resource.NewConfigValidationError(path,
+				errors.Errorf("priority_resolution must be positive when priority is set, got %v", cfg.PriorityResolution))
+		}
+		for name := range cfg.Priority {
+			if !known(name) {
+				return nil, resource.NewConfigValidationError(path, errors.Errorf("priority given for unknown camera %v", name))
+			}
+		}
+	}
+	if cfg.MinClusterSize < 0 {
+		return nil, resource.NewConfigValidationError(path,
+			errors.Errorf("min_cluster_size must be non-negative, got %v", cfg.MinClusterSize))
+	}
+	if cfg.ClusterTolerance < 0 {
+		return nil, resource.NewConfigValidationError(path,
+			errors.Errorf("cluster_tolerance must be non-negative, got %v", cfg.ClusterTolerance))
+	}
+	if cfg.ClusterTolerance > 0 && cfg.MinClusterSize == 0 {
+		return nil, resource.NewConfigValidationError(path, errors.New("cluster_tolerance requires min_cluster_size"))
+	}
+	if cfg.DedupResolution < 0 {
+		return nil, resource.NewConfigValidationError(path,
+			errors.Errorf("dedup_resolution must be non-negative, got %v", cfg.DedupResolution))
+	}
+	if cfg.Projection != nil {
+		if err := cfg.Projection.Validate(); err != nil {
+			return nil, resource.NewConfigValidationError(path, errors.Wrap(err, "invalid projection"))
+		}
+		if name := cfg.Projection.IntrinsicsCamera; name != "" && !known(name) {
+			return nil, resource.NewConfigValidationError(path,
+				errors.Errorf("projection intrinsics_camera %v is not one of the cameras", name))
+		}
+	}
+	if cfg.MaxPoints < 0 {
+		return nil, resource.NewConfigValidationError(path, errors.Errorf("max_points must be non-negative, got %v", cfg.MaxPoints))
+	}
+	if cfg.BackgroundRateHz < 0 {
+		return nil, resource.NewConfigValidationError(path,
+			errors.Errorf("background_rate_hz must be non-negative, got %v", cfg.BackgroundRateHz))
+	}
+	if cfg.CropBox != nil {
+		if err := cfg.CropBox.Validate(); err != nil {
+			return nil, resource.NewConfigValidationError(path, errors.Wrap(err, "invalid crop_box"))
+		}
+	}
+	if cfg.CropFrame != "" && cfg.CropBox == nil && cfg.GroundRemoval == nil {
+		return nil, resource.NewConfigValidationError(path, errors.New("crop_frame requires crop_box or ground_removal"))
+	}
+	for name, boxes := range cfg.ExclusionBoxes {
+		if !known(name) {
+			return nil, resource.NewConfigValidationError(path, errors.Errorf("exclusion_boxes given for unknown camera %v", name))
+		}
+		for i, box := range boxes {
+			if box == nil {
+				return nil, resource.NewConfigValidationError(path, errors.Errorf("exclusion box %v of camera %v is empty", i, name))
+			}
+			if err := box.Validate(); err != nil {
+				return nil, resource.NewConfigValidationError(path,
+					errors.Wrapf(err, "invalid exclusion box %v of camera %v", i, name))
+			}
+		}
+	}
+	if cfg.MinRange < 0 || cfg.MaxRange < 0 {
+		return nil, resource.NewConfigValidationError(path,
+			errors.Errorf("min_range (%v) and max_range (%v) must be non-negative", cfg.MinRange, cfg.MaxRange))
+	}
+	if cfg.MaxRange > 0 && cfg.MinRange > cfg.MaxRange {
+		return nil, resource.NewConfigValidationError(path,
+			errors.Errorf("min_range (%v) must not exceed max_range (%v)", cfg.MinRange, cfg.MaxRange))
+	}
+	if cfg.Retries < 0 {
+		return nil, resource.NewConfigValidationError(path, errors.Errorf("retries must be non-negative, got %v", cfg.Retries))
+	}
+	if cfg.FrameSystemRetries < 0 {
+		return nil, resource.NewConfigValidationError(path,
+			errors.Errorf("frame_system_retries must be non-negative, got %v", cfg.FrameSystemRetries))
+	}
+	if cfg.CircuitBreaker != nil {
+		if err := cfg.CircuitBreaker.Validate(); err != nil {
+			return nil, resource.NewConfigValidationError(path, errors.Wrap(err, "invalid circuit_breaker"))
+		}
+	}
+	if cfg.PerCameraTimeout != "" {
+		timeout, err := time.ParseDuration(cfg.PerCameraTimeout)
+		if err != nil {
+			return nil, resource.NewConfigValidationError(path, errors.Wrap(err, "invalid per_camera_timeout"))
+		}
+		if timeout <= 0 {
+			return nil, resource.NewConfigValidationError(path,
+				errors.Errorf("per_camera_timeout must be positive, got %v", cfg.PerCameraTimeout))
+		}
+	}
+	for name, offset := range cfg.LatencyOffset {
+		if !known(name) {
+			return nil, resource.NewConfigValidationError(path, errors.Errorf("latency_offset given for unknown camera %v", name))
+		}
+		latency, err := time.ParseDuration(offset)
+		if err != nil {
+			return nil, resource.NewConfigValidationError(path, errors.Wrapf(err, "invalid latency_offset for camera %v", name))
+		}
+		if latency < 0 {
+			return nil, resource.NewConfigValidationError(path,
+				errors.Errorf("latency_offset of camera %v must be non-negative, got %v", name, offset))
+		}
+	}
+	if cfg.WaitForCameras != "" {
+		wait, err := time.ParseDuration(cfg.WaitForCameras)
+		if err != nil {
+			return nil, resource.NewConfigValidationError(path, errors.Wrap(err, "invalid wait_for_cameras"))
+		}
+		if wait <= 0 {
+			return nil, resource.NewConfigValidationError(path,
+				errors.Errorf("wait_for_cameras must be positive, got %v", cfg.WaitForCameras))
+		}
+	}
+	if cfg.SyncWindow != "" {
+		window, err := time.ParseDuration(cfg.SyncWindow)
+		if err != nil {
+			return nil, resource.NewConfigValidationError(path, errors.Wrap(err, "invalid sync_window"))
+		}
+		if window <= 0 {
+			return nil, resource.NewConfigValidationError(path, errors.Errorf("sync_window must be positive, got %v", cfg.SyncWindow))
+		}
+	}
+	for name, remote := range cfg.RemoteCameras {
+		if !known(name) {
+			return nil, resource.NewConfigValidationError(path, errors.Errorf("remote_cameras given for unknown camera %v", name))
+		}
+		if remote == nil {
+			return nil, resource.NewConfigValidationError(path, errors.Errorf("remote_cameras entry for camera %v is empty", name))
+		}
+		if err := remote.Validate(); err != nil {
+			return nil, resource.NewConfigValidationError(path, errors.Wrapf(err, "invalid remote camera %v", name))
+		}
+	}
+	for name, override := range cfg.PoseOverride {
+		if !known(name) {
+			return nil, resource.NewConfigValidationError(path, errors.Errorf("pose_override given for unknown camera %v", name))
+		}
+		if override == nil {
+			return nil, resource.NewConfigValidationError(path, errors.Errorf("pose_override for camera %v is empty", name))
+		}
+		if _, err := override.Pose(); err != nil {
+			return nil, resource.NewConfigValidationError(path, errors.Wrapf(err, "invalid pose_override for camera %v", name))
+		}
+	}
+	if cfg.OutputOffset != nil {
+		if _, err := cfg.OutputOffset.Pose(); err != nil {
+			return nil, resource.NewConfigValidationError(path, errors.Wrap(err, "invalid output_offset"))
+		}
+	}
+	if cfg.OutlierRemoval != nil {
+		if _, err := cfg.OutlierRemoval.filter(); err != nil {
+			return nil, resource.NewConfigValidationError(path, errors.Wrap(err, "invalid outlier_removal"))
+		}
+	}
+	if cfg.ICPRefine != nil {
+		if err := cfg.ICPRefine.Validate(); err != nil {
+			return nil, resource.NewConfigValidationError(path, errors.Wrap(err, "invalid icp_refine"))
+		}
+	}
+	if cfg.GroundRemoval != nil {
+		if err := cfg.GroundRemoval.Validate(); err != nil {
+			return nil, resource.NewConfigValidationError(path, errors.Wrap(err, "invalid ground_removal"))
+		}
+	}
+	if cfg.MinConfidence < 0 || cfg.MinConfidence > math.MaxUint16 {
+		return nil, resource.NewConfigValidationError(path,
+			errors.Errorf("min_confidence must be between 0 and %v, got %v", math.MaxUint16, cfg.MinConfidence))
+	}
+	if cfg.CacheSize < 0 || cfg.CacheSize > maxCacheSize {
+		return nil, resource.NewConfigValidationError(path,
+			errors.Errorf("cache_size must be between 0 and %v, got %v", maxCacheSize, cfg.CacheSize))
+	}
+	if cfg.CacheMaxPoints < 0 {
+		return nil, resource.NewConfigValidationError(path,
+			errors.Errorf("cache_max_points must be non-negative, got %v", cfg.CacheMaxPoints))
+	}
+	if cfg.AccumulateFrames < 0 {
+		return nil, resource.NewConfigValidationError(path,
+			errors.Errorf("accumulate_frames must be non-negative, got %v", cfg.AccumulateFrames))
+	}
+	if cfg.AccumulateFrames > 1 {
+		if !cfg.StaticRig {
+			return nil, resource.NewConfigValidationError(path,
+				errors.New("accumulate_frames requires static_rig, since accumulated frames smear when the cameras move"))
+		}
+		if !cfg.EnableCache {
+			return nil, resource.NewConfigValidationError(path, errors.New("accumulate_frames requires enable_cache"))
+		}
+		if cacheSize, _ := cfg.cacheLimits(); cfg.AccumulateFrames > cacheSize {
+			return nil, resource.NewConfigValidationError(path,
+				errors.Errorf("accumulate_frames (%v) must not exceed cache_size (%v)", cfg.AccumulateFrames, cacheSize))
+		}
+	}
+	if cfg.AccumulateDecay != "" {
+		if cfg.AccumulateFrames <= 1 {
+			return nil, resource.NewConfigValidationError(path, errors.New("accumulate_decay requires accumulate_frames"))
+		}
+		decay, err := time.ParseDuration(cfg.AccumulateDecay)
+		if err != nil {
+			return nil, resource.NewConfigValidationError(path, errors.Wrap(err, "invalid accumulate_decay"))
+		}
+		if decay <= 0 {
+			return nil, resource.NewConfigValidationError(path,
+				errors.Errorf("accumulate_decay must be positive, got %v", cfg.AccumulateDecay))
+		}
+	}
+	switch cfg.ColorMode {
+	case "", colorModePreserve, colorModeStrip, colorModeRequire:
+	default:
+		return nil, resource.NewConfigValidationError(path, errors.Errorf("invalid color_mode %q, must be %q, %q or %q",
+			cfg.ColorMode, colorModePreserve, colorModeStrip, colorModeRequire))
+	}
+	if cfg.DefaultValue != 0 && !cfg.UniformSchema {
+		return nil, resource.NewConfigValidationError(path, errors.New("default_value requires uniform_schema"))
+	}
+	if cfg.DefaultColor != nil {
+		if cfg.ColorMode != "" && cfg.ColorMode != colorModePreserve {
+			return nil, resource.NewConfigValidationError(path,
+				errors.Errorf("default_color requires color_mode %q, got %q", colorModePreserve, cfg.ColorMode))
+		}
+		if err := cfg.DefaultColor.Validate(); err != nil {
+			return nil, resource.NewConfigValidationError(path, errors.Wrap(err, "invalid default_color"))
+		}
+	}
+	switch cfg.MergeStrategy {
+	case "", mergeStrategyRDK, mergeStrategyAppend:
+	default:
+		return nil, resource.NewConfigValidationError(path, errors.Errorf("invalid merge_strategy %q, must be %q or %q",
+			cfg.MergeStrategy, mergeStrategyRDK, mergeStrategyAppend))
+	}
+	if cfg.MergeWorkers < 0 {
+		return nil, resource.NewConfigValidationError(path,
+			errors.Errorf("merge_workers must be non-negative, got %v", cfg.MergeWorkers))
+	}
+	if cfg.MergeWorkers > 1 && cfg.MergeStrategy != "" {
+		return nil, resource.NewConfigValidationError(path, errors.New("merge_workers cannot be combined with merge_strategy"))
+	}
+	if cfg.StreamingMerge {
+		for _, conflict := range []struct {
+			field string
+			set   bool
+		}{
+			{"merge_strategy", cfg.MergeStrategy != ""},
+			{"sync_window", cfg.SyncWindow != ""},
+			{"icp_refine", cfg.ICPRefine != nil},
+			{"merge_workers", cfg.MergeWorkers > 1},
+			{"uniform_schema", cfg.UniformSchema},
+		} {
+			if conflict.set {
+				return nil, resource.NewConfigValidationError(path,
+					errors.Errorf("streaming_merge cannot be combined with %v", conflict.field))
+			}
+		}
+	}
+	if cfg.ROSFrame && cfg.Projection != nil {
+		// the projection renders along the optical z axis, which ros_frame turns into x
+		return nil, resource.NewConfigValidationError(path, errors.New("ros_frame cannot be combined with projection"))
+	}
+	switch cfg.OutputUnits {
+	case "", outputUnitsMillimeters:
+	case outputUnitsMeters:
+		if cfg.Projection != nil {
+			return nil, resource.NewConfigValidationError(path,
+				errors.Errorf("output_units %q cannot be combined with projection", cfg.OutputUnits))
+		}
+	default:
+		return nil, resource.NewConfigValidationError(path, errors.Errorf("invalid output_units %q, must be %q or %q",
+			cfg.OutputUnits, outputUnitsMillimeters, outputUnitsMeters))
+	}
+	switch cfg.OutputStructure {
+	case "", outputStructureBasic, outputStructureOctree:
+	default:
+		return nil, resource.NewConfigValidationError(path, errors.Errorf("invalid output_structure %q, must be %q or %q",
+			cfg.OutputStructure, outputStructureBasic, outputStructureOctree))
+	}
+	if cfg.OctreeResolution < 0 {
+		return nil, resource.NewConfigValidationError(path,
+			errors.Errorf("octree_resolution must be non-negative, got %v", cfg.OctreeResolution))
+	}
+	switch cfg.TimestampMode {
+	case "", timestampModeEarliest, timestampModeLatest:
+	default:
+		return nil, resource.NewConfigValidationError(path,
+			errors.Errorf("invalid timestamp_mode %q, must be %q or %q", cfg.TimestampMode, timestampModeEarliest, timestampModeLatest))
+	}
+	// remote cameras are dialed by the merged camera rather than provided as dependencies
+	deps := make([]string, 0, len(cfg.Cameras)+1)
+	for _, name := range cfg.Cameras {
+		if cfg.RemoteCameras[name] == nil {
+			deps = append(deps, name)
+		}
 	}
-	deps := cfg.Cameras
-
 	deps = append(deps, framesystem.InternalServiceName.String())
 
 	return deps, nil
@@ -53,6 +498,232 @@ func (cfg *Config) Validate(path string) ([]string, error) {
 // Config describes how to configure the merged camera component.
 type Config struct {
 	Cameras []string `json:"cameras,omitempty"`
+	// CameraGroupFrame replaces cameras with every camera whose frame descends from this frame, in frame name order.
+	// The frame system is queried on every reconfigure, so cameras added under or removed from the frame are picked
+	// up without changing this config. Cameras of the group that are not dependencies are looked up on the robot.
+	CameraGroupFrame string `json:"camera_group_frame,omitempty"`
+	// RemoteCameras maps names in cameras to cameras outside the robot config, which are dialed directly over gRPC
+	// instead of being taken from the dependencies. Their connections are kept across reconfigures until their
+	// config changes and closed with the merged camera. A remote camera has no frame of its own unless the frame
+	// system has a frame of the same name, so it usually needs a pose_override.
+	RemoteCameras map[string]*RemoteCameraConfig `json:"remote_cameras,omitempty"`
+	// TargetFrame is the frame the merged point cloud is expressed in. Defaults to the frame of the first camera.
+	TargetFrame string `json:"target_frame,omitempty"`
+	// ErrorPolicy controls how camera failures are handled, either "strict" (default) or "skip". Under strict, a merge
+	// with failed cameras returns a *MergeError holding the failure of each.
+	ErrorPolicy string `json:"error_policy,omitempty"`
+	// VoxelSize is the edge length in meters of the voxel grid used to downsample the merged point cloud.
+	// Downsampling is disabled when zero.
+	VoxelSize float64 `json:"voxel_size,omitempty"`
+	// TargetDensity, in points per cubic meter, thins the merged point cloud after voxel downsampling so that regions
+	// seen by several cameras are no denser than regions seen by one. The voxel size adapts to the cloud: regions
+	// eight voxels of one point at the target density on a side are halved while their points are denser than the
+	// target, down to that single point voxel, which keeps only the point closest to the centroid of its points.
+	// Regions at or below the target density are unchanged.
+	TargetDensity float64 `json:"target_density,omitempty"`
+	// DynamicFrames disables caching of camera transforms for rigs where cameras move relative to each other. The
+	// inputs of the frame system are then read once per merge, so every camera is transformed as of the same moment.
+	DynamicFrames bool `json:"dynamic_frames,omitempty"`
+	// AllowDepthProjection accepts cameras that do not support point clouds but report intrinsics. Their point
+	// clouds are projected from the depth image they return, colored by their color image when it has the same size.
+	AllowDepthProjection bool `json:"allow_depth_projection,omitempty"`
+	// Projection configures how the merged point cloud is rendered by Images and Stream.
+	Projection *ProjectionConfig `json:"projection,omitempty"`
+	// CropBox removes merged points outside of the box, which is expressed in the target frame unless crop_frame is
+	// set.
+	CropBox *BoxConfig `json:"crop_box,omitempty"`
+	// CropFrame is the frame crop_box and ground_removal are expressed in, the target frame if unset. Its transform to
+	// the target frame is resolved once per merge and cached like those of the cameras when frames are static.
+	CropFrame string `json:"crop_frame,omitempty"`
+	// KeepInvalidPoints keeps points with NaN or infinite coordinates, which are otherwise dropped from each camera's
+	// point cloud before any other filter. Some depth drivers emit them for invalid pixels.
+	KeepInvalidPoints bool `json:"keep_invalid_points,omitempty"`
+	// ExclusionBoxes maps camera names to boxes in that camera's frame whose points are dropped before any other
+	// filter, e.g. to mask out parts of the robot the camera sees. Unlisted cameras keep every point.
+	ExclusionBoxes map[string][]*BoxConfig `json:"exclusion_boxes,omitempty"`
+	// MinRange and MaxRange, in meters, drop points of each camera that are closer or further away from that camera.
+	// A value of zero disables the corresponding bound.
+	MinRange float64 `json:"min_range,omitempty"`
+	MaxRange float64 `json:"max_range,omitempty"`
+	// PerCameraTimeout bounds how long each camera may take to return a point cloud, e.g. "500ms".
+	PerCameraTimeout string `json:"per_camera_timeout,omitempty"`
+	// WaitForCameras is how long Reconfigure keeps retrying cameras that are missing or failing, e.g. "30s", so that
+	// slowly enumerating cameras do not prevent startup. By default Reconfigure fails immediately.
+	WaitForCameras string `json:"wait_for_cameras,omitempty"`
+	// LatencyOffset maps camera names to how long before being received their point clouds are captured, e.g.
+	// "30ms". The capture time of each point cloud is taken to be the time it was received less the offset, which
+	// is used for timestamp_mode, sync_window and status. Offsets are assumed to be constant so points are not moved
+	// to compensate for motion of the rig. Unlisted cameras have no offset.
+	LatencyOffset map[string]string `json:"latency_offset,omitempty"`
+	// SyncWindow bounds how far apart the capture times of merged point clouds may be, e.g. "100ms". Cameras
+	// captured more than the window before the latest camera are treated as failed and handled by the error
+	// policy. Point cloud requests carry no capture time, so the time each point cloud was received is used.
+	SyncWindow string `json:"sync_window,omitempty"`
+	// Retries is the number of times a failed point cloud request is retried before the error policy applies.
+	Retries int `json:"retries,omitempty"`
+	// FrameSystemRetries is the number of times a failed frame system call is retried, with the same backoff as
+	// retries, before a camera is failed. Unless dynamic_frames is set, a camera whose transform still cannot be
+	// resolved then falls back to the last transform resolved for it since the last reconfigure, if any, which
+	// keeps merges going through a momentary frame system outage. A warning is logged whenever that happens.
+	FrameSystemRetries int `json:"frame_system_retries,omitempty"`
+	// CircuitBreaker skips cameras that keep failing for a cooldown instead of requesting, and retrying, their point
+	// clouds on every merge. Skipped cameras fail immediately and are handled by the error policy.
+	CircuitBreaker *CircuitBreakerConfig `json:"circuit_breaker,omitempty"`
+	// OwnCameras closes the cameras when the merged camera is closed. Only set this when no other resource
+	// uses the cameras.
+	OwnCameras bool `json:"own_cameras,omitempty"`
+	// DedupResolution is the cell size in meters used to collapse near-duplicate points where cameras overlap.
+	// Deduplication is disabled when zero.
+	DedupResolution float64 `json:"dedup_resolution,omitempty"`
+	// PoseOverride maps camera names to the pose of the camera in the target frame, used instead of looking the
+	// camera up in the frame system. Cameras without an override are looked up in the frame system.
+	PoseOverride map[string]*PoseConfig `json:"pose_override,omitempty"`
+	// OutputOffset is applied to the merged point cloud after every other step but ros_frame, e.g. to align it with a
+	// CAD model.
+	OutputOffset *PoseConfig `json:"output_offset,omitempty"`
+	// ROSFrame converts the merged point cloud, after output_offset, from the optical axis convention of the target
+	// frame (x right, y down, z forward) to the ROS body convention (x forward, y left, z up) expected by ROS
+	// PointCloud2 consumers. Each point (x, y, z) becomes (z, -x, -y), a rotation of -90 degrees about x followed by
+	// -90 degrees about z, so both conventions are right-handed. It cannot be combined with projection, which renders
+	// the optical axes, and Images is unimplemented with it.
+	ROSFrame bool `json:"ros_frame,omitempty"`
+	// OutputUnits is the unit of the merged point cloud, either "mm" (default) or "m". The default is millimeters
+	// rather than meters because RDK point clouds, the frame system and every other camera already use them, so the
+	// merged cloud is left as merged unless meters are asked for. Meters scale the merged cloud by 0.001 after
+	// ros_frame, the inverse of a scale of 1000 applied to every camera, so that consumers expecting meters need not
+	// convert. The octree_resolution is still given in meters. Meters cannot be combined with projection, which
+	// projects millimeters, and Images is unimplemented with them.
+	OutputUnits string `json:"output_units,omitempty"`
+	// TagSource sets the value of every merged point to the index of its camera in Cameras. Any value
+	// reported by the camera is replaced, colors are kept.
+	TagSource bool `json:"tag_source,omitempty"`
+	// Scale maps camera names to a factor applied to their point clouds before any other processing, e.g. 1000 for
+	// a camera reporting meters rather than millimeters. Unlisted cameras are not scaled.
+	Scale map[string]float64 `json:"scale,omitempty"`
+	// ColorCorrection maps camera names to a gain and offset applied to the colors of their point clouds before the
+	// color mode, so that cameras with different color calibrations look alike once merged. Uncolored points are
+	// left as they are.
+	ColorCorrection map[string]*ColorCorrectionConfig `json:"color_correction,omitempty"`
+	// AxisRemap maps camera names to the axes of their frame expressed in the axes their driver reports, e.g.
+	// "x,-z,y" takes y from -z and z from y. It corrects drivers with a different handedness or axis convention
+	// than the frame system and applies right after scale. Unlisted cameras are not remapped.
+	AxisRemap map[string]string `json:"axis_remap,omitempty"`
+	// SourceVoxelSize maps camera names to a voxel size in meters used to downsample their point clouds in their
+	// own frame before they are transformed and merged. Unlike voxel_size this balances the contribution of high
+	// and low resolution cameras and saves merge work. Unlisted cameras are not downsampled.
+	SourceVoxelSize map[string]float64 `json:"source_voxel_size,omitempty"`
+	// Priority maps camera names to their priority, zero for unlisted cameras. Where cameras overlap within a cell
+	// of PriorityResolution meters, only the points of the highest-priority camera are kept. This runs before
+	// dedup_resolution and voxel_size, so those only combine points of the preferred camera within an overlap.
+	// Requires TagSource, which identifies the camera of each point.
+	Priority           map[string]int `json:"priority,omitempty"`
+	PriorityResolution float64        `json:"priority_resolution,omitempty"`
+	// MinConfidence drops points of each camera whose confidence is below it before merging. Confidence is carried in
+	// the intensity of the RDK point data, which is preserved through the merge alongside color and value. Zero keeps
+	// every point.
+	MinConfidence int `json:"min_confidence,omitempty"`
+	// ColorMode controls the color of the merged point cloud: "preserve" (default) keeps the color of each camera,
+	// "strip" removes all color and "require" treats a camera returning any uncolored point as failed.
+	ColorMode string `json:"color_mode,omitempty"`
+	// DefaultColor colors the points that cameras return without color, e.g. those of depth only cameras, so that
+	// every merged point is colored. It is applied before color_mode and requires the "preserve" mode, since "strip"
+	// would remove it again and "require" exists to reject uncolored cameras rather than paint them.
+	DefaultColor *ColorConfig `json:"default_color,omitempty"`
+	// UniformSchema gives every point the same data channels before merging, so that the merged point cloud is
+	// uniform even when some cameras return color or values and others only positions. Points lacking a channel
+	// that another point of the merge carries are given black, unless default_color already colored them, or
+	// default_value. Intensity is carried by every point regardless.
+	UniformSchema bool `json:"uniform_schema,omitempty"`
+	// DefaultValue is the value uniform_schema gives the points without one. Defaults to zero.
+	DefaultValue int `json:"default_value,omitempty"`
+	// OutlierRemoval removes noise from the merged point cloud. It is expensive and disabled unless configured.
+	OutlierRemoval *OutlierRemovalConfig `json:"outlier_removal,omitempty"`
+	// MinClusterSize drops every cluster of fewer points from the merged point cloud after outlier removal, where a
+	// cluster is a set of points joined by chains of points each within cluster_tolerance of the next. This removes
+	// isolated clumps of noise that outlier_removal keeps because their points are close to each other, but it
+	// compares every point with its neighbors within the tolerance, which is slower than outlier removal on dense
+	// clouds and grows with the tolerance. Disabled when zero.
+	MinClusterSize int `json:"min_cluster_size,omitempty"`
+	// ClusterTolerance is the distance in meters within which points belong to the same cluster. Defaults to 0.02.
+	ClusterTolerance float64 `json:"cluster_tolerance,omitempty"`
+	// RandomSeed seeds the random sampling of every merge, so that identical inputs always yield identical merged
+	// point clouds. The only stage that samples randomly is RANSAC ground_removal, but it and max_points subsampling
+	// pick points by their order, which only the "rdk" merge_strategy varies between merges. Each merge is seeded
+	// from the time when unset.
+	RandomSeed *int64 `json:"random_seed,omitempty"`
+	// GroundRemoval removes the ground from the merged point cloud after voxel downsampling. It is disabled unless
+	// configured.
+	GroundRemoval *GroundRemovalConfig `json:"ground_removal,omitempty"`
+	// ICPRefine corrects small errors in the orientation of each camera by aligning its cloud with those of the
+	// cameras before it. It is expensive and disabled unless configured.
+	ICPRefine *ICPConfig `json:"icp_refine,omitempty"`
+	// MergeStrategy is either "rdk" (default), which merges with pointcloud.MergePointClouds, or "append", which
+	// transforms the points of every camera straight into a single point cloud sized for all of them. Both produce
+	// the same points. Append keeps the order of the cameras in the config and of the points within each cloud, so
+	// identical inputs give an identical output. Rdk hands the clouds to MergePointClouds in config order, but it
+	// interleaves their points in whatever order its goroutines deliver them. Append also avoids the allocations of
+	// MergePointClouds, which dominate when merging many small clouds.
+	MergeStrategy string `json:"merge_strategy,omitempty"`
+	// MergeWorkers, when greater than one, replaces merge_strategy with a merge on this many workers. The clouds are
+	// split into chunks that the workers transform concurrently into partial clouds, which are then reduced into the
+	// merged cloud in order. The result is the same as the "append" strategy, but the transforms of large clouds no
+	// longer run on a single core.
+	MergeWorkers int `json:"merge_workers,omitempty"`
+	// StreamingMerge fetches the cameras one at a time in config order, appending each cloud to the merged cloud and
+	// releasing it before fetching the next. Only one source cloud is held at a time, which lowers peak memory when
+	// merging many large clouds at the cost of fetching the cameras sequentially. It replaces merge_strategy, skips
+	// the unit mismatch check and cannot be combined with sync_window or icp_refine, which need every cloud at once.
+	StreamingMerge bool `json:"streaming_merge,omitempty"`
+	// OutputStructure is either "basic" (default) or "octree". Building the octree makes every merge slower and
+	// its iteration order is spatial rather than by camera, but it speeds up spatial queries such as collision
+	// checks on the returned cloud.
+	OutputStructure string `json:"output_structure,omitempty"`
+	// OctreeResolution is the smallest octree cell in meters. Points sharing a cell are collapsed to the first,
+	// which bounds the depth of the octree. Zero keeps every point.
+	OctreeResolution float64 `json:"octree_resolution,omitempty"`
+	// TimestampMode selects whether the capture time of a merged point cloud is the "earliest" (default) or
+	// "latest" capture time of its cameras.
+	TimestampMode string `json:"timestamp_mode,omitempty"`
+	// MaxPoints caps the number of points in the merged point cloud, which is uniformly subsampled when it exceeds
+	// the budget. Zero means no limit.
+	MaxPoints int `json:"max_points,omitempty"`
+	// EnableCache keeps the most recent merged point clouds for replay through the "get_cached" command. At most
+	// CacheSize clouds, 100 by default and at most, and CacheMaxPoints points, 5000000 by default, are kept.
+	EnableCache    bool `json:"enable_cache,omitempty"`
+	CacheSize      int  `json:"cache_size,omitempty"`
+	CacheMaxPoints int  `json:"cache_max_points,omitempty"`
+	// AccumulateFrames, when greater than one, returns the union of the most recent AccumulateFrames cached merges,
+	// the current one included, to densify sparse clouds. It is only meant for static-rig scenes, where neither the
+	// cameras nor the scene move, since otherwise every frame lands somewhere else in the target frame, so it
+	// requires StaticRig to be set along with enable_cache, whose clouds are the frames accumulated. Where frames
+	// share a position the newest wins. Filters such as max_points apply to each frame rather than to the union.
+	AccumulateFrames int `json:"accumulate_frames,omitempty"`
+	// AccumulateDecay drops accumulated frames captured longer than this before the current one, e.g. "2s", so that
+	// points of objects that left the scene fade out. By default every accumulated frame is kept.
+	AccumulateDecay string `json:"accumulate_decay,omitempty"`
+	// StaticRig acknowledges that the cameras and the scene are static, which accumulate_frames relies on.
+	StaticRig bool `json:"static_rig,omitempty"`
+	// FailFast stops Reconfigure at the first invalid camera rather than reporting every invalid camera at once.
+	FailFast bool `json:"fail_fast,omitempty"`
+	// BackgroundRateHz, when positive, merges point clouds in the background at this rate. NextPointCloud then
+	// returns the most recent background merge immediately instead of merging on every call, so consumers are not
+	// slowed to the cadence of the cameras. Errors of the most recent merge are returned the same way.
+	BackgroundRateHz float64 `json:"background_rate_hz,omitempty"`
+	// LogPipeline logs every step of each merge at info level with structured fields: the points, timings and
+	// transform of each camera, the point count after each filter and the final point count.
+	LogPipeline bool `json:"log_pipeline,omitempty"`
+}
+
+// cacheLimits returns the number of clouds and total points the merged cloud cache may hold.
+func (cfg *Config) cacheLimits() (int, int) {
+	cacheSize, cacheMaxPoints := cfg.CacheSize, cfg.CacheMaxPoints
+	if cacheSize == 0 {
+		cacheSize = maxCacheSize
+	}
+	if cacheMaxPoints == 0 {
+		cacheMaxPoints = defaultCacheMaxPoints
+	}
+	return cacheSize, cacheMaxPoints
 }
 
 type mergedCamera struct {
@@ -60,9 +731,102 @@ type mergedCamera struct {
 	logger logging.Logger
 
 	cameras []camera.Camera
-	mu      sync.Mutex
+	// cameraHandles holds the resolved cameras keyed by their configured name
+	cameraHandles map[string]cameraHandle
+	// appliedConfig is the JSON encoding of the config last applied by Reconfigure, nil when it failed to apply
+	appliedConfig []byte
+	// remoteCameras holds the connected remote cameras keyed by their configured name
+	remoteCameras map[string]*remoteCamera
+	// mu guards the configuration and closed. Reconfigure and Close hold it exclusively while merges share it, so
+	// concurrent merges run in parallel.
+	mu sync.RWMutex
+	// stateMu guards the state updated by every merge: lastCapturedAt, lastPointCloud, lastTrace, metrics and the
+	// fields of cameraStatuses. It also guards disabledCameras, which is changed by DoCommand between merges.
+	stateMu sync.Mutex
+	// backgroundMu guards background, which is nil unless background_rate_hz is set. It is separate from mu since
+	// the background merges hold mu while Reconfigure and Close wait for them to stop.
+	backgroundMu sync.Mutex
+	background   *backgroundMerger
+
+	fsService   framesystem.Service
+	targetFrame string
+	errorPolicy string
+	ownCameras  bool
+	logPipeline bool
+
+	timestampMode  string
+	lastCapturedAt time.Time
+	lastPointCloud pointcloud.PointCloud
+	cloudCache     *cloudCache // nil unless enable_cache is set
+	// lastTrace holds the stages of the merge that produced lastPointCloud
+	lastTrace *pipelineTrace
+	// accumulateFrames and accumulateDecay configure accumulation over the cache, disabled when accumulateFrames is
+	// at most one
+	accumulateFrames int
+	accumulateDecay  time.Duration
+
+	intrinsics           *transform.PinholeCameraIntrinsics
+	projectionIntrinsics *transform.PinholeCameraIntrinsics
+	projectionErr        error // why projectionIntrinsics is nil for a perspective projection
+	projection           *ProjectionConfig
+	allowDepthProjection bool
+	// depthIntrinsics is indexed like cameras and set for the cameras projected from depth images, nil when none are
+	depthIntrinsics []*transform.PinholeCameraIntrinsics
+
+	// processing applied to each camera's point cloud before merging
+	keepInvalid      bool
+	scales           []float64          // indexed like cameras, nil when no camera is scaled
+	colorCorrections []*colorCorrection // indexed like cameras, nil when no camera is color corrected
+	axisRemaps       []*axisRemap       // indexed like cameras, nil when no camera is remapped
+	exclusionBoxes   [][]*BoxConfig     // indexed like cameras, nil when no camera has exclusion boxes
+	latencyOffsets   []time.Duration    // indexed like cameras, nil when no camera has a latency offset
+	sourceVoxelSizes []float64          // indexed like cameras in millimeters, nil when no camera is downsampled
+	perCameraTimeout time.Duration
+	retries          int
+	fsRetries        int             // retries of failed frame system calls
+	breaker          *circuitBreaker // nil when no circuit breaker is configured
+	syncWindow       time.Duration
+	minRange         float64
+	maxRange         float64
+	tagSource        bool
+	colorMode        string
+	defaultColor     *color.NRGBA // nil when uncolored points are left uncolored
+	uniformSchema    bool
+	defaultValue     int
+
+	// processing applied to the merged point cloud
+	cropBox            *BoxConfig
+	cropFrame          string
+	priorities         []int // indexed like cameras, nil when priority is not configured
+	priorityResolution float64
+	dedupResolution    float64
+	maxPoints          int
+	outlierFilter      func(pointcloud.PointCloud) (pointcloud.PointCloud, error)
+	minClusterSize     int
+	clusterTolerance   float64 // in millimeters
+	groundRemoval      *GroundRemovalConfig
+	randomSeed         *int64 // nil to seed from the time
+	icpRefine          *ICPConfig
+	minConfidence      uint16
+	voxelSize          float64
+	targetDensity      float64
+	outputOffset       spatialmath.Pose // nil when no offset is configured
+	rosFrame           bool
+	outputScale        float64 // converts millimeters to the output_units, zero when they are millimeters
+	outputStructure    string
+	mergeStrategy      string
+	mergeWorkers       int
+	streamingMerge     bool
+	octreeResolution   float64
+
+	dynamicFrames  bool
+	poseOverrides  map[string]spatialmath.Pose // keyed by camera short name
+	transformCache *transformCache
 
-	fsService framesystem.Service
+	cameraStatuses map[string]*cameraStatus
+	metrics        *mergeMetrics
+	// disabledCameras holds the short names of the cameras disabled by set_enabled, which are not merged
+	disabledCameras map[string]bool
 
 	closed bool
 }
@@ -83,124 +847,1607 @@ func newMergedCamera(
 	return cam, nil
 }
 
-// Close stops replay camera, closes the channels and its connections to the cloud.
+// Close stops the merged camera and releases its references to the cameras and frame system. The connections to
+// remote cameras are always closed, while the other cameras are only closed when the merged camera owns them.
+// Closing an already closed camera is a no-op.
 func (merged *mergedCamera) Close(ctx context.Context) error {
+	merged.stopBackgroundMerges()
 	merged.mu.Lock()
 	defer merged.mu.Unlock()
 
+	if merged.closed {
+		return nil
+	}
 	merged.closed = true
-	return nil
+
+	err := merged.closeRemoteCameras(ctx, nil)
+	if merged.ownCameras {
+		for _, cam := range merged.cameras {
+			if _, remote := cam.(*remoteCamera); remote {
+				continue
+			}
+			if closeErr := cam.Close(ctx); closeErr != nil {
+				err = multierr.Append(err, errors.Wrapf(closeErr, "error closing camera %v", cam.Name().ShortName()))
+			}
+		}
+	}
+
+	merged.cameras = nil
+	merged.cameraHandles = nil
+	merged.fsService = nil
+	merged.transformCache = nil
+	merged.cameraStatuses = nil
+	merged.metrics = nil
+	merged.lastPointCloud = nil
+	merged.lastTrace = nil
+	merged.cloudCache = nil
+	return err
+}
+
+// frameSystemFromDependencies returns the frame system service among the dependencies.
+func frameSystemFromDependencies(deps resource.Dependencies) (framesystem.Service, error) {
+	dep, ok := deps[framesystem.InternalServiceName]
+	if !ok {
+		return nil, errors.Errorf("missing dependency on frame system service %v", framesystem.InternalServiceName)
+	}
+	fsService, ok := dep.(framesystem.Service)
+	if !ok {
+		return nil, errors.New("frame system service is invalid type")
+	}
+	return fsService, nil
 }
 
 // Reconfigure finishes the bring up of the replay camera by evaluating given arguments and setting up the required cloud
-// connection.
+// connection. A config identical to the one last applied, with the same cameras among the dependencies, is not
+// applied again.
 func (merged *mergedCamera) Reconfigure(ctx context.Context, deps resource.Dependencies, conf resource.Config) error {
 
 	mergedCameraConfig, err := resource.NativeConfig[*Config](conf)
 	if err != nil {
 		return err
 	}
+	applied, err := json.Marshal(mergedCameraConfig)
+	if err != nil {
+		return errors.Wrap(err, "error encoding config")
+	}
+	unchanged, err := merged.reconfigureUnchanged(deps, mergedCameraConfig, applied)
+	if err != nil || unchanged {
+		return err
+	}
 
-	var cameras []camera.Camera
-	for _, cameraName := range mergedCameraConfig.Cameras {
+	// background merges restart under the new config, and stay stopped if it cannot be applied
+	merged.stopBackgroundMerges()
+	merged.mu.Lock()
+	defer merged.mu.Unlock()
+	// a config that fails to apply leaves the merged camera partly reconfigured, so it is never considered applied
+	merged.appliedConfig = nil
 
-		cam, err := camera.FromDependencies(deps, cameraName)
+	var waitForCameras time.Duration
+	if mergedCameraConfig.WaitForCameras != "" {
+		waitForCameras, err = time.ParseDuration(mergedCameraConfig.WaitForCameras)
 		if err != nil {
-			return errors.Wrapf(err, "error getting camera %v", cameraName)
+			return errors.Wrap(err, "invalid wait_for_cameras")
 		}
+	}
 
-		// If there is a camera provided in the 'camera' field, we enforce that it supports PCD.
-		properties, err := cam.Properties(ctx)
-		if err != nil {
-			return errors.Wrapf(err, "error getting camera properties %v", cameraName)
-		}
+	if err := merged.closeRemoteCameras(ctx, mergedCameraConfig.RemoteCameras); err != nil {
+		merged.logger.Warnf("error closing remote cameras that are no longer configured: %v", err)
+	}
 
-		if properties.SupportsPCD != true {
-			return errors.Errorf("error camera %v does not support PCDs", cameraName)
+	cameraNames := mergedCameraConfig.Cameras
+	if mergedCameraConfig.CameraGroupFrame != "" {
+		cameraNames, deps, err = merged.groupCameras(ctx, deps, mergedCameraConfig.CameraGroupFrame,
+			mergedCameraConfig.RemoteCameras)
+		if err != nil {
+			return err
 		}
-
-		cameras = append(cameras, cam)
 	}
 
-	for name, dep := range deps {
-		if name == framesystem.InternalServiceName {
-			fsService, ok := dep.(framesystem.Service)
-			if !ok {
-				return errors.New("frame system service is invalid type")
+	// resolve every camera, retrying those that fail until wait_for_cameras has passed
+	handles := make([]*cameraHandle, len(cameraNames))
+	statuses := make([]*cameraStatus, len(cameraNames))
+	pending := make([]int, len(cameraNames))
+	for i := range pending {
+		pending[i] = i
+	}
+	deadline := time.Now().Add(waitForCameras)
+	var cameraErrs error
+	for {
+		var missing []int
+		var missingNames []string
+		cameraErrs = nil
+		for _, i := range pending {
+			cameraName := cameraNames[i]
+			handle, status, err := merged.resolveCamera(ctx, deps, cameraName, mergedCameraConfig.RemoteCameras[cameraName],
+				mergedCameraConfig.AllowDepthProjection)
+			if err != nil {
+				if mergedCameraConfig.FailFast && waitForCameras == 0 {
+					return err
+				}
+				cameraErrs = multierr.Append(cameraErrs, err)
+				missing = append(missing, i)
+				missingNames = append(missingNames, cameraName)
+				continue
 			}
-			merged.fsService = fsService
+			handles[i], statuses[i] = &handle, status
+		}
+		remaining := time.Until(deadline)
+		if len(missing) == 0 || remaining <= 0 {
 			break
 		}
+		merged.logger.Infof("waiting up to %v for cameras %v", remaining.Round(time.Millisecond), missingNames)
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "interrupted while waiting for cameras %v", missingNames)
+		case <-time.After(waitForCamerasInterval):
+		}
+		pending = missing
+	}
+	if cameraErrs != nil && mergedCameraConfig.FailFast {
+		return multierr.Errors(cameraErrs)[0]
 	}
 
-	merged.cameras = cameras
-	return nil
-}
+	var cameras []camera.Camera
+	var cameraProperties []camera.Properties
+	cameraHandles := make(map[string]cameraHandle)
+	cameraStatuses := make(map[string]*cameraStatus)
+	// frames, statuses and transforms are keyed by short name, which includes the remote, so two configured names
+	// resolving to cameras with the same short name would silently share them
+	shortNames := make(map[string]string)
+	for i, cameraName := range cameraNames {
+		if handles[i] == nil {
+			continue
+		}
+		handle := *handles[i]
+		shortName := handle.cam.Name().ShortName()
+		if other, ok := shortNames[shortName]; ok {
+			err := errors.Errorf("cameras %v and %v share the short name %v", other, cameraName, shortName)
+			if mergedCameraConfig.FailFast {
+				return err
+			}
+			cameraErrs = multierr.Append(cameraErrs, err)
+			continue
+		}
+		shortNames[shortName] = cameraName
 
-// NextPointCloud returns the next point cloud retrieved from cloud storage based on the applied filter.
-func (merged *mergedCamera) NextPointCloud(ctx context.Context) (pointcloud.PointCloud, error) {
-	merged.mu.Lock()
-	defer merged.mu.Unlock()
-	if merged.closed {
-		return nil, errors.New("session closed")
+		cameras = append(cameras, handle.cam)
+		cameraProperties = append(cameraProperties, handle.properties)
+		cameraHandles[cameraName] = handle
+		cameraStatuses[shortName] = statuses[i]
+	}
+	if cameraErrs != nil {
+		return errors.Wrapf(cameraErrs, "%v of %v cameras are invalid",
+			len(multierr.Errors(cameraErrs)), len(cameraNames))
 	}
 
-	var cloudAndOffsetFuncs []pointcloud.CloudAndOffsetFunc
-	for _, cam := range merged.cameras {
-		camCopy := cam
-		fmt.Printf("%v Camera \n", cam)
-
-		cloudAndOffsetFunc := func(ctx context.Context) (pointcloud.PointCloud, spatialmath.Pose, error) {
-			pc, err := camCopy.NextPointCloud(ctx)
-			fmt.Printf("%v NextPointCloud PC: %v \n", camCopy.Name().ShortName(), pc)
-			fmt.Printf("%v NextPointCloud err: %v \n\n", camCopy.Name().ShortName(), err)
+	fsService, err := frameSystemFromDependencies(deps)
+	if err != nil {
+		return err
+	}
+	merged.fsService = fsService
 
-			// determine transform from each camera to first camera
-			origin := referenceframe.NewPoseInFrame(merged.cameras[0].Name().ShortName(), spatialmath.NewZeroPose())
-			transformedPose, err := merged.fsService.TransformPose(ctx, origin, camCopy.Name().ShortName(), nil)
-			if err != nil {
-				return nil, nil, errors.Errorf("issue getting tranform from camera %v to first camera %v", merged.cameras[0].Name().ShortName(), camCopy.Name().ShortName())
-			}
+	// merge into the frame of the first camera unless a target frame is given
+	targetFrame := mergedCameraConfig.TargetFrame
+	if targetFrame != "" {
+		if err := merged.checkFrameExists(ctx, targetFrame); err != nil {
+			return errors.Wrapf(err, "invalid target frame %v", targetFrame)
+		}
+	} else if len(cameras) > 0 {
+		targetFrame = cameras[0].Name().ShortName()
+	}
+	if cropFrame := mergedCameraConfig.CropFrame; cropFrame != "" && mergedCameraConfig.PoseOverride[cropFrame] == nil {
+		if err := merged.checkFrameExists(ctx, cropFrame); err != nil {
+			return errors.Wrapf(err, "invalid crop frame %v", cropFrame)
+		}
+	}
 
-			return pc, transformedPose.Pose(), err
+	var perCameraTimeout time.Duration
+	if mergedCameraConfig.PerCameraTimeout != "" {
+		perCameraTimeout, err = time.ParseDuration(mergedCameraConfig.PerCameraTimeout)
+		if err != nil {
+			return errors.Wrap(err, "invalid per_camera_timeout")
 		}
+	}
 
-		cloudAndOffsetFuncs = append(cloudAndOffsetFuncs, cloudAndOffsetFunc)
+	var accumulateDecay time.Duration
+	if mergedCameraConfig.AccumulateDecay != "" {
+		accumulateDecay, err = time.ParseDuration(mergedCameraConfig.AccumulateDecay)
+		if err != nil {
+			return errors.Wrap(err, "invalid accumulate_decay")
+		}
 	}
 
-	fmt.Println("hIIII")
-	mergedPC, err := pointcloud.MergePointClouds(ctx, cloudAndOffsetFuncs, merged.logger)
+	breaker, err := newCircuitBreaker(mergedCameraConfig.CircuitBreaker)
 	if err != nil {
-		return nil, errors.Wrapf(err, "issue merging pointclouds")
+		return errors.Wrap(err, "invalid circuit_breaker")
 	}
-	fmt.Println("merged PC: ", mergedPC)
-	fmt.Println("error PC: ", err)
 
-	return mergedPC, err
+	var syncWindow time.Duration
+	if mergedCameraConfig.SyncWindow != "" {
+		syncWindow, err = time.ParseDuration(mergedCameraConfig.SyncWindow)
+		if err != nil {
+			return errors.Wrap(err, "invalid sync_window")
+		}
+	}
 
-}
+	poseOverrides := make(map[string]spatialmath.Pose)
+	for i, cameraName := range cameraNames {
+		override, ok := mergedCameraConfig.PoseOverride[cameraName]
+		if !ok || override == nil {
+			continue
+		}
+		pose, err := override.Pose()
+		if err != nil {
+			return errors.Wrapf(err, "invalid pose_override for camera %v", cameraName)
+		}
+		poseOverrides[cameras[i].Name().ShortName()] = pose
+	}
 
-// Images is a part of the camera interface but is not implemented for replay.
-func (merged *mergedCamera) Images(ctx context.Context) ([]camera.NamedImage, resource.ResponseMetadata, error) {
-	return nil, resource.ResponseMetadata{}, errors.New("Images is unimplemented")
-}
+	var outputOffset spatialmath.Pose
+	if mergedCameraConfig.OutputOffset != nil {
+		outputOffset, err = mergedCameraConfig.OutputOffset.Pose()
+		if err != nil {
+			return errors.Wrap(err, "invalid output_offset")
+		}
+	}
 
-// Properties is a part of the camera interface and returns the camera.Properties struct with SupportsPCD set to true.
-func (merged *mergedCamera) Properties(ctx context.Context) (camera.Properties, error) {
-	props := camera.Properties{
-		SupportsPCD: true,
+	var outlierFilter func(pointcloud.PointCloud) (pointcloud.PointCloud, error)
+	if mergedCameraConfig.OutlierRemoval != nil {
+		outlierFilter, err = mergedCameraConfig.OutlierRemoval.filter()
+		if err != nil {
+			return errors.Wrap(err, "invalid outlier_removal")
+		}
 	}
-	return props, nil
-}
 
-// Projector is a part of the camera interface but is not implemented for replay.
-func (merged *mergedCamera) Projector(ctx context.Context) (transform.Projector, error) {
-	var proj transform.Projector
-	return proj, errors.New("Projector is unimplemented")
-}
+	errorPolicy := mergedCameraConfig.ErrorPolicy
+	if errorPolicy == "" {
+		errorPolicy = errorPolicyStrict
+	}
 
-// Stream is a part of the camera interface but is not implemented for replay.
-func (merged *mergedCamera) Stream(ctx context.Context, errHandlers ...gostream.ErrorHandler) (gostream.VideoStream, error) {
-	var stream gostream.VideoStream
-	return stream, errors.New("Stream is unimplemented")
+	merged.cameras = cameras
+	merged.cameraHandles = cameraHandles
+	merged.targetFrame = targetFrame
+	merged.errorPolicy = errorPolicy
+	merged.timestampMode = mergedCameraConfig.TimestampMode
+	merged.voxelSize = mergedCameraConfig.VoxelSize
+	merged.targetDensity = mergedCameraConfig.TargetDensity
+	merged.cropBox = mergedCameraConfig.CropBox
+	merged.cropFrame = mergedCameraConfig.CropFrame
+	merged.outputOffset = outputOffset
+	merged.rosFrame = mergedCameraConfig.ROSFrame
+	merged.outputScale = 0
+	if mergedCameraConfig.OutputUnits == outputUnitsMeters {
+		merged.outputScale = 1 / mmPerMeter
+	}
+	merged.outputStructure = mergedCameraConfig.OutputStructure
+	merged.mergeStrategy = mergedCameraConfig.MergeStrategy
+	merged.mergeWorkers = mergedCameraConfig.MergeWorkers
+	merged.streamingMerge = mergedCameraConfig.StreamingMerge
+	if merged.mergeStrategy == "" {
+		merged.mergeStrategy = mergeStrategyRDK
+	}
+	merged.octreeResolution = mergedCameraConfig.OctreeResolution
+	merged.dedupResolution = mergedCameraConfig.DedupResolution
+	merged.maxPoints = mergedCameraConfig.MaxPoints
+	merged.outlierFilter = outlierFilter
+	merged.minClusterSize = mergedCameraConfig.MinClusterSize
+	merged.clusterTolerance = defaultClusterTolerance * mmPerMeter
+	if mergedCameraConfig.ClusterTolerance > 0 {
+		merged.clusterTolerance = mergedCameraConfig.ClusterTolerance * mmPerMeter
+	}
+	merged.groundRemoval = mergedCameraConfig.GroundRemoval
+	merged.randomSeed = mergedCameraConfig.RandomSeed
+	merged.icpRefine = mergedCameraConfig.ICPRefine
+	merged.minConfidence = uint16(mergedCameraConfig.MinConfidence)
+	merged.perCameraTimeout = perCameraTimeout
+	merged.retries = mergedCameraConfig.Retries
+	merged.fsRetries = mergedCameraConfig.FrameSystemRetries
+	merged.breaker = breaker
+	merged.syncWindow = syncWindow
+	merged.ownCameras = mergedCameraConfig.OwnCameras
+	merged.keepInvalid = mergedCameraConfig.KeepInvalidPoints
+	merged.minRange = mergedCameraConfig.MinRange * mmPerMeter
+	merged.maxRange = mergedCameraConfig.MaxRange * mmPerMeter
+	merged.tagSource = mergedCameraConfig.TagSource
+	merged.colorMode = mergedCameraConfig.ColorMode
+	merged.uniformSchema = mergedCameraConfig.UniformSchema
+	merged.defaultValue = mergedCameraConfig.DefaultValue
+	merged.defaultColor = nil
+	if mergedCameraConfig.DefaultColor != nil {
+		defaultColor := mergedCameraConfig.DefaultColor.nrgba()
+		merged.defaultColor = &defaultColor
+	}
+	merged.scales = nil
+	if len(mergedCameraConfig.Scale) > 0 {
+		merged.scales = make([]float64, len(cameraNames))
+		for i, cameraName := range cameraNames {
+			merged.scales[i] = 1
+			if scale, ok := mergedCameraConfig.Scale[cameraName]; ok {
+				merged.scales[i] = scale
+			}
+		}
+	}
+	merged.colorCorrections = nil
+	if len(mergedCameraConfig.ColorCorrection) > 0 {
+		merged.colorCorrections = make([]*colorCorrection, len(cameraNames))
+		for i, cameraName := range cameraNames {
+			if correction, ok := mergedCameraConfig.ColorCorrection[cameraName]; ok {
+				merged.colorCorrections[i] = correction.correction()
+			}
+		}
+	}
+	merged.axisRemaps = nil
+	if len(mergedCameraConfig.AxisRemap) > 0 {
+		merged.axisRemaps = make([]*axisRemap, len(cameraNames))
+		for i, cameraName := range cameraNames {
+			if s, ok := mergedCameraConfig.AxisRemap[cameraName]; ok {
+				remap, err := parseAxisRemap(s)
+				if err != nil {
+					return errors.Wrapf(err, "invalid axis_remap of camera %v", cameraName)
+				}
+				merged.axisRemaps[i] = &remap
+			}
+		}
+	}
+	merged.exclusionBoxes = nil
+	if len(mergedCameraConfig.ExclusionBoxes) > 0 {
+		merged.exclusionBoxes = make([][]*BoxConfig, len(cameraNames))
+		for i, cameraName := range cameraNames {
+			merged.exclusionBoxes[i] = mergedCameraConfig.ExclusionBoxes[cameraName]
+		}
+	}
+	merged.latencyOffsets = nil
+	if len(mergedCameraConfig.LatencyOffset) > 0 {
+		merged.latencyOffsets = make([]time.Duration, len(cameraNames))
+		for i, cameraName := range cameraNames {
+			if offset, ok := mergedCameraConfig.LatencyOffset[cameraName]; ok {
+				merged.latencyOffsets[i], err = time.ParseDuration(offset)
+				if err != nil {
+					return errors.Wrapf(err, "invalid latency_offset for camera %v", cameraName)
+				}
+			}
+		}
+	}
+	merged.sourceVoxelSizes = nil
+	if len(mergedCameraConfig.SourceVoxelSize) > 0 {
+		merged.sourceVoxelSizes = make([]float64, len(cameraNames))
+		for i, cameraName := range cameraNames {
+			merged.sourceVoxelSizes[i] = mergedCameraConfig.SourceVoxelSize[cameraName] * mmPerMeter
+		}
+	}
+	merged.priorities = nil
+	if len(mergedCameraConfig.Priority) > 0 {
+		merged.priorities = make([]int, len(cameraNames))
+		for i, cameraName := range cameraNames {
+			merged.priorities[i] = mergedCameraConfig.Priority[cameraName]
+		}
+	}
+	merged.priorityResolution = mergedCameraConfig.PriorityResolution
+	merged.intrinsics = sharedIntrinsics(cameraProperties)
+	merged.allowDepthProjection = mergedCameraConfig.AllowDepthProjection
+	merged.depthIntrinsics = nil
+	for i, properties := range cameraProperties {
+		if properties.SupportsPCD {
+			continue
+		}
+		if merged.depthIntrinsics == nil {
+			merged.depthIntrinsics = make([]*transform.PinholeCameraIntrinsics, len(cameraProperties))
+		}
+		merged.depthIntrinsics[i] = properties.IntrinsicParams
+		merged.logger.Infof("camera %v does not support point clouds, its depth images will be projected", cameras[i].Name().ShortName())
+	}
+	merged.dynamicFrames = mergedCameraConfig.DynamicFrames
+	merged.logPipeline = mergedCameraConfig.LogPipeline
+	merged.poseOverrides = poseOverrides
+	merged.transformCache = newTransformCache()
+	merged.cameraStatuses = cameraStatuses
+	merged.disabledCameras = nil
+	if merged.metrics == nil {
+		merged.metrics = newMergeMetrics()
+	}
+	if mergedCameraConfig.EnableCache {
+		cacheSize, cacheMaxPoints := mergedCameraConfig.cacheLimits()
+		// clouds cached under the previous config are kept unless the limits changed
+		if merged.cloudCache == nil || merged.cloudCache.maxClouds != cacheSize || merged.cloudCache.maxPoints != cacheMaxPoints {
+			merged.cloudCache = newCloudCache(cacheSize, cacheMaxPoints)
+		}
+	} else {
+		merged.cloudCache = nil
+	}
+	merged.accumulateFrames = mergedCameraConfig.AccumulateFrames
+	merged.accumulateDecay = accumulateDecay
+	merged.projection = mergedCameraConfig.Projection
+	merged.projectionIntrinsics, merged.projectionErr = resolveProjectionIntrinsics(
+		ctx, mergedCameraConfig.Projection, targetFrame, cameras, cameraProperties)
+	if merged.projectionErr != nil && mergedCameraConfig.Projection != nil {
+		merged.logger.Warnf("projection cannot render images: %v", merged.projectionErr)
+	}
+	if mergedCameraConfig.BackgroundRateHz > 0 {
+		merged.startBackgroundMerges(time.Duration(float64(time.Second) / mergedCameraConfig.BackgroundRateHz))
+	}
+	merged.appliedConfig = applied
+	return nil
+}
+
+// reconfigureUnchanged reports whether the config, encoded as applied, is the config last applied by Reconfigure
+// and every camera it names is still the dependency it was resolved to. Such a config is short-circuited: only the
+// frame system service is refreshed and the transform cache cleared, since the frames may have changed without
+// changing the config, and every camera is enabled again like any other reconfigure does. Camera groups, whose
+// cameras depend on the frame system, are always applied again.
+func (merged *mergedCamera) reconfigureUnchanged(deps resource.Dependencies, cfg *Config, applied []byte) (bool, error) {
+	merged.mu.Lock()
+	defer merged.mu.Unlock()
+	if merged.closed || merged.appliedConfig == nil || cfg.CameraGroupFrame != "" ||
+		!bytes.Equal(merged.appliedConfig, applied) {
+		return false, nil
+	}
+	for _, cameraName := range cfg.Cameras {
+		handle, ok := merged.cameraHandles[cameraName]
+		if !ok {
+			return false, nil
+		}
+		if cfg.RemoteCameras[cameraName] != nil {
+			continue
+		}
+		cam, err := camera.FromDependencies(deps, cameraName)
+		if err != nil || cam != handle.cam {
+			return false, nil
+		}
+	}
+	fsService, err := frameSystemFromDependencies(deps)
+	if err != nil {
+		return false, err
+	}
+	merged.fsService = fsService
+	merged.transformCache.clear()
+	merged.stateMu.Lock()
+	merged.disabledCameras = nil
+	merged.stateMu.Unlock()
+	merged.logger.Debug("config is unchanged, skipping reconfigure")
+	return true, nil
+}
+
+// sharedIntrinsics returns the intrinsics of the cameras if every camera reports the same intrinsics and nil otherwise.
+func sharedIntrinsics(cameraProperties []camera.Properties) *transform.PinholeCameraIntrinsics {
+	if len(cameraProperties) == 0 {
+		return nil
+	}
+	intrinsics := cameraProperties[0].IntrinsicParams
+	for _, properties := range cameraProperties {
+		if properties.IntrinsicParams == nil || intrinsics == nil || *properties.IntrinsicParams != *intrinsics {
+			return nil
+		}
+	}
+	return intrinsics
+}
+
+// cameraHandle is a resolved source camera along with the properties it reported when it was resolved.
+type cameraHandle struct {
+	cam        camera.Camera
+	properties camera.Properties
+}
+
+// resolveCamera returns the handle and status of the named camera, which is dialed when remote is set and taken from
+// the dependencies otherwise. A camera whose dependency or connection is unchanged keeps its handle, properties and
+// status.
+func (merged *mergedCamera) resolveCamera(
+	ctx context.Context,
+	deps resource.Dependencies,
+	cameraName string,
+	remote *RemoteCameraConfig,
+	allowDepthProjection bool,
+) (cameraHandle, *cameraStatus, error) {
+	var cam camera.Camera
+	var err error
+	if remote != nil {
+		cam, err = merged.remoteCameraFor(ctx, cameraName, *remote)
+	} else {
+		cam, err = camera.FromDependencies(deps, cameraName)
+	}
+	if err != nil {
+		return cameraHandle{}, nil, errors.Wrapf(err, "error getting camera %v", cameraName)
+	}
+
+	handle, ok := merged.cameraHandles[cameraName]
+	status := merged.cameraStatuses[cam.Name().ShortName()]
+	if ok && handle.cam == cam && status != nil {
+		// the properties are unchanged but allow_depth_projection may not be
+		if err := checkProperties(handle.properties, cameraName, allowDepthProjection); err != nil {
+			return cameraHandle{}, nil, err
+		}
+		merged.logger.Debugf("reusing unchanged camera %v", cameraName)
+		return handle, status, nil
+	}
+	properties, err := checkCamera(ctx, cam, cameraName, allowDepthProjection)
+	if err != nil {
+		return cameraHandle{}, nil, err
+	}
+	return cameraHandle{cam: cam, properties: properties}, &cameraStatus{supportsPCD: properties.SupportsPCD}, nil
+}
+
+// checkCamera returns the properties of the camera, checking that it can be merged.
+func checkCamera(ctx context.Context, cam camera.Camera, cameraName string, allowDepthProjection bool) (camera.Properties, error) {
+	properties, err := cam.Properties(ctx)
+	if err != nil {
+		return camera.Properties{}, errors.Wrapf(err, "error getting camera properties %v", cameraName)
+	}
+
+	if err := checkProperties(properties, cameraName, allowDepthProjection); err != nil {
+		return camera.Properties{}, err
+	}
+	return properties, nil
+}
+
+// checkProperties checks that a camera with the given properties supports point clouds or, when depth projection
+// is allowed, reports the intrinsics needed to project its depth images.
+func checkProperties(properties camera.Properties, cameraName string, allowDepthProjection bool) error {
+	if properties.SupportsPCD {
+		return nil
+	}
+	if !allowDepthProjection {
+		return errors.Errorf("error camera %v does not support PCDs", cameraName)
+	}
+	if properties.IntrinsicParams == nil {
+		return errors.Errorf("camera %v supports neither PCDs nor depth projection since it reports no intrinsics", cameraName)
+	}
+	if err := properties.IntrinsicParams.CheckValid(); err != nil {
+		return errors.Wrapf(err, "camera %v supports neither PCDs nor depth projection since its intrinsics are invalid", cameraName)
+	}
+	return nil
+}
+
+// checkFrameExists returns an error if the given frame is not part of the frame system.
+func (merged *mergedCamera) checkFrameExists(ctx context.Context, frameName string) error {
+	if merged.fsService == nil {
+		return errors.New("frame system service is required to look up frames")
+	}
+	fs, err := merged.fsService.FrameSystem(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "error getting frame system")
+	}
+	if fs.Frame(frameName) == nil {
+		return errors.Errorf("frame %v does not exist in the frame system", frameName)
+	}
+	return nil
+}
+
+// NextPointCloud returns the point clouds of all cameras merged into the target frame. The returned cloud belongs to
+// the caller, who may modify it without affecting the clouds exported or cached by DoCommand. Camera failures under
+// the strict error policy are returned as a *MergeError.
+func (merged *mergedCamera) NextPointCloud(ctx context.Context) (pointcloud.PointCloud, error) {
+	if bg := merged.backgroundMerges(); bg != nil {
+		pc, _, err := bg.latest(ctx)
+		if err != nil {
+			return nil, err
+		}
+		// the background cloud is shared by every caller, so each gets its own copy
+		return copyPointCloud(pc)
+	}
+	pc, _, err := merged.nextMergedPointCloud(ctx)
+	return pc, err
+}
+
+// nextMergedPointCloud merges the point clouds of all cameras and returns the merged cloud together with its
+// capture time, which is the earliest or latest capture time of the merged cameras depending on the config.
+func (merged *mergedCamera) nextMergedPointCloud(ctx context.Context) (pointcloud.PointCloud, time.Time, error) {
+	return merged.mergeCameras(ctx, nil, "")
+}
+
+// mergeCameras merges the point clouds of the cameras whose short names are in subset, or of all cameras when subset
+// is nil, into the given frame, or into the target frame when frame is empty. Only merges of all cameras into the
+// target frame are kept for DoCommand, the cache and the metrics.
+func (merged *mergedCamera) mergeCameras(
+	ctx context.Context, subset map[string]bool, frame string,
+) (pointcloud.PointCloud, time.Time, error) {
+	merged.mu.RLock()
+	defer merged.mu.RUnlock()
+	if merged.closed {
+		return nil, time.Time{}, errors.Wrap(ErrClosed, "cannot get next point cloud")
+	}
+	if frame == "" {
+		frame = merged.targetFrame
+	}
+
+	// a cancelled merge returns the context error itself, rather than the camera or merge errors it causes, so
+	// callers can tell cancellation apart from real failures
+	var results []cameraResult
+	var streamed pointcloud.PointCloud
+	fetchStart := time.Now()
+	if merged.streamingMerge {
+		var err error
+		results, streamed, err = merged.streamPointClouds(ctx, subset, frame)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, time.Time{}, ctxErr
+		}
+		if err != nil {
+			return nil, time.Time{}, errors.Wrap(err, "issue streaming pointclouds into the merged pointcloud")
+		}
+	} else {
+		results = merged.fetchPointClouds(ctx, subset)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, time.Time{}, err
+	}
+	if len(results) == 0 {
+		return nil, time.Time{}, errors.New("every camera is disabled")
+	}
+	if !merged.streamingMerge {
+		merged.warnUnitMismatch(results)
+		merged.resolveTransforms(ctx, results, maxConcurrentFetches, frame)
+		if err := ctx.Err(); err != nil {
+			return nil, time.Time{}, err
+		}
+	}
+	if merged.syncWindow > 0 {
+		checkSync(results, merged.syncWindow)
+	}
+	merged.updateCameraStatuses(results)
+	if merged.icpRefine != nil {
+		merged.refineAlignment(results)
+	}
+	if merged.logPipeline {
+		merged.logCameraResults(results)
+	}
+
+	if merged.errorPolicy != errorPolicySkip {
+		if mergeErr := newMergeError(results); mergeErr != nil {
+			return nil, time.Time{}, mergeErr
+		}
+	}
+	var accepted []cameraResult
+	var skipped []string
+	var capturedAt time.Time
+	for _, result := range results {
+		if result.err != nil {
+			if result.failedStage == stageTransform {
+				merged.logger.Debugf("skipping camera %v after a transform failure: %v", result.name, result.err)
+			} else {
+				merged.logger.Debugf("skipping camera %v after a data failure: %v", result.name, result.err)
+			}
+			skipped = append(skipped, result.name)
+			continue
+		}
+		if capturedAt.IsZero() ||
+			(merged.timestampMode == timestampModeLatest && result.capturedAt.After(capturedAt)) ||
+			(merged.timestampMode != timestampModeLatest && result.capturedAt.Before(capturedAt)) {
+			capturedAt = result.capturedAt
+		}
+		accepted = append(accepted, result)
+	}
+	if len(skipped) > 0 {
+		merged.logger.Warnf("skipped cameras %v when merging point clouds", skipped)
+	}
+	if len(accepted) == 0 {
+		return nil, time.Time{}, errors.Wrapf(ErrNoCameraData, "skipped cameras %v", skipped)
+	}
+	if merged.uniformSchema {
+		if err := merged.fillSchemas(accepted); err != nil {
+			return nil, time.Time{}, err
+		}
+	}
+
+	trace := newPipelineTrace(fetchStart)
+	fetched := 0
+	for _, result := range accepted {
+		fetched += result.pointCount()
+	}
+	trace.record("fetch", fetched)
+
+	mergeStart := time.Now()
+	var mergedPC pointcloud.PointCloud
+	var err error
+	strategy := merged.mergeStrategy
+	if merged.streamingMerge {
+		// the clouds were merged as they were fetched
+		mergedPC, strategy, mergeStart = streamed, "streaming", fetchStart
+	} else if merged.mergeWorkers > 1 {
+		mergedPC, err = mergeInChunks(ctx, accepted, merged.mergeWorkers)
+		strategy = fmt.Sprintf("%v workers", merged.mergeWorkers)
+	} else if merged.mergeStrategy == mergeStrategyAppend {
+		mergedPC, err = appendPointClouds(accepted)
+	} else {
+		// accepted is in config order, as fetchPointClouds places every result at the index of its camera
+		mergedPC, err = merged.mergeWithRDK(ctx, accepted)
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, time.Time{}, ctxErr
+	}
+	if err != nil {
+		return nil, time.Time{}, errors.Wrapf(err, "issue merging pointclouds from cameras %v", describeContributors(accepted))
+	}
+	merged.logger.Debugf("merged point cloud from %v cameras contains %v points", len(accepted), mergedPC.Size())
+	merged.logStep("merged", "cameras", len(accepted), "skipped", skipped, "strategy", strategy,
+		"points", mergedPC.Size(), "merge_ms", milliseconds(time.Since(mergeStart)))
+	trace.record("merge", mergedPC.Size())
+
+	var cropPose spatialmath.Pose
+	cropFrame := merged.cropFrame
+	if cropFrame == "" && (merged.cropBox != nil || merged.groundRemoval != nil) {
+		// the crop box and the ground are configured in the target frame, which has to be placed in any other frame
+		cropFrame = merged.targetFrame
+	}
+	if cropFrame != "" && cropFrame != frame {
+		cropPose, err = merged.transformToTarget(ctx, cropFrame, frame, nil)
+		if err != nil {
+			return nil, time.Time{}, errors.Wrapf(err, "issue getting transform from crop frame %v to target frame %v",
+				cropFrame, frame)
+		}
+		trace.record("crop_frame", mergedPC.Size())
+	}
+
+	if merged.cropBox != nil {
+		mergedPC, err = cropToBox(mergedPC, merged.cropBox, cropPose)
+		if err != nil {
+			return nil, time.Time{}, errors.Wrap(err, "issue cropping merged pointcloud")
+		}
+		merged.logger.Debugf("cropped merged point cloud to %v points", mergedPC.Size())
+		merged.logStep("filtered", "filter", "crop_box", "points", mergedPC.Size())
+		trace.record("crop_box", mergedPC.Size())
+	}
+
+	if merged.priorities != nil {
+		mergedPC, err = preferPriority(mergedPC, merged.priorityResolution*mmPerMeter, merged.priorities)
+		if err != nil {
+			return nil, time.Time{}, errors.Wrap(err, "issue applying camera priority to merged pointcloud")
+		}
+		merged.logger.Debugf("kept %v points of the highest priority cameras in overlapping regions", mergedPC.Size())
+		merged.logStep("filtered", "filter", "priority", "points", mergedPC.Size())
+		trace.record("priority", mergedPC.Size())
+	}
+
+	if merged.dedupResolution > 0 {
+		mergedPC, err = dedupPoints(mergedPC, merged.dedupResolution*mmPerMeter)
+		if err != nil {
+			return nil, time.Time{}, errors.Wrap(err, "issue deduplicating merged pointcloud")
+		}
+		merged.logger.Debugf("deduplicated merged point cloud to %v points", mergedPC.Size())
+		merged.logStep("filtered", "filter", "dedup_resolution", "points", mergedPC.Size())
+		trace.record("dedup_resolution", mergedPC.Size())
+	}
+
+	if merged.outlierFilter != nil {
+		mergedPC, err = merged.outlierFilter(mergedPC)
+		if err != nil {
+			return nil, time.Time{}, errors.Wrap(err, "issue removing outliers from merged pointcloud")
+		}
+		merged.logger.Debugf("removed outliers from merged point cloud leaving %v points", mergedPC.Size())
+		merged.logStep("filtered", "filter", "outlier_removal", "points", mergedPC.Size())
+		trace.record("outlier_removal", mergedPC.Size())
+	}
+
+	if merged.minClusterSize > 0 {
+		mergedPC, err = removeSmallClusters(mergedPC, merged.clusterTolerance, merged.minClusterSize)
+		if err != nil {
+			return nil, time.Time{}, errors.Wrap(err, "issue removing small clusters from merged pointcloud")
+		}
+		merged.logger.Debugf("removed small clusters from merged point cloud leaving %v points", mergedPC.Size())
+		merged.logStep("filtered", "filter", "min_cluster_size", "points", mergedPC.Size())
+		trace.record("min_cluster_size", mergedPC.Size())
+	}
+
+	if merged.voxelSize > 0 {
+		mergedPC, err = voxelDownsample(mergedPC, merged.voxelSize*mmPerMeter)
+		if err != nil {
+			return nil, time.Time{}, errors.Wrap(err, "issue downsampling merged pointcloud")
+		}
+		merged.logger.Debugf("downsampled merged point cloud to %v points", mergedPC.Size())
+		merged.logStep("filtered", "filter", "voxel_size", "points", mergedPC.Size())
+		trace.record("voxel_size", mergedPC.Size())
+	}
+
+	if merged.targetDensity > 0 {
+		mergedPC, err = resampleToDensity(mergedPC, merged.targetDensity)
+		if err != nil {
+			return nil, time.Time{}, errors.Wrap(err, "issue resampling merged pointcloud to the target density")
+		}
+		merged.logger.Debugf("resampled merged point cloud to %v points", mergedPC.Size())
+		merged.logStep("filtered", "filter", "target_density", "points", mergedPC.Size())
+		trace.record("target_density", mergedPC.Size())
+	}
+
+	if merged.groundRemoval != nil {
+		mergedPC, err = removeGround(mergedPC, merged.groundRemoval, cropPose, merged.newRand())
+		if err != nil {
+			return nil, time.Time{}, errors.Wrap(err, "issue removing ground from merged pointcloud")
+		}
+		merged.logger.Debugf("removed ground from merged point cloud leaving %v points", mergedPC.Size())
+		merged.logStep("filtered", "filter", "ground_removal", "points", mergedPC.Size())
+		trace.record("ground_removal", mergedPC.Size())
+	}
+
+	if merged.maxPoints > 0 && mergedPC.Size() > merged.maxPoints {
+		before := mergedPC.Size()
+		mergedPC, err = subsample(mergedPC, merged.maxPoints)
+		if err != nil {
+			return nil, time.Time{}, errors.Wrap(err, "issue subsampling merged pointcloud")
+		}
+		merged.logger.Infof("merged point cloud of %v points exceeds max_points, subsampled to %v points", before, mergedPC.Size())
+		merged.logStep("filtered", "filter", "max_points", "points", mergedPC.Size())
+		trace.record("max_points", mergedPC.Size())
+	}
+
+	if merged.outputOffset != nil {
+		mergedPC, err = transformPointCloud(mergedPC, merged.outputOffset)
+		if err != nil {
+			return nil, time.Time{}, errors.Wrap(err, "issue applying output offset to merged pointcloud")
+		}
+		merged.logStep("filtered", "filter", "output_offset", "points", mergedPC.Size())
+		trace.record("output_offset", mergedPC.Size())
+	}
+
+	if merged.rosFrame {
+		mergedPC, err = remapPointCloud(mergedPC, rosFrameRemap)
+		if err != nil {
+			return nil, time.Time{}, errors.Wrap(err, "issue converting merged pointcloud to the ROS frame")
+		}
+		merged.logStep("filtered", "filter", "ros_frame", "points", mergedPC.Size())
+		trace.record("ros_frame", mergedPC.Size())
+	}
+
+	octreeResolution := merged.octreeResolution * mmPerMeter
+	if merged.outputScale > 0 {
+		mergedPC, err = scalePointCloud(mergedPC, merged.outputScale)
+		if err != nil {
+			return nil, time.Time{}, errors.Wrap(err, "issue converting merged pointcloud to the output units")
+		}
+		merged.logStep("filtered", "filter", "output_units", "points", mergedPC.Size())
+		trace.record("output_units", mergedPC.Size())
+		octreeResolution *= merged.outputScale
+	}
+
+	if merged.outputStructure == outputStructureOctree {
+		mergedPC, err = toOctree(mergedPC, octreeResolution)
+		if err != nil {
+			return nil, time.Time{}, errors.Wrap(err, "issue converting merged pointcloud to an octree")
+		}
+		merged.logStep("filtered", "filter", "output_structure", "points", mergedPC.Size())
+		trace.record("output_structure", mergedPC.Size())
+	}
+
+	if subset != nil || frame != merged.targetFrame {
+		return mergedPC, capturedAt, nil
+	}
+
+	// the returned cloud belongs to the caller, so the cloud kept for DoCommand and the cache is a copy the caller
+	// cannot mutate
+	retained, err := copyPointCloud(mergedPC)
+	if err != nil {
+		return nil, time.Time{}, errors.Wrap(err, "issue copying merged pointcloud")
+	}
+	cached := merged.cloudCache != nil && merged.cloudCache.add(retained, capturedAt)
+	if merged.cloudCache != nil && !cached {
+		merged.logger.Debugf("merged point cloud of %v points exceeds cache_max_points and was not cached", mergedPC.Size())
+	}
+	// the cached clouds are single merges, so accumulating them never accumulates a cloud twice; a merge that was
+	// not cached is returned on its own
+	if merged.accumulateFrames > 1 && cached {
+		var since time.Time
+		if merged.accumulateDecay > 0 {
+			since = capturedAt.Add(-merged.accumulateDecay)
+		}
+		frames := merged.cloudCache.recent(merged.accumulateFrames, since, capturedAt)
+		mergedPC, err = accumulateClouds(frames)
+		if err == nil && merged.outputStructure == outputStructureOctree {
+			mergedPC, err = toOctree(mergedPC, octreeResolution)
+		}
+		if err != nil {
+			return nil, time.Time{}, errors.Wrap(err, "issue accumulating merged pointclouds")
+		}
+		merged.logger.Debugf("accumulated %v merged point clouds into %v points", len(frames), mergedPC.Size())
+		merged.logStep("filtered", "filter", "accumulate_frames", "frames", len(frames), "points", mergedPC.Size())
+		trace.record("accumulate_frames", mergedPC.Size())
+		retained, err = copyPointCloud(mergedPC)
+		if err != nil {
+			return nil, time.Time{}, errors.Wrap(err, "issue copying merged pointcloud")
+		}
+	}
+	merged.stateMu.Lock()
+	defer merged.stateMu.Unlock()
+	// concurrent merges can finish out of order, so an older capture never replaces a newer one
+	if !capturedAt.Before(merged.lastCapturedAt) {
+		merged.lastCapturedAt = capturedAt
+		merged.lastPointCloud = retained
+		trace.finish(results, capturedAt)
+		merged.lastTrace = trace
+	}
+	if merged.metrics != nil {
+		merged.metrics.record(results, time.Since(mergeStart), mergedPC)
+	}
+	merged.logStep("finished", "points", mergedPC.Size(), "captured_at", formatTime(capturedAt))
+	return mergedPC, capturedAt, err
+}
+
+// logStep logs a step of the merge pipeline with the given structured fields when log_pipeline is set.
+func (merged *mergedCamera) logStep(step string, keysAndValues ...interface{}) {
+	if merged.logPipeline {
+		merged.logger.Infow("merge pipeline "+step, keysAndValues...)
+	}
+}
+
+// logCameraResults logs the point cloud and transform of every camera as a step of the merge pipeline.
+func (merged *mergedCamera) logCameraResults(results []cameraResult) {
+	for _, result := range results {
+		fields := []interface{}{"camera", result.name, "fetch_ms", milliseconds(result.fetchDuration)}
+		if result.pc != nil || result.err == nil {
+			fields = append(fields, "points", result.pointCount())
+		}
+		if result.pose != nil {
+			fields = append(fields,
+				"transform_ms", milliseconds(result.transformDuration),
+				"translation", vectorMap(result.pose.Point()),
+				"orientation", orientationMap(result.pose.Orientation()))
+		}
+		if result.err != nil {
+			fields = append(fields, "error", result.err.Error())
+		}
+		merged.logStep("fetched", fields...)
+	}
+}
+
+// mergeWithRDK merges the results with pointcloud.MergePointClouds, handing it the clouds in the order of the
+// results. MergePointClouds returns no cloud when every cloud is empty, and panics when its reader waits more than a
+// few milliseconds for the first points, which happens under load such as many concurrent merges. Both cases fall
+// back to appendPointClouds, which produces the same points.
+func (merged *mergedCamera) mergeWithRDK(ctx context.Context, results []cameraResult) (pc pointcloud.PointCloud, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			merged.logger.Debugf("merging point clouds panicked, appending them instead: %v", r)
+			pc, err = appendPointClouds(results)
+		}
+	}()
+	// MergePointClouds is not defined for zero clouds
+	if len(results) == 0 {
+		return nil, ErrNoCameraData
+	}
+
+	cloudAndOffsetFuncs := make([]pointcloud.CloudAndOffsetFunc, 0, len(results))
+	for _, result := range results {
+		resultCopy := result
+		cloudAndOffsetFunc := func(ctx context.Context) (pointcloud.PointCloud, spatialmath.Pose, error) {
+			return resultCopy.pc, resultCopy.pose, nil
+		}
+		cloudAndOffsetFuncs = append(cloudAndOffsetFuncs, cloudAndOffsetFunc)
+	}
+	pc, err = pointcloud.MergePointClouds(ctx, cloudAndOffsetFuncs, merged.logger)
+	if err == nil && pc == nil {
+		return appendPointClouds(results)
+	}
+	return pc, err
+}
+
+// describeContributors lists the cameras of the results along with the size of their point clouds.
+func describeContributors(results []cameraResult) string {
+	contributors := make([]string, 0, len(results))
+	for _, result := range results {
+		contributors = append(contributors, fmt.Sprintf("%v (%v points)", result.name, result.pointCount()))
+	}
+	return strings.Join(contributors, ", ")
+}
+
+// cameraResult holds the point cloud retrieved from a single camera along with the pose needed to
+// express it in the merged frame.
+type cameraResult struct {
+	name              string
+	pc                pointcloud.PointCloud
+	capturedAt        time.Time
+	fetchDuration     time.Duration
+	pose              spatialmath.Pose
+	transformDuration time.Duration
+	err               error
+	// failedStage is set when err comes from resolving the transform or from the sync check rather than from
+	// fetching the point cloud.
+	failedStage string
+	// streamedPoints is the size of pc, which a streaming merge releases once its points are merged.
+	streamedPoints int
+	// breakerOpen is set when the camera was skipped by its open circuit breaker rather than fetched.
+	breakerOpen bool
+}
+
+// stage returns the stage at which the camera failed.
+func (result *cameraResult) stage() string {
+	if result.failedStage == "" {
+		return stageFetch
+	}
+	return result.failedStage
+}
+
+// pointCount returns the number of points the camera returned, zero if it returned no point cloud.
+func (result *cameraResult) pointCount() int {
+	if result.pc == nil {
+		return result.streamedPoints
+	}
+	return result.pc.Size()
+}
+
+// warnUnitMismatch logs a warning for every camera whose point cloud extent differs from the median extent by more
+// than unitMismatchRatio, which usually means the camera reports different units than the others.
+func (merged *mergedCamera) warnUnitMismatch(results []cameraResult) {
+	extents := make(map[string]float64)
+	var sorted []float64
+	for _, result := range results {
+		if result.err != nil || result.pc.Size() < 2 {
+			continue
+		}
+		meta := result.pc.MetaData()
+		extent := r3.Vector{X: meta.MaxX - meta.MinX, Y: meta.MaxY - meta.MinY, Z: meta.MaxZ - meta.MinZ}.Norm()
+		if extent == 0 {
+			continue
+		}
+		extents[result.name] = extent
+		sorted = append(sorted, extent)
+	}
+	if len(sorted) < 2 {
+		return
+	}
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+	for _, result := range results {
+		extent, ok := extents[result.name]
+		if !ok {
+			continue
+		}
+		if ratio := extent / median; ratio > unitMismatchRatio || ratio < 1/unitMismatchRatio {
+			merged.logger.Warnf("point cloud of camera %v spans %.4gmm, %.3gx the median of %.4gmm; check its units and scale",
+				result.name, extent, ratio, median)
+		}
+	}
+}
+
+// newRand returns the source of randomness of a single merge, seeded with random_seed if set and the time otherwise.
+func (merged *mergedCamera) newRand() *rand.Rand {
+	seed := time.Now().UnixNano()
+	if merged.randomSeed != nil {
+		seed = *merged.randomSeed
+	}
+	return rand.New(rand.NewSource(seed))
+}
+
+// fillSchemas gives the points of every result the union of the channels carried by the points of all results.
+func (merged *mergedCamera) fillSchemas(results []cameraResult) error {
+	clouds := make([]pointcloud.PointCloud, len(results))
+	for i, result := range results {
+		clouds[i] = result.pc
+	}
+	schema := unionSchema(clouds)
+	for i := range results {
+		filled, err := fillSchema(results[i].pc, schema, merged.defaultValue)
+		if err != nil {
+			return errors.Wrapf(err, "issue filling the data of pointcloud from camera %v", results[i].name)
+		}
+		results[i].pc = filled
+	}
+	return nil
+}
+
+// checkSync marks the cameras captured more than the window before the latest successful camera as failed.
+func checkSync(results []cameraResult, window time.Duration) {
+	var latest time.Time
+	for _, result := range results {
+		if result.err == nil && result.capturedAt.After(latest) {
+			latest = result.capturedAt
+		}
+	}
+	for i := range results {
+		if results[i].err != nil {
+			continue
+		}
+		if behind := latest.Sub(results[i].capturedAt); behind > window {
+			results[i].err = errors.Errorf("camera %v was captured %v before the latest camera, outside the sync_window of %v",
+				results[i].name, behind, window)
+			results[i].failedStage = stageSync
+		}
+	}
+}
+
+// updateCameraStatuses records the outcome of the latest fetch for each camera.
+func (merged *mergedCamera) updateCameraStatuses(results []cameraResult) {
+	merged.stateMu.Lock()
+	defer merged.stateMu.Unlock()
+	now := time.Now()
+	for _, result := range results {
+		s, ok := merged.cameraStatuses[result.name]
+		if !ok || result.breakerOpen {
+			continue
+		}
+		if merged.breaker != nil {
+			opened, closed := merged.breaker.record(s, result.err != nil && result.stage() == stageFetch, now)
+			if opened {
+				merged.logger.Warnf("camera %v failed %v times in a row, skipping it for %v", result.name,
+					s.consecutiveFailures, merged.breaker.cooldown)
+			} else if closed {
+				merged.logger.Infof("camera %v is responding again", result.name)
+			}
+		}
+		s.lastError = result.err
+		if result.err == nil {
+			s.lastCapturedAt = result.capturedAt
+		}
+		s.lastPointCount = result.pointCount()
+		if result.err != nil {
+			continue
+		}
+		if s.lastPointCount > 0 {
+			if s.consecutiveEmpty >= emptyCloudWarnThreshold {
+				merged.logger.Infof("camera %v returned points again after %v empty point clouds", result.name, s.consecutiveEmpty)
+			}
+			s.consecutiveEmpty = 0
+			continue
+		}
+		s.consecutiveEmpty++
+		if s.consecutiveEmpty == emptyCloudWarnThreshold {
+			merged.logger.Warnf("camera %v has returned %v empty point clouds in a row; check its configuration",
+				result.name, s.consecutiveEmpty)
+		}
+	}
+}
+
+// fetchPointClouds concurrently retrieves the point cloud of every enabled camera, limited to the short names in
+// subset unless it is nil. Results are returned in the same order as merged.cameras, without the cameras left out.
+func (merged *mergedCamera) fetchPointClouds(ctx context.Context, subset map[string]bool) []cameraResult {
+	enabled := merged.enabledCameras(subset)
+	results := make([]cameraResult, len(enabled))
+	sem := make(chan struct{}, maxConcurrentFetches)
+
+	var wg sync.WaitGroup
+	for next, i := range enabled {
+		wg.Add(1)
+		go func(i, next int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[next] = merged.fetchPointCloud(ctx, i, merged.cameras[i])
+		}(i, next)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// enabledCameras returns the indices in merged.cameras of the cameras that are not disabled, limited to the short
+// names in subset unless it is nil.
+func (merged *mergedCamera) enabledCameras(subset map[string]bool) []int {
+	merged.stateMu.Lock()
+	defer merged.stateMu.Unlock()
+	var enabled []int
+	for i, cam := range merged.cameras {
+		name := cam.Name().ShortName()
+		if !merged.disabledCameras[name] && (subset == nil || subset[name]) {
+			enabled = append(enabled, i)
+		}
+	}
+	return enabled
+}
+
+// streamPointClouds retrieves the point clouds of the enabled cameras one at a time in config order like
+// fetchPointClouds, transforming each into the returned merged cloud and releasing it before fetching the next.
+// Failed cameras are left out of the merged cloud and, unless the error policy skips them, end the merge early. With
+// dynamic frames, every transform is resolved against a single snapshot of the frame system taken when the first
+// camera needs it.
+func (merged *mergedCamera) streamPointClouds(
+	ctx context.Context, subset map[string]bool, frame string,
+) ([]cameraResult, pointcloud.PointCloud, error) {
+	enabled := merged.enabledCameras(subset)
+	results := make([]cameraResult, 0, len(enabled))
+	streamed := pointcloud.New()
+	var snapshot *frameSnapshot
+	var snapshotErr error
+	snapshotted := false
+	for _, i := range enabled {
+		if ctx.Err() != nil {
+			break
+		}
+		result := []cameraResult{merged.fetchPointCloud(ctx, i, merged.cameras[i])}
+		if merged.dynamicFrames && !snapshotted && merged.needsFrameSystem(result) {
+			snapshotted = true
+			snapshot, snapshotErr = merged.snapshotFrames(ctx)
+		}
+		if snapshotErr != nil {
+			merged.failSnapshotTransforms(result, snapshotErr, frame)
+		}
+		merged.resolveTransformsWith(ctx, result, 1, snapshot, frame)
+
+		if result[0].err == nil {
+			if err := appendTransformed(streamed, result[0].pc, result[0].pose); err != nil {
+				return nil, nil, errors.Wrapf(err, "issue merging pointcloud from camera %v", result[0].name)
+			}
+			result[0].streamedPoints = result[0].pc.Size()
+			result[0].pc = nil
+		}
+		results = append(results, result[0])
+		if result[0].err != nil && merged.errorPolicy != errorPolicySkip {
+			break
+		}
+	}
+	return results, streamed, nil
+}
+
+// fetchPointCloud retrieves the point cloud of a single camera. The index is the position of the camera in the
+// config.
+func (merged *mergedCamera) fetchPointCloud(ctx context.Context, index int, cam camera.Camera) cameraResult {
+	name := cam.Name().ShortName()
+	if until := merged.breakerOpenFor(name); !until.IsZero() {
+		return cameraResult{
+			name:        name,
+			err:         errors.Errorf("camera %v is skipped by its circuit breaker until %v", name, until.Format(timeFormat)),
+			breakerOpen: true,
+		}
+	}
+	start := time.Now()
+	var depthIntrinsics *transform.PinholeCameraIntrinsics
+	if merged.depthIntrinsics != nil {
+		depthIntrinsics = merged.depthIntrinsics[index]
+	}
+	pc, err := merged.nextPointCloud(ctx, cam, depthIntrinsics)
+	receivedAt := time.Now()
+	capturedAt := receivedAt
+	if merged.latencyOffsets != nil {
+		capturedAt = receivedAt.Add(-merged.latencyOffsets[index])
+	}
+	if err != nil {
+		merged.logger.Debugf("camera %v failed to return a point cloud: %v", name, err)
+		return cameraResult{name: name, err: errors.Wrapf(err, "error getting point cloud from camera %v", name)}
+	}
+	if pc == nil {
+		merged.logger.Debugf("camera %v returned a nil point cloud", name)
+		return cameraResult{name: name, err: errors.Errorf("camera %v returned no point cloud", name)}
+	}
+	merged.logger.Debugf("camera %v returned a point cloud with %v points", name, pc.Size())
+
+	if !merged.keepInvalid {
+		var dropped int
+		pc, dropped, err = dropInvalidPoints(pc)
+		if err != nil {
+			return cameraResult{name: name, err: errors.Wrapf(err, "error dropping invalid points from camera %v", name)}
+		}
+		if dropped > 0 {
+			merged.logger.Debugf("dropped %v points with non-finite coordinates from camera %v", dropped, name)
+		}
+	}
+	if merged.scales != nil && merged.scales[index] != 1 {
+		pc, err = scalePointCloud(pc, merged.scales[index])
+		if err != nil {
+			return cameraResult{name: name, err: errors.Wrapf(err, "error scaling point cloud from camera %v", name)}
+		}
+	}
+
+	if merged.axisRemaps != nil && merged.axisRemaps[index] != nil {
+		pc, err = remapPointCloud(pc, *merged.axisRemaps[index])
+		if err != nil {
+			return cameraResult{name: name, err: errors.Wrapf(err, "error remapping axes of point cloud from camera %v", name)}
+		}
+	}
+
+	// exclusion boxes and range filtering apply in the camera's own frame, before the cloud is transformed
+	if merged.exclusionBoxes != nil && len(merged.exclusionBoxes[index]) > 0 {
+		pc, err = excludeBoxes(pc, merged.exclusionBoxes[index])
+		if err != nil {
+			return cameraResult{name: name, err: errors.Wrapf(err, "error excluding boxes from point cloud from camera %v", name)}
+		}
+	}
+
+	if merged.minRange > 0 || merged.maxRange > 0 {
+		pc, err = filterRange(pc, merged.minRange, merged.maxRange)
+		if err != nil {
+			return cameraResult{name: name, err: errors.Wrapf(err, "error filtering point cloud from camera %v by range", name)}
+		}
+	}
+
+	if merged.minConfidence > 0 {
+		pc, err = filterConfidence(pc, merged.minConfidence)
+		if err != nil {
+			return cameraResult{name: name, err: errors.Wrapf(err, "error filtering point cloud from camera %v by confidence", name)}
+		}
+	}
+
+	if merged.sourceVoxelSizes != nil && merged.sourceVoxelSizes[index] > 0 {
+		pc, err = voxelDownsample(pc, merged.sourceVoxelSizes[index])
+		if err != nil {
+			return cameraResult{name: name, err: errors.Wrapf(err, "error downsampling point cloud from camera %v", name)}
+		}
+	}
+
+	if merged.colorCorrections != nil && merged.colorCorrections[index] != nil {
+		pc, err = correctColor(pc, merged.colorCorrections[index])
+		if err != nil {
+			return cameraResult{name: name, err: errors.Wrapf(err, "error correcting color of point cloud from camera %v", name)}
+		}
+	}
+
+	pc, err = normalizeColor(pc, merged.colorMode, merged.defaultColor)
+	if err != nil {
+		return cameraResult{name: name, err: errors.Wrapf(err, "error normalizing color of point cloud from camera %v", name)}
+	}
+
+	if merged.tagSource {
+		pc, err = tagSource(pc, index)
+		if err != nil {
+			return cameraResult{name: name, err: errors.Wrapf(err, "error tagging point cloud from camera %v", name)}
+		}
+	}
+
+	return cameraResult{name: name, pc: pc, capturedAt: capturedAt, fetchDuration: receivedAt.Sub(start)}
+}
+
+// resolveTransforms concurrently resolves the transform to the target frame of every camera that returned a point
+// cloud, target being the frame of the merge, running at most concurrency lookups at once. Cameras whose transform
+// cannot be resolved are marked as failed. With dynamic frames, every transform is resolved against a single snapshot
+// of the frame system.
+func (merged *mergedCamera) resolveTransforms(ctx context.Context, results []cameraResult, concurrency int, target string) {
+	var snapshot *frameSnapshot
+	if merged.dynamicFrames && merged.needsFrameSystem(results) {
+		var err error
+		snapshot, err = merged.snapshotFrames(ctx)
+		if err != nil {
+			merged.failSnapshotTransforms(results, err, target)
+		}
+	}
+	merged.resolveTransformsWith(ctx, results, concurrency, snapshot, target)
+}
+
+// failSnapshotTransforms marks every camera that returned a point cloud and needs the frame system as failed after
+// snapshotting the frame system failed.
+func (merged *mergedCamera) failSnapshotTransforms(results []cameraResult, err error, target string) {
+	merged.logger.Debugf("failed to snapshot the frame system: %v", err)
+	for i := range results {
+		if _, overridden := merged.poseOverrides[results[i].name]; results[i].err == nil && !overridden {
+			results[i].err = errors.Wrapf(err, "issue getting tranform from camera %v to target frame %v",
+				results[i].name, target)
+			results[i].failedStage = stageTransform
+		}
+	}
+}
+
+// resolveTransformsWith resolves the transforms like resolveTransforms, against the given snapshot unless it is nil.
+func (merged *mergedCamera) resolveTransformsWith(
+	ctx context.Context, results []cameraResult, concurrency int, snapshot *frameSnapshot, target string,
+) {
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for i := range results {
+		if results[i].err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(result *cameraResult) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			start := time.Now()
+			pose, err := merged.transformToTarget(ctx, result.name, target, snapshot)
+			result.transformDuration = time.Since(start)
+			if err != nil {
+				merged.logger.Debugf("camera %v failed to resolve its transform: %v", result.name, err)
+				result.err = errors.Wrapf(err, "issue getting tranform from camera %v to target frame %v", result.name, target)
+				result.failedStage = stageTransform
+				return
+			}
+			if err := validatePose(pose); err != nil {
+				merged.logger.Debugf("camera %v resolved a degenerate transform: %v", result.name, err)
+				result.err = errors.Wrapf(err, "invalid transform from camera %v to target frame %v", result.name, target)
+				result.failedStage = stageTransform
+				return
+			}
+			result.pose = pose
+		}(&results[i])
+	}
+	wg.Wait()
+}
+
+// nextPointCloud requests a point cloud from the camera, retrying failed requests with exponential backoff. It gives
+// up once the per camera timeout elapses or the context is cancelled, even if the camera does not respect the
+// context itself. The per camera timeout bounds all attempts together. Cameras with depth intrinsics have their point
+// clouds projected from their depth images.
+func (merged *mergedCamera) nextPointCloud(
+	ctx context.Context, cam camera.Camera, depthIntrinsics *transform.PinholeCameraIntrinsics,
+) (pointcloud.PointCloud, error) {
+	if merged.perCameraTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, merged.perCameraTimeout)
+		defer cancel()
+	}
+
+	backoff := initialRetryBackoff
+	var pc pointcloud.PointCloud
+	var err error
+	for attempt := 1; ; attempt++ {
+		pc, err = requestPointCloud(ctx, cam, depthIntrinsics)
+		if err == nil || attempt > merged.retries || ctx.Err() != nil {
+			break
+		}
+
+		merged.logger.Debugf("retrying camera %v in %v after attempt %v failed: %v", cam.Name().ShortName(), backoff, attempt, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			err = errors.Wrapf(ctx.Err(), "gave up retrying after %v attempts", attempt)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		backoff *= 2
+	}
+
+	if err != nil && merged.perCameraTimeout > 0 && errors.Is(err, context.DeadlineExceeded) {
+		return nil, errors.Wrapf(err, "timed out after %v", merged.perCameraTimeout)
+	}
+	return pc, err
+}
+
+// requestPointCloud makes a single point cloud request, returning as soon as the context is done.
+func requestPointCloud(
+	ctx context.Context, cam camera.Camera, depthIntrinsics *transform.PinholeCameraIntrinsics,
+) (pointcloud.PointCloud, error) {
+	type response struct {
+		pc  pointcloud.PointCloud
+		err error
+	}
+	responses := make(chan response, 1)
+	go func() {
+		var pc pointcloud.PointCloud
+		var err error
+		if depthIntrinsics != nil {
+			pc, err = projectDepthImages(ctx, cam, depthIntrinsics)
+		} else {
+			pc, err = cam.NextPointCloud(ctx)
+		}
+		responses <- response{pc: pc, err: err}
+	}()
+
+	select {
+	case resp := <-responses:
+		return resp.pc, resp.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// needsFrameSystem reports whether any camera that returned a point cloud has no pose override.
+func (merged *mergedCamera) needsFrameSystem(results []cameraResult) bool {
+	for _, result := range results {
+		if _, overridden := merged.poseOverrides[result.name]; result.err == nil && !overridden {
+			return true
+		}
+	}
+	return false
+}
+
+// frameSnapshot is the frame system together with the inputs of its components as read at one moment.
+type frameSnapshot struct {
+	fs     referenceframe.FrameSystem
+	inputs map[string][]referenceframe.Input
+}
+
+// retryFrameSystem calls the frame system through fn, retrying failed calls up to frame_system_retries times with
+// exponential backoff. The action describes the call in logs.
+func (merged *mergedCamera) retryFrameSystem(ctx context.Context, action string, fn func() error) error {
+	backoff := initialRetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt > merged.fsRetries || ctx.Err() != nil {
+			return err
+		}
+		merged.logger.Debugf("retrying %v in %v after attempt %v failed: %v", action, backoff, attempt, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "gave up retrying after %v attempts", attempt)
+		}
+		backoff *= 2
+	}
+}
+
+// snapshotFrames reads the frame system and the current inputs of its components, retrying like retryFrameSystem.
+func (merged *mergedCamera) snapshotFrames(ctx context.Context) (*frameSnapshot, error) {
+	var snapshot *frameSnapshot
+	err := merged.retryFrameSystem(ctx, "snapshotting the frame system", func() error {
+		var err error
+		snapshot, err = merged.readFrames(ctx)
+		return err
+	})
+	return snapshot, err
+}
+
+// readFrames reads the frame system and the current inputs of its components once.
+func (merged *mergedCamera) readFrames(ctx context.Context) (*frameSnapshot, error) {
+	fs, err := merged.fsService.FrameSystem(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "issue getting frame system")
+	}
+	inputs, _, err := merged.fsService.CurrentInputs(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "issue getting frame system inputs")
+	}
+	return &frameSnapshot{fs: fs, inputs: inputs}, nil
+}
+
+// transformToTarget returns the transform from the given camera frame to the target frame, which is the configured
+// target frame or the frame of a single merge. A configured pose override, which is expressed in the configured target
+// frame, is used as is or composed with the pose of the configured target frame in the target. Otherwise the
+// transform is resolved against the snapshot when one is given, and through the frame system, where static transforms
+// to the configured target frame are cached, when it is nil.
+func (merged *mergedCamera) transformToTarget(
+	ctx context.Context, frameName, target string, snapshot *frameSnapshot,
+) (spatialmath.Pose, error) {
+	if pose, ok := merged.poseOverrides[frameName]; ok {
+		if target == merged.targetFrame {
+			return pose, nil
+		}
+		targetPose, err := merged.resolveTransform(ctx, merged.targetFrame, target, snapshot)
+		if err != nil {
+			return nil, err
+		}
+		return spatialmath.Compose(targetPose, pose), nil
+	}
+	return merged.resolveTransform(ctx, frameName, target, snapshot)
+}
+
+// resolveTransform resolves the transform from the given frame to the target frame like transformToTarget, ignoring
+// pose overrides. Frames of a single merge are usually moving objects, so transforms to them are never cached.
+func (merged *mergedCamera) resolveTransform(
+	ctx context.Context, frameName, target string, snapshot *frameSnapshot,
+) (spatialmath.Pose, error) {
+	// The returned pose is the origin of the camera frame expressed in the target frame. Composing it with a
+	// point in the camera frame, as pointcloud.MergePointClouds does, yields that point in the target frame.
+	origin := referenceframe.NewPoseInFrame(frameName, spatialmath.NewZeroPose())
+	if snapshot != nil {
+		transformed, err := snapshot.fs.Transform(snapshot.inputs, origin, target)
+		if err != nil {
+			return nil, err
+		}
+		transformedPose, ok := transformed.(*referenceframe.PoseInFrame)
+		if !ok {
+			return nil, errors.Errorf("frame system transformed the pose of camera %v into a %T", frameName, transformed)
+		}
+		return transformedPose.Pose(), nil
+	}
+
+	useCache := !merged.dynamicFrames && merged.transformCache != nil && target == merged.targetFrame
+	if useCache {
+		if pose, ok := merged.transformCache.get(frameName, merged.targetFrame); ok {
+			return pose, nil
+		}
+	}
+
+	var transformedPose *referenceframe.PoseInFrame
+	err := merged.retryFrameSystem(ctx, "transforming the pose of camera "+frameName, func() error {
+		var err error
+		transformedPose, err = merged.fsService.TransformPose(ctx, origin, target, nil)
+		return err
+	})
+	if err != nil {
+		if useCache {
+			if pose, ok := merged.transformCache.lastKnownPose(frameName, merged.targetFrame); ok {
+				merged.logger.Warnf("using the last known transform of camera %v since the frame system failed: %v", frameName, err)
+				return pose, nil
+			}
+		}
+		return nil, err
+	}
+
+	if useCache {
+		merged.transformCache.set(frameName, merged.targetFrame, transformedPose.Pose())
+	}
+	return transformedPose.Pose(), nil
+}
+
+// Properties is a part of the camera interface and returns the camera.Properties struct with SupportsPCD set to true.
+// Point clouds are expressed in the target frame. When Images can render the merged cloud, a depth stream is
+// reported along with the intrinsics of the projection, which Projector uses too and which are nil for a top-down
+// projection. Otherwise no image type is reported, and intrinsics only when every source camera shares the same
+// intrinsics.
+func (merged *mergedCamera) Properties(ctx context.Context) (camera.Properties, error) {
+	merged.mu.RLock()
+	defer merged.mu.RUnlock()
+
+	props := camera.Properties{
+		SupportsPCD:     true,
+		ImageType:       camera.UnspecifiedStream,
+		IntrinsicParams: merged.intrinsics,
+	}
+	if settings, err := merged.renderSettings(); err == nil {
+		props.ImageType = camera.DepthStream
+		props.IntrinsicParams = settings.intrinsics
+	}
+	return props, nil
 }
@@ -3,128 +3,2854 @@ package main
 
 import (
 	"context"
-	"fmt"
+	"image/color"
+	"math"
+	"math/rand"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
 	"go.viam.com/rdk/components/camera"
 	"go.viam.com/rdk/logging"
 	"go.viam.com/rdk/pointcloud"
 	"go.viam.com/rdk/referenceframe"
 	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage/transform"
 	"go.viam.com/rdk/robot/framesystem"
 	"go.viam.com/rdk/spatialmath"
 	"go.viam.com/rdk/testutils/inject"
 	"go.viam.com/test"
 )
 
-func createMockCamera(name string, points []r3.Vector) camera.Camera {
-	pc := pointcloud.New()
-	for _, pt := range points {
-		pc.Set(pt, pointcloud.NewBasicData())
+func TestMergedCamera(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	camName1 := "cam1"
+	points1 := []r3.Vector{{X: 0, Y: 1, Z: 2}}
+	cam1 := createMockCamera(camName1, points1)
+
+	camName2 := "cam2"
+	points2 := []r3.Vector{{X: 0, Y: 0, Z: 2}}
+	cam2 := createMockCamera(camName2, points2)
+
+	allPoints := append(points1, points2...)
+	cameras := []camera.Camera{cam1, cam2}
+
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+
+	// Create merged camera struct
+	mergedCam := mergedCamera{
+		cameras:     cameras,
+		fsService:   fsService,
+		targetFrame: camName1,
+		logger:      logger,
 	}
+	pc, err := mergedCam.NextPointCloud(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pc.Size(), test.ShouldEqual, 2)
+
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		found := false
+		for _, pt := range allPoints {
+			if p == pt {
+				found = true
+			}
+		}
+		test.That(t, found, test.ShouldBeTrue)
+		return true
+	})
+}
+
+func TestReconfigureTargetFrame(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	cam1 := createMockCamera("cam1", []r3.Vector{{X: 0, Y: 1, Z: 2}})
+	cam2 := createMockCamera("cam2", []r3.Vector{{X: 0, Y: 0, Z: 2}})
+	cameras := []camera.Camera{cam1, cam2}
+
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+	deps := createDependencies(cameras, fsService)
+
+	mergedCam := &mergedCamera{logger: logger}
+
+	t.Run("defaults to the first camera", func(t *testing.T) {
+		conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+		test.That(t, mergedCam.targetFrame, test.ShouldEqual, "cam1")
+	})
+
+	t.Run("uses the configured frame", func(t *testing.T) {
+		conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}, TargetFrame: "world"}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+		test.That(t, mergedCam.targetFrame, test.ShouldEqual, "world")
+
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+	})
+
+	t.Run("errors on an unknown frame", func(t *testing.T) {
+		conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}, TargetFrame: "missing"}}
+		err := mergedCam.Reconfigure(ctx, deps, conf)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "frame missing does not exist in the frame system")
+	})
+}
+
+func TestCropFrame(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	cam1 := createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 0}, {X: 1000, Y: 0, Z: 50}})
+	cam2 := createMockCamera("cam2", []r3.Vector{{X: 0, Y: 0, Z: 0}})
+	cameras := []camera.Camera{cam1, cam2}
+	poses := map[string]spatialmath.Pose{"cam2": spatialmath.NewPoseFromPoint(r3.Vector{X: 1000})}
+	fsService, err := createFrameSystemServiceWithPoses(ctx, cameras, poses, logger)
+	test.That(t, err, test.ShouldBeNil)
+	deps := createDependencies(cameras, fsService)
+	box := &BoxConfig{Min: r3.Vector{X: -100, Y: -100, Z: -100}, Max: r3.Vector{X: 100, Y: 100, Z: 100}}
+
+	mergedCam := &mergedCamera{logger: logger}
+
+	t.Run("crops in the target frame by default", func(t *testing.T) {
+		conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}, CropBox: box}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 1)
+	})
+
+	t.Run("crops in the crop frame", func(t *testing.T) {
+		conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}, CropBox: box, CropFrame: "cam2"}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+		_, ok := pc.At(1000, 0, 50)
+		test.That(t, ok, test.ShouldBeTrue)
+		_, ok = pc.At(1000, 0, 0)
+		test.That(t, ok, test.ShouldBeTrue)
+	})
+
+	t.Run("errors on an unknown crop frame", func(t *testing.T) {
+		conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}, CropBox: box, CropFrame: "missing"}}
+		err := mergedCam.Reconfigure(ctx, deps, conf)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "invalid crop frame missing")
+	})
+
+	t.Run("validate requires something to crop", func(t *testing.T) {
+		_, err := (&Config{Cameras: []string{"cam1", "cam2"}, CropFrame: "cam2"}).Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "crop_frame requires crop_box or ground_removal")
+	})
+}
+
+func TestErrorPolicy(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	goodCam := createMockCamera("cam1", []r3.Vector{{X: 0, Y: 1, Z: 2}})
+	badCam := createFailingCamera("cam2")
+	cameras := []camera.Camera{goodCam, badCam}
+
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+
+	t.Run("strict fails on any camera error", func(t *testing.T) {
+		mergedCam := mergedCamera{
+			cameras:     cameras,
+			fsService:   fsService,
+			targetFrame: "world",
+			errorPolicy: errorPolicyStrict,
+			logger:      logger,
+		}
+		_, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "cam2")
+	})
+
+	t.Run("skip omits failing cameras", func(t *testing.T) {
+		mergedCam := mergedCamera{
+			cameras:     cameras,
+			fsService:   fsService,
+			targetFrame: "world",
+			errorPolicy: errorPolicySkip,
+			logger:      logger,
+		}
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 1)
+	})
+
+	t.Run("skip fails when every camera fails", func(t *testing.T) {
+		mergedCam := mergedCamera{
+			cameras:     []camera.Camera{badCam},
+			fsService:   fsService,
+			targetFrame: "world",
+			errorPolicy: errorPolicySkip,
+			logger:      logger,
+		}
+		_, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+
+	t.Run("validate rejects unknown policies", func(t *testing.T) {
+		cfg := &Config{Cameras: []string{"cam1", "cam2"}, ErrorPolicy: "ignore"}
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("requires cameras", func(t *testing.T) {
+		cfg := &Config{}
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+
+	t.Run("requires at least two cameras", func(t *testing.T) {
+		cfg := &Config{Cameras: []string{"cam1"}}
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "got 1")
+	})
+
+	t.Run("rejects duplicate cameras", func(t *testing.T) {
+		cfg := &Config{Cameras: []string{"cam1", "cam2", "cam1"}}
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "camera cam1 is listed more than once")
+	})
+
+	t.Run("rejects unknown timestamp mode", func(t *testing.T) {
+		cfg := &Config{Cameras: []string{"cam1", "cam2"}, TimestampMode: "median"}
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "timestamp_mode")
+	})
+
+	t.Run("rejects negative max points", func(t *testing.T) {
+		cfg := &Config{Cameras: []string{"cam1", "cam2"}, MaxPoints: -1}
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "max_points")
+	})
+
+	t.Run("priority requires tag source and a resolution", func(t *testing.T) {
+		cfg := &Config{Cameras: []string{"cam1", "cam2"}, Priority: map[string]int{"cam1": 1}, PriorityResolution: 0.01}
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "tag_source")
+
+		cfg.TagSource = true
+		cfg.PriorityResolution = 0
+		_, err = cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "priority_resolution")
+
+		cfg.PriorityResolution = 0.01
+		cfg.Priority = map[string]int{"cam3": 1}
+		_, err = cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "unknown camera cam3")
+	})
+
+	t.Run("rejects unknown output structure", func(t *testing.T) {
+		cfg := &Config{Cameras: []string{"cam1", "cam2"}, OutputStructure: "kdtree"}
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "output_structure")
+
+		cfg = &Config{Cameras: []string{"cam1", "cam2"}, OutputStructure: outputStructureOctree, OctreeResolution: -1}
+		_, err = cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "octree_resolution")
+	})
+
+	t.Run("rejects out of range cache limits", func(t *testing.T) {
+		cfg := &Config{Cameras: []string{"cam1", "cam2"}, EnableCache: true, CacheSize: maxCacheSize + 1}
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "cache_size")
+
+		cfg = &Config{Cameras: []string{"cam1", "cam2"}, EnableCache: true, CacheMaxPoints: -1}
+		_, err = cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "cache_max_points")
+	})
+
+	t.Run("rejects unknown color mode", func(t *testing.T) {
+		cfg := &Config{Cameras: []string{"cam1", "cam2"}, ColorMode: "blend"}
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "color_mode")
+	})
+
+	t.Run("returns cameras and frame system as dependencies", func(t *testing.T) {
+		cfg := &Config{Cameras: []string{"cam1", "cam2"}}
+		deps, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, deps, test.ShouldResemble, []string{"cam1", "cam2", framesystem.InternalServiceName.String()})
+	})
+}
 
-	cam := inject.NewCamera(name)
-	cam.NextPointCloudFunc = func(ctx context.Context) (pointcloud.PointCloud, error) {
-		return pc, nil
+func TestSharedIntrinsics(t *testing.T) {
+	intrinsics := &transform.PinholeCameraIntrinsics{Width: 640, Height: 480, Fx: 400, Fy: 400, Ppx: 320, Ppy: 240}
+	sameIntrinsics := *intrinsics
+	otherIntrinsics := sameIntrinsics
+	otherIntrinsics.Fx = 500
+
+	shared := sharedIntrinsics([]camera.Properties{{IntrinsicParams: intrinsics}, {IntrinsicParams: &sameIntrinsics}})
+	test.That(t, shared, test.ShouldResemble, intrinsics)
+
+	shared = sharedIntrinsics([]camera.Properties{{IntrinsicParams: intrinsics}, {IntrinsicParams: &otherIntrinsics}})
+	test.That(t, shared, test.ShouldBeNil)
+
+	shared = sharedIntrinsics([]camera.Properties{{IntrinsicParams: intrinsics}, {}})
+	test.That(t, shared, test.ShouldBeNil)
+}
+
+func TestTransformCache(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	cam1 := createMockCamera("cam1", []r3.Vector{{X: 0, Y: 1, Z: 2}})
+	cam2 := createMockCamera("cam2", []r3.Vector{{X: 0, Y: 0, Z: 2}})
+	cameras := []camera.Camera{cam1, cam2}
+
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+
+	var transformCalls int32
+	countingService := inject.NewFrameSystemService("counting")
+	countingService.Service = fsService
+	countingService.TransformPoseFunc = func(
+		ctx context.Context,
+		pose *referenceframe.PoseInFrame,
+		dst string,
+		additionalTransforms []*referenceframe.LinkInFrame,
+	) (*referenceframe.PoseInFrame, error) {
+		atomic.AddInt32(&transformCalls, 1)
+		return fsService.TransformPose(ctx, pose, dst, additionalTransforms)
+	}
+	var snapshots int32
+	countingService.CurrentInputsFunc = func(
+		ctx context.Context,
+	) (map[string][]referenceframe.Input, map[string]referenceframe.InputEnabled, error) {
+		atomic.AddInt32(&snapshots, 1)
+		return fsService.CurrentInputs(ctx)
 	}
 
-	return cam
+	for _, tc := range []struct {
+		name              string
+		dynamicFrames     bool
+		expectedCalls     int32
+		expectedSnapshots int32
+	}{
+		{"static frames are resolved once", false, 2, 0},
+		{"dynamic frames are resolved against a snapshot every call", true, 0, 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			atomic.StoreInt32(&transformCalls, 0)
+			atomic.StoreInt32(&snapshots, 0)
+			mergedCam := mergedCamera{
+				cameras:        cameras,
+				fsService:      countingService,
+				targetFrame:    "world",
+				dynamicFrames:  tc.dynamicFrames,
+				transformCache: newTransformCache(),
+				logger:         logger,
+			}
+			for i := 0; i < 2; i++ {
+				_, err := mergedCam.NextPointCloud(ctx)
+				test.That(t, err, test.ShouldBeNil)
+			}
+			test.That(t, atomic.LoadInt32(&transformCalls), test.ShouldEqual, tc.expectedCalls)
+			test.That(t, atomic.LoadInt32(&snapshots), test.ShouldEqual, tc.expectedSnapshots)
+		})
+	}
 }
 
-// createCameraLink instantiates the camera to base link for the frame system.
-func createCameraLink(camName, baseFrame string) (*referenceframe.LinkInFrame, error) {
-	camPose := spatialmath.NewPoseFromPoint(r3.Vector{X: 0, Y: 0, Z: 0})
-	camSphere, err := spatialmath.NewSphere(camPose, 5, "cam-sphere")
-	if err != nil {
-		return nil, err
+func TestDynamicFrames(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	cam1 := createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 1}})
+	cam2 := createMockCamera("cam2", []r3.Vector{{X: 0, Y: 0, Z: 2}})
+	cameras := []camera.Camera{cam1, cam2}
+
+	// cam2 moves along x between merges
+	var frameSystems []referenceframe.FrameSystem
+	for _, x := range []float64{100, 200} {
+		poses := map[string]spatialmath.Pose{"cam2": spatialmath.NewPoseFromPoint(r3.Vector{X: x})}
+		fsService, err := createFrameSystemServiceWithPoses(ctx, cameras, poses, logger)
+		test.That(t, err, test.ShouldBeNil)
+		fs, err := fsService.FrameSystem(ctx, nil)
+		test.That(t, err, test.ShouldBeNil)
+		frameSystems = append(frameSystems, fs)
+	}
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+	movingService := inject.NewFrameSystemService("moving")
+	movingService.Service = fsService
+	var frameSystemCalls int32
+	movingService.FrameSystemFunc = func(
+		ctx context.Context,
+		additionalTransforms []*referenceframe.LinkInFrame,
+	) (referenceframe.FrameSystem, error) {
+		calls := atomic.AddInt32(&frameSystemCalls, 1)
+		return frameSystems[(calls-1)%int32(len(frameSystems))], nil
 	}
 
-	camLink := referenceframe.NewLinkInFrame(
-		baseFrame,
-		spatialmath.NewZeroPose(),
-		camName,
-		camSphere,
-	)
-	return camLink, nil
+	mergedCam := &mergedCamera{logger: logger}
+	conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}, TargetFrame: "world", DynamicFrames: true}}
+	test.That(t, mergedCam.Reconfigure(ctx, createDependencies(cameras, movingService), conf), test.ShouldBeNil)
+
+	for i, x := range []float64{100, 200, 100} {
+		atomic.StoreInt32(&frameSystemCalls, int32(i%len(frameSystems)))
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+		_, ok := pc.At(0, 0, 1)
+		test.That(t, ok, test.ShouldBeTrue)
+		_, ok = pc.At(x, 0, 2)
+		test.That(t, ok, test.ShouldBeTrue)
+		// both cameras are transformed against a single frame system
+		test.That(t, atomic.LoadInt32(&frameSystemCalls), test.ShouldEqual, int32(i%len(frameSystems))+1)
+	}
 }
 
-// createFrameSystemService will create a basic frame service from the list of parts.
-func createFrameSystemService(
-	ctx context.Context,
-	cameras []camera.Camera,
-	logger logging.Logger,
-) (framesystem.Service, error) {
-	var fsParts []*referenceframe.FrameSystemPart
-	deps := make(resource.Dependencies)
+func TestConcurrentNextPointCloud(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
 
-	// create camera link
-	for _, cam := range cameras {
-		cameraLink, err := createCameraLink(cam.Name().Name, "world")
-		if err != nil {
-			return nil, err
+	// cam1 only returns once every caller is fetching from it, which never happens if merges are serialized
+	const callers = 8
+	var fetching int32
+	allFetching := make(chan struct{})
+	cam1 := createMockCamera("cam1", []r3.Vector{{X: 0, Y: 1, Z: 2}}).(*inject.Camera)
+	next := cam1.NextPointCloudFunc
+	cam1.NextPointCloudFunc = func(ctx context.Context) (pointcloud.PointCloud, error) {
+		if atomic.AddInt32(&fetching, 1) == callers {
+			close(allFetching)
+		}
+		select {
+		case <-allFetching:
+			return next(ctx)
+		case <-time.After(5 * time.Second):
+			return nil, errors.New("merges did not run concurrently")
 		}
-		fsParts = append(fsParts, &referenceframe.FrameSystemPart{FrameConfig: cameraLink})
-		deps[cam.Name()] = cam
 	}
+	cam2 := createMockCamera("cam2", []r3.Vector{{X: 0, Y: 0, Z: 2}})
+	cameras := []camera.Camera{cam1, cam2}
 
-	fsSvc, err := framesystem.New(ctx, deps, logger)
-	if err != nil {
-		return nil, err
-	}
-	conf := resource.Config{
-		ConvertedAttributes: &framesystem.Config{Parts: fsParts},
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+	deps := createDependencies(cameras, fsService)
+
+	mergedCam := &mergedCamera{logger: logger}
+	conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}, EnableCache: true}}
+	test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+
+	var wg sync.WaitGroup
+	errs := make([]error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			pc, err := mergedCam.NextPointCloud(ctx)
+			if err == nil && pc.Size() != 2 {
+				err = errors.Errorf("expected 2 points, got %v", pc.Size())
+			}
+			if err == nil {
+				_, err = mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: statusCommand})
+			}
+			if err == nil {
+				_, err = mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: metricsCommand})
+			}
+			errs[i] = err
+		}(i)
 	}
-	if err := fsSvc.Reconfigure(ctx, deps, conf); err != nil {
-		return nil, err
+	wg.Wait()
+	for _, err := range errs {
+		test.That(t, err, test.ShouldBeNil)
 	}
 
-	return fsSvc, nil
+	resp, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: metricsCommand})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["merge_ms"].(map[string]interface{})["count"], test.ShouldEqual, callers)
+	test.That(t, mergedCam.cloudCache.len(), test.ShouldEqual, callers)
 }
 
-func TestMergedCamera(t *testing.T) {
+func TestAllEmptyClouds(t *testing.T) {
 	ctx := context.Background()
 	logger := logging.NewTestLogger(t)
 
-	camName1 := "cam1"
-	points1 := []r3.Vector{{X: 0, Y: 1, Z: 2}}
-	cam1 := createMockCamera(camName1, points1)
+	cameras := []camera.Camera{createMockCamera("cam1", nil), createMockCamera("cam2", nil)}
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
 
-	camName2 := "cam2"
-	points2 := []r3.Vector{{X: 0, Y: 0, Z: 2}}
-	cam2 := createMockCamera(camName2, points2)
+	for _, strategy := range []string{mergeStrategyRDK, mergeStrategyAppend} {
+		t.Run(strategy, func(t *testing.T) {
+			mergedCam := &mergedCamera{logger: logger}
+			conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}, MergeStrategy: strategy}}
+			test.That(t, mergedCam.Reconfigure(ctx, createDependencies(cameras, fsService), conf), test.ShouldBeNil)
+			pc, err := mergedCam.NextPointCloud(ctx)
+			test.That(t, err, test.ShouldBeNil)
+			test.That(t, pc.Size(), test.ShouldEqual, 0)
+		})
+	}
+}
 
-	allPoints := append(points1, points2...)
+func TestPerCameraTimeout(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	fastCam := createMockCamera("cam1", []r3.Vector{{X: 0, Y: 1, Z: 2}})
+	slowCam := createBlockingCamera("cam2")
+	cameras := []camera.Camera{fastCam, slowCam}
+
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+
+	newCam := func(errorPolicy string, timeout time.Duration) *mergedCamera {
+		return &mergedCamera{
+			cameras:          cameras,
+			fsService:        fsService,
+			targetFrame:      "world",
+			errorPolicy:      errorPolicy,
+			perCameraTimeout: timeout,
+			logger:           logger,
+		}
+	}
+
+	t.Run("strict fails on a timed out camera", func(t *testing.T) {
+		_, err := newCam(errorPolicyStrict, 50*time.Millisecond).NextPointCloud(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "timed out")
+		test.That(t, errors.Is(err, context.DeadlineExceeded), test.ShouldBeTrue)
+	})
+
+	t.Run("skip omits a timed out camera", func(t *testing.T) {
+		pc, err := newCam(errorPolicySkip, 50*time.Millisecond).NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 1)
+	})
+
+	t.Run("parent cancellation aborts the merge", func(t *testing.T) {
+		cancelCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err := newCam(errorPolicyStrict, 0).NextPointCloud(cancelCtx)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, time.Since(start), test.ShouldBeLessThan, time.Second)
+	})
+
+	t.Run("validate rejects bad timeouts", func(t *testing.T) {
+		cfg := &Config{Cameras: []string{"cam1", "cam2"}, PerCameraTimeout: "soon"}
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+}
+
+func TestCancellation(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	var cancel context.CancelFunc
+	cam1 := createMockCamera("cam1", []r3.Vector{{X: 0, Y: 1, Z: 2}}).(*inject.Camera)
+	cam2 := createMockCamera("cam2", []r3.Vector{{X: 0, Y: 0, Z: 2}})
 	cameras := []camera.Camera{cam1, cam2}
 
-	fmt.Println(cameras)
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+	cancellingService := inject.NewFrameSystemService("cancelling")
+	cancellingService.Service = fsService
+
+	// without a transform cache every merge resolves transforms through the frame system
+	mergedCam := mergedCamera{
+		cameras:     cameras,
+		fsService:   cancellingService,
+		targetFrame: "world",
+		logger:      logger,
+	}
+
+	t.Run("during fetch", func(t *testing.T) {
+		next := cam1.NextPointCloudFunc
+		cam1.NextPointCloudFunc = func(ctx context.Context) (pointcloud.PointCloud, error) {
+			cancel()
+			return next(ctx)
+		}
+		defer func() { cam1.NextPointCloudFunc = next }()
+
+		var cancelCtx context.Context
+		cancelCtx, cancel = context.WithCancel(ctx)
+		_, err := mergedCam.NextPointCloud(cancelCtx)
+		test.That(t, err, test.ShouldEqual, context.Canceled)
+	})
+
+	t.Run("during transform resolution", func(t *testing.T) {
+		cancellingService.TransformPoseFunc = func(
+			ctx context.Context,
+			pose *referenceframe.PoseInFrame,
+			dst string,
+			additionalTransforms []*referenceframe.LinkInFrame,
+		) (*referenceframe.PoseInFrame, error) {
+			cancel()
+			return fsService.TransformPose(ctx, pose, dst, additionalTransforms)
+		}
+		defer func() { cancellingService.TransformPoseFunc = nil }()
+
+		var cancelCtx context.Context
+		cancelCtx, cancel = context.WithCancel(ctx)
+		_, err := mergedCam.NextPointCloud(cancelCtx)
+		test.That(t, err, test.ShouldEqual, context.Canceled)
+	})
+}
+
+func TestClose(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	for _, tc := range []struct {
+		name          string
+		ownCameras    bool
+		expectedClose int
+	}{
+		{"shared cameras are left open", false, 0},
+		{"owned cameras are closed", true, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var closeCalls int
+			cam := inject.NewCamera("cam1")
+			cam.CloseFunc = func(ctx context.Context) error {
+				closeCalls++
+				return nil
+			}
+
+			mergedCam := &mergedCamera{
+				cameras:    []camera.Camera{cam},
+				ownCameras: tc.ownCameras,
+				logger:     logger,
+			}
+			test.That(t, mergedCam.Close(ctx), test.ShouldBeNil)
+			test.That(t, mergedCam.Close(ctx), test.ShouldBeNil)
+			test.That(t, closeCalls, test.ShouldEqual, tc.expectedClose)
+			test.That(t, mergedCam.cameras, test.ShouldBeNil)
+
+			_, err := mergedCam.NextPointCloud(ctx)
+			test.That(t, errors.Is(err, ErrClosed), test.ShouldBeTrue)
+			_, _, err = mergedCam.Images(ctx)
+			test.That(t, errors.Is(err, ErrClosed), test.ShouldBeTrue)
+			_, err = mergedCam.Projector(ctx)
+			test.That(t, errors.Is(err, ErrClosed), test.ShouldBeTrue)
+			_, err = mergedCam.Stream(ctx)
+			test.That(t, errors.Is(err, ErrClosed), test.ShouldBeTrue)
+		})
+	}
+}
+
+func TestNilPointCloud(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	goodCam := createMockCamera("cam1", []r3.Vector{{X: 0, Y: 1, Z: 2}})
+	nilCam := createNilCamera("cam2")
+	cameras := []camera.Camera{goodCam, nilCam}
 
 	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+
+	t.Run("strict names the camera", func(t *testing.T) {
+		mergedCam := mergedCamera{cameras: cameras, fsService: fsService, targetFrame: "world", logger: logger}
+		_, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "camera cam2 returned no point cloud")
+	})
+
+	t.Run("skip omits the camera", func(t *testing.T) {
+		mergedCam := mergedCamera{
+			cameras:     cameras,
+			fsService:   fsService,
+			targetFrame: "world",
+			errorPolicy: errorPolicySkip,
+			logger:      logger,
+		}
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 1)
+	})
+}
+
+func TestTransformDirection(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	cam1 := createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 10}})
+	cam2 := createMockCamera("cam2", []r3.Vector{{X: 10, Y: 0, Z: 0}})
+	cameras := []camera.Camera{cam1, cam2}
+
+	// cam2 sits 100mm along the x axis of cam1 and is rotated 90 degrees about z
+	poses := map[string]spatialmath.Pose{
+		"cam2": spatialmath.NewPose(r3.Vector{X: 100}, &spatialmath.OrientationVectorDegrees{OZ: 1, Theta: 90}),
+	}
+	fsService, err := createFrameSystemServiceWithPoses(ctx, cameras, poses, logger)
+	test.That(t, err, test.ShouldBeNil)
 
-	// Create merged camera struct
 	mergedCam := mergedCamera{
-		cameras:   cameras,
-		fsService: fsService,
-		logger:    logger,
+		cameras:     cameras,
+		fsService:   fsService,
+		targetFrame: "cam1",
+		logger:      logger,
 	}
-	fmt.Println("sdsdsaaaaaaa")
 	pc, err := mergedCam.NextPointCloud(ctx)
-	fmt.Println("sdsdsdsdsdsdsdss")
 	test.That(t, err, test.ShouldBeNil)
-	fmt.Println("sdsdsd122211s")
-	fmt.Println(pc)
 	test.That(t, pc.Size(), test.ShouldEqual, 2)
 
-	fmt.Println("sdsdsds")
+	expected := []r3.Vector{{X: 0, Y: 0, Z: 10}, {X: 100, Y: 10, Z: 0}}
 	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
 		found := false
-		for _, pt := range allPoints {
-			if p == pt {
+		for _, pt := range expected {
+			if p.Sub(pt).Norm() < 1e-6 {
 				found = true
 			}
 		}
 		test.That(t, found, test.ShouldBeTrue)
 		return true
 	})
+}
+
+func TestCaptureTimestamp(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	fastCam := createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 1}})
+	slowCam := createDelayedCamera("cam2", []r3.Vector{{X: 0, Y: 0, Z: 2}}, 50*time.Millisecond)
+	cameras := []camera.Camera{fastCam, slowCam}
+
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+	deps := createDependencies(cameras, fsService)
+
+	for _, mode := range []string{"", timestampModeEarliest, timestampModeLatest} {
+		t.Run("mode "+mode, func(t *testing.T) {
+			mergedCam := &mergedCamera{logger: logger}
+			conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}, TimestampMode: mode}}
+			test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+
+			start := time.Now()
+			_, capturedAt, err := mergedCam.nextMergedPointCloud(ctx)
+			test.That(t, err, test.ShouldBeNil)
+
+			fast := mergedCam.cameraStatuses["cam1"].lastCapturedAt
+			slow := mergedCam.cameraStatuses["cam2"].lastCapturedAt
+			test.That(t, fast.Before(slow), test.ShouldBeTrue)
+			test.That(t, slow.Sub(start), test.ShouldBeGreaterThanOrEqualTo, 50*time.Millisecond)
+			if mode == timestampModeLatest {
+				test.That(t, capturedAt, test.ShouldEqual, slow)
+			} else {
+				test.That(t, capturedAt, test.ShouldEqual, fast)
+			}
+			test.That(t, mergedCam.lastCapturedAt, test.ShouldEqual, capturedAt)
+		})
+	}
+}
+
+func BenchmarkResolveTransforms(b *testing.B) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(b)
+
+	var cameras []camera.Camera
+	var names []string
+	for i := 0; i < 8; i++ {
+		name := "cam" + strconv.Itoa(i)
+		cameras = append(cameras, createMockCamera(name, []r3.Vector{{X: 0, Y: 0, Z: 1}}))
+		names = append(names, name)
+	}
+
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	if err != nil {
+		b.Fatal(err)
+	}
+	// simulate the latency of a frame system lookup on a remote part
+	slowService := inject.NewFrameSystemService("slow")
+	slowService.Service = fsService
+	slowService.TransformPoseFunc = func(
+		ctx context.Context,
+		pose *referenceframe.PoseInFrame,
+		dst string,
+		additionalTransforms []*referenceframe.LinkInFrame,
+	) (*referenceframe.PoseInFrame, error) {
+		time.Sleep(time.Millisecond)
+		return fsService.TransformPose(ctx, pose, dst, additionalTransforms)
+	}
+
+	mergedCam := &mergedCamera{logger: logger}
+	conf := resource.Config{ConvertedAttributes: &Config{Cameras: names}}
+	if err := mergedCam.Reconfigure(ctx, createDependencies(cameras, slowService), conf); err != nil {
+		b.Fatal(err)
+	}
+	// without a transform cache every resolution goes through the slow frame system
+	mergedCam.transformCache = nil
+	results := mergedCam.fetchPointClouds(ctx, nil)
+
+	for _, bm := range []struct {
+		name        string
+		concurrency int
+	}{
+		{"serial", 1},
+		{"parallel", maxConcurrentFetches},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				mergedCam.resolveTransforms(ctx, results, bm.concurrency, mergedCam.targetFrame)
+			}
+		})
+	}
+}
+
+func TestReconfigureInvalidCameras(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	goodCam := createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 1}})
+	noPCDCam := inject.NewCamera("cam2")
+	noPCDCam.PropertiesFunc = func(ctx context.Context) (camera.Properties, error) {
+		return camera.Properties{}, nil
+	}
+	cameras := []camera.Camera{goodCam, noPCDCam}
+
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+	deps := createDependencies(cameras, fsService)
+
+	t.Run("reports every invalid camera", func(t *testing.T) {
+		mergedCam := &mergedCamera{logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2", "cam3"}}}
+		err := mergedCam.Reconfigure(ctx, deps, conf)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "2 of 3 cameras are invalid")
+		test.That(t, err.Error(), test.ShouldContainSubstring, "camera cam2 does not support PCDs")
+		test.That(t, err.Error(), test.ShouldContainSubstring, "error getting camera cam3")
+	})
+
+	t.Run("fail fast reports the first invalid camera", func(t *testing.T) {
+		mergedCam := &mergedCamera{logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2", "cam3"}, FailFast: true}}
+		err := mergedCam.Reconfigure(ctx, deps, conf)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "camera cam2 does not support PCDs")
+		test.That(t, err.Error(), test.ShouldNotContainSubstring, "cam3")
+	})
+}
+
+func TestReconfigureShortNameCollision(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	// both dependencies resolve to cameras named remote:cam
+	deps := resource.Dependencies{
+		camera.Named("cam1"): createMockCamera("remote:cam", []r3.Vector{{X: 0, Y: 0, Z: 1}}),
+		camera.Named("cam2"): createMockCamera("remote:cam", []r3.Vector{{X: 0, Y: 0, Z: 2}}),
+	}
+
+	mergedCam := &mergedCamera{logger: logger}
+	conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}}}
+	err := mergedCam.Reconfigure(ctx, deps, conf)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "cameras cam1 and cam2 share the short name remote:cam")
+}
+
+func TestWaitForCameras(t *testing.T) {
+	ctx := context.Background()
+
+	// slowCamera fails its first properties requests as if it were still enumerating
+	slowCamera := func(name string, failures int32) camera.Camera {
+		cam := createMockCamera(name, []r3.Vector{{X: 0, Y: 0, Z: 1}}).(*inject.Camera)
+		var calls int32
+		cam.PropertiesFunc = func(ctx context.Context) (camera.Properties, error) {
+			if atomic.AddInt32(&calls, 1) <= failures {
+				return camera.Properties{}, errors.New("camera not ready")
+			}
+			return camera.Properties{SupportsPCD: true}, nil
+		}
+		return cam
+	}
+
+	t.Run("retries cameras until they are available", func(t *testing.T) {
+		logger, logs := logging.NewObservedTestLogger(t)
+		cameras := []camera.Camera{createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 1}}), slowCamera("cam2", 2)}
+		fsService, err := createFrameSystemService(ctx, cameras, logger)
+		test.That(t, err, test.ShouldBeNil)
+
+		mergedCam := &mergedCamera{logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}, WaitForCameras: "5s"}}
+		test.That(t, mergedCam.Reconfigure(ctx, createDependencies(cameras, fsService), conf), test.ShouldBeNil)
+		test.That(t, len(mergedCam.cameras), test.ShouldEqual, 2)
+		test.That(t, logs.FilterMessageSnippet("waiting up to").FilterMessageSnippet("[cam2]").Len(), test.ShouldEqual, 2)
+	})
+
+	t.Run("gives up once the wait has passed", func(t *testing.T) {
+		logger := logging.NewTestLogger(t)
+		cameras := []camera.Camera{createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 1}}), slowCamera("cam2", 100)}
+		fsService, err := createFrameSystemService(ctx, cameras, logger)
+		test.That(t, err, test.ShouldBeNil)
+
+		mergedCam := &mergedCamera{logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}, WaitForCameras: "300ms"}}
+		start := time.Now()
+		err = mergedCam.Reconfigure(ctx, createDependencies(cameras, fsService), conf)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "1 of 2 cameras are invalid")
+		test.That(t, err.Error(), test.ShouldContainSubstring, "camera not ready")
+		test.That(t, time.Since(start), test.ShouldBeGreaterThanOrEqualTo, 300*time.Millisecond)
+	})
+
+	t.Run("validate rejects bad waits", func(t *testing.T) {
+		for _, wait := range []string{"soon", "-1s", "0s"} {
+			cfg := &Config{Cameras: []string{"cam1", "cam2"}, WaitForCameras: wait}
+			_, err := cfg.Validate("path")
+			test.That(t, err, test.ShouldNotBeNil)
+			test.That(t, err.Error(), test.ShouldContainSubstring, "wait_for_cameras")
+		}
+	})
+}
+
+func TestReconfigureReusesCameras(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	var propertiesCalls int32
+	countingCamera := func(name string) camera.Camera {
+		cam := createMockCamera(name, []r3.Vector{{X: 0, Y: 0, Z: 1}}).(*inject.Camera)
+		cam.PropertiesFunc = func(ctx context.Context) (camera.Properties, error) {
+			atomic.AddInt32(&propertiesCalls, 1)
+			return camera.Properties{SupportsPCD: true}, nil
+		}
+		return cam
+	}
+	cam1 := countingCamera("cam1")
+	cam2 := countingCamera("cam2")
+	cameras := []camera.Camera{cam1, cam2}
+
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+
+	mergedCam := &mergedCamera{logger: logger}
+	conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}}}
+	test.That(t, mergedCam.Reconfigure(ctx, createDependencies(cameras, fsService), conf), test.ShouldBeNil)
+	test.That(t, atomic.LoadInt32(&propertiesCalls), test.ShouldEqual, 2)
+	_, err = mergedCam.NextPointCloud(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	status := mergedCam.cameraStatuses["cam1"]
+
+	t.Run("unchanged cameras are not re-resolved", func(t *testing.T) {
+		conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}, TargetFrame: "cam2"}}
+		test.That(t, mergedCam.Reconfigure(ctx, createDependencies(cameras, fsService), conf), test.ShouldBeNil)
+		test.That(t, atomic.LoadInt32(&propertiesCalls), test.ShouldEqual, 2)
+		test.That(t, mergedCam.targetFrame, test.ShouldEqual, "cam2")
+		test.That(t, mergedCam.cameras[0], test.ShouldEqual, cam1)
+		test.That(t, mergedCam.cameraStatuses["cam1"], test.ShouldEqual, status)
+	})
+
+	t.Run("changed cameras are re-resolved", func(t *testing.T) {
+		newCam2 := countingCamera("cam2")
+		conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}}}
+		deps := createDependencies([]camera.Camera{cam1, newCam2}, fsService)
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+		test.That(t, atomic.LoadInt32(&propertiesCalls), test.ShouldEqual, 3)
+		test.That(t, mergedCam.cameras[1], test.ShouldEqual, newCam2)
+		test.That(t, mergedCam.cameraStatuses["cam1"], test.ShouldEqual, status)
+	})
+}
+
+func TestColorCorrection(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	gray := color.NRGBA{R: 100, G: 100, B: 100, A: 255}
+	cam1 := createColoredCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 1}}, gray)
+	cam2 := createColoredCamera("cam2", []r3.Vector{{X: 0, Y: 0, Z: 2}}, gray)
+	cameras := []camera.Camera{cam1, cam2}
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+
+	// cam2 renders too blue, which its correction undoes
+	mergedCam := newTestMergedCamera(t, &Config{
+		Cameras: []string{"cam1", "cam2"},
+		ColorCorrection: map[string]*ColorCorrectionConfig{
+			"cam2": {Gain: &RGBConfig{R: 1.2, G: 1, B: 0.8}, Offset: &RGBConfig{G: 5}},
+		},
+	}, cameras, fsService)
+	pc, err := mergedCam.NextPointCloud(ctx)
+	test.That(t, err, test.ShouldBeNil)
+
+	d, ok := pc.At(0, 0, 1)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.Color(), test.ShouldResemble, &gray)
+	d, ok = pc.At(0, 0, 2)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.Color(), test.ShouldResemble, &color.NRGBA{R: 120, G: 105, B: 80, A: 255})
+
+	_, err = (&Config{
+		Cameras:         []string{"cam1", "cam2"},
+		ColorCorrection: map[string]*ColorCorrectionConfig{"cam3": {}},
+	}).Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "color_correction given for unknown camera cam3")
+
+	_, err = (&Config{
+		Cameras:         []string{"cam1", "cam2"},
+		ColorCorrection: map[string]*ColorCorrectionConfig{"cam1": {Gain: &RGBConfig{G: -2}}},
+	}).Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "invalid color_correction for camera cam1")
+}
+
+func TestColorMode(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	red := color.NRGBA{R: 255, A: 255}
+	coloredCam := createColoredCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 1}}, red)
+	uncoloredCam := createMockCamera("cam2", []r3.Vector{{X: 0, Y: 0, Z: 2}})
+	cameras := []camera.Camera{coloredCam, uncoloredCam}
+
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+	deps := createDependencies(cameras, fsService)
+
+	merge := func(colorMode string) (pointcloud.PointCloud, error) {
+		mergedCam := &mergedCamera{logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}, ColorMode: colorMode}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+		return mergedCam.NextPointCloud(ctx)
+	}
+
+	t.Run("preserve keeps the color of each camera", func(t *testing.T) {
+		pc, err := merge(colorModePreserve)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+		d, ok := pc.At(0, 0, 1)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, d.HasColor(), test.ShouldBeTrue)
+		d, ok = pc.At(0, 0, 2)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, d.HasColor(), test.ShouldBeFalse)
+	})
+
+	t.Run("strip removes all color", func(t *testing.T) {
+		pc, err := merge(colorModeStrip)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+		test.That(t, pc.MetaData().HasColor, test.ShouldBeFalse)
+	})
+
+	t.Run("require fails the uncolored camera", func(t *testing.T) {
+		_, err := merge(colorModeRequire)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "camera cam2")
+		test.That(t, err.Error(), test.ShouldContainSubstring, "without color")
+	})
+
+	t.Run("default color paints uncolored points", func(t *testing.T) {
+		mergedCam := &mergedCamera{logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{
+			Cameras: []string{"cam1", "cam2"}, DefaultColor: &ColorConfig{R: 0, G: 0, B: 255},
+		}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		d, ok := pc.At(0, 0, 1)
+		test.That(t, ok, test.ShouldBeTrue)
+		r, g, b := d.RGB255()
+		test.That(t, []uint8{r, g, b}, test.ShouldResemble, []uint8{255, 0, 0})
+		d, ok = pc.At(0, 0, 2)
+		test.That(t, ok, test.ShouldBeTrue)
+		r, g, b = d.RGB255()
+		test.That(t, []uint8{r, g, b}, test.ShouldResemble, []uint8{0, 0, 255})
+	})
+
+	t.Run("validate rejects bad default colors", func(t *testing.T) {
+		cfg := &Config{Cameras: []string{"cam1", "cam2"}, DefaultColor: &ColorConfig{R: 300}}
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "invalid default_color")
+
+		cfg = &Config{Cameras: []string{"cam1", "cam2"}, DefaultColor: &ColorConfig{}, ColorMode: colorModeStrip}
+		_, err = cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "default_color requires color_mode")
+	})
+}
+
+func TestRetries(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	// createFlakyCamera returns a camera that fails the given number of requests before succeeding
+	createFlakyCamera := func(name string, failures int32) (camera.Camera, *int32) {
+		var calls int32
+		cam := createMockCamera(name, []r3.Vector{{X: 0, Y: 0, Z: 1}}).(*inject.Camera)
+		next := cam.NextPointCloudFunc
+		cam.NextPointCloudFunc = func(ctx context.Context) (pointcloud.PointCloud, error) {
+			if atomic.AddInt32(&calls, 1) <= failures {
+				return nil, errors.New("transient failure")
+			}
+			return next(ctx)
+		}
+		return cam, &calls
+	}
+
+	newCam := func(cameras []camera.Camera, retries int, timeout time.Duration) *mergedCamera {
+		fsService, err := createFrameSystemService(ctx, cameras, logger)
+		test.That(t, err, test.ShouldBeNil)
+		return &mergedCamera{
+			cameras:          cameras,
+			fsService:        fsService,
+			targetFrame:      "world",
+			errorPolicy:      errorPolicyStrict,
+			retries:          retries,
+			perCameraTimeout: timeout,
+			logger:           logger,
+		}
+	}
+
+	t.Run("succeeds after transient failures", func(t *testing.T) {
+		flakyCam, calls := createFlakyCamera("cam2", 2)
+		cameras := []camera.Camera{createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 2}}), flakyCam}
+		pc, err := newCam(cameras, 2, 0).NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+		test.That(t, atomic.LoadInt32(calls), test.ShouldEqual, 3)
+	})
+
+	t.Run("fails once retries are exhausted", func(t *testing.T) {
+		flakyCam, calls := createFlakyCamera("cam2", 2)
+		cameras := []camera.Camera{createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 2}}), flakyCam}
+		_, err := newCam(cameras, 1, 0).NextPointCloud(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "transient failure")
+		test.That(t, atomic.LoadInt32(calls), test.ShouldEqual, 2)
+	})
+
+	t.Run("per camera timeout bounds all retries", func(t *testing.T) {
+		flakyCam, _ := createFlakyCamera("cam2", 100)
+		cameras := []camera.Camera{createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 2}}), flakyCam}
+		start := time.Now()
+		_, err := newCam(cameras, 100, 120*time.Millisecond).NextPointCloud(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "timed out")
+		test.That(t, errors.Is(err, context.DeadlineExceeded), test.ShouldBeTrue)
+		test.That(t, time.Since(start), test.ShouldBeLessThan, time.Second)
+	})
+
+	t.Run("validate rejects negative retries", func(t *testing.T) {
+		cfg := &Config{Cameras: []string{"cam1", "cam2"}, Retries: -1}
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+}
+
+func TestReconfigureWithoutFrameSystem(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	cameras := []camera.Camera{
+		createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 1}}),
+		createMockCamera("cam2", []r3.Vector{{X: 0, Y: 0, Z: 2}}),
+	}
+	deps := make(resource.Dependencies)
+	for _, cam := range cameras {
+		deps[cam.Name()] = cam
+	}
+
+	mergedCam := &mergedCamera{logger: logger}
+	conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}}}
+	err := mergedCam.Reconfigure(ctx, deps, conf)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "frame system")
+	test.That(t, mergedCam.fsService, test.ShouldBeNil)
+}
+
+func TestSyncWindow(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	fastCam := createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 1}})
+	slowCam := createDelayedCamera("cam2", []r3.Vector{{X: 0, Y: 0, Z: 2}}, 100*time.Millisecond)
+	cameras := []camera.Camera{fastCam, slowCam}
+
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+	deps := createDependencies(cameras, fsService)
+
+	newCam := func(errorPolicy, syncWindow string) *mergedCamera {
+		mergedCam := &mergedCamera{logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{
+			Cameras:     []string{"cam1", "cam2"},
+			ErrorPolicy: errorPolicy,
+			SyncWindow:  syncWindow,
+		}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+		return mergedCam
+	}
+
+	t.Run("strict fails when captures are out of sync", func(t *testing.T) {
+		_, err := newCam(errorPolicyStrict, "20ms").NextPointCloud(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "camera cam1")
+		test.That(t, err.Error(), test.ShouldContainSubstring, "sync_window")
+	})
+
+	t.Run("skip drops the stale camera", func(t *testing.T) {
+		pc, err := newCam(errorPolicySkip, "20ms").NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 1)
+		_, ok := pc.At(0, 0, 2)
+		test.That(t, ok, test.ShouldBeTrue)
+	})
+
+	t.Run("captures within the window are merged", func(t *testing.T) {
+		pc, err := newCam(errorPolicyStrict, "1s").NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+	})
+
+	t.Run("validate rejects bad windows", func(t *testing.T) {
+		cfg := &Config{Cameras: []string{"cam1", "cam2"}, SyncWindow: "-1s"}
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+}
+
+func TestLatencyOffset(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	fastCam := createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 1}})
+	slowCam := createDelayedCamera("cam2", []r3.Vector{{X: 0, Y: 0, Z: 2}}, 100*time.Millisecond)
+	cameras := []camera.Camera{fastCam, slowCam}
+
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+	deps := createDependencies(cameras, fsService)
+
+	t.Run("offset captures are brought into sync", func(t *testing.T) {
+		mergedCam := &mergedCamera{logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{
+			Cameras:       []string{"cam1", "cam2"},
+			SyncWindow:    "50ms",
+			LatencyOffset: map[string]string{"cam2": "100ms"},
+		}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+
+		resp, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: statusCommand})
+		test.That(t, err, test.ShouldBeNil)
+		statuses := resp["cameras"].([]interface{})
+		test.That(t, statuses[0].(map[string]interface{})["latency_offset_ms"], test.ShouldEqual, 0.0)
+		test.That(t, statuses[1].(map[string]interface{})["latency_offset_ms"], test.ShouldEqual, 100.0)
+	})
+
+	t.Run("validate rejects bad offsets", func(t *testing.T) {
+		for _, offsets := range []map[string]string{{"cam3": "10ms"}, {"cam1": "fast"}, {"cam1": "-10ms"}} {
+			cfg := &Config{Cameras: []string{"cam1", "cam2"}, LatencyOffset: offsets}
+			_, err := cfg.Validate("path")
+			test.That(t, err, test.ShouldNotBeNil)
+		}
+	})
+}
+
+func TestPoseOverride(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	cam1 := createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 10}})
+	cam2 := createMockCamera("cam2", []r3.Vector{{X: 10, Y: 0, Z: 0}})
+	cameras := []camera.Camera{cam1, cam2}
+
+	// only cam1 is part of the frame system
+	fsService, err := createFrameSystemService(ctx, []camera.Camera{cam1}, logger)
+	test.That(t, err, test.ShouldBeNil)
+
+	mergedCam := &mergedCamera{logger: logger}
+	conf := resource.Config{ConvertedAttributes: &Config{
+		Cameras: []string{"cam1", "cam2"},
+		PoseOverride: map[string]*PoseConfig{
+			"cam2": {
+				Translation: r3.Vector{X: 100},
+				Orientation: &spatialmath.OrientationConfig{
+					Type:  spatialmath.OrientationVectorDegreesType,
+					Value: []byte(`{"z": 1, "th": 90}`),
+				},
+			},
+		},
+	}}
+	test.That(t, mergedCam.Reconfigure(ctx, createDependencies(cameras, fsService), conf), test.ShouldBeNil)
+
+	pc, err := mergedCam.NextPointCloud(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pc.Size(), test.ShouldEqual, 2)
+	_, ok := pc.At(0, 0, 10)
+	test.That(t, ok, test.ShouldBeTrue)
+	found := false
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		found = found || p.Sub(r3.Vector{X: 100, Y: 10}).Norm() < 1e-6
+		return true
+	})
+	test.That(t, found, test.ShouldBeTrue)
+
+	t.Run("validate rejects invalid overrides", func(t *testing.T) {
+		cfg := &Config{
+			Cameras: []string{"cam1", "cam2"},
+			PoseOverride: map[string]*PoseConfig{
+				"cam2": {Orientation: &spatialmath.OrientationConfig{Type: "bad"}},
+			},
+		}
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "pose_override")
+
+		cfg = &Config{Cameras: []string{"cam1", "cam2"}, PoseOverride: map[string]*PoseConfig{"cam3": {}}}
+		_, err = cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "pose_override given for unknown camera cam3")
+	})
+}
+
+func TestScale(t *testing.T) {
+	ctx := context.Background()
+	logger, logs := logging.NewObservedTestLogger(t)
+
+	// cam2 reports meters while cam1 reports millimeters
+	mmCam := createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 1000}, {X: 500, Y: 0, Z: 1000}})
+	mCam := createMockCamera("cam2", []r3.Vector{{X: 0, Y: 0, Z: 2}, {X: 0.5, Y: 0, Z: 2}})
+	cameras := []camera.Camera{mmCam, mCam}
+
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+	deps := createDependencies(cameras, fsService)
+
+	t.Run("unscaled clouds warn of a unit mismatch", func(t *testing.T) {
+		mergedCam := &mergedCamera{logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+		_, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, logs.FilterMessageSnippet("check its units").Len(), test.ShouldEqual, 1)
+	})
+
+	t.Run("scaled clouds are merged in millimeters", func(t *testing.T) {
+		before := logs.FilterMessageSnippet("check its units").Len()
+		mergedCam := &mergedCamera{logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}, Scale: map[string]float64{"cam2": 1000}}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		_, ok := pc.At(500, 0, 2000)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, logs.FilterMessageSnippet("check its units").Len(), test.ShouldEqual, before)
+	})
+
+	t.Run("validate rejects non-positive scales", func(t *testing.T) {
+		cfg := &Config{Cameras: []string{"cam1", "cam2"}, Scale: map[string]float64{"cam2": 0}}
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "scale")
+	})
+}
+
+func TestMinConfidence(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	cameras := []camera.Camera{
+		createConfidenceCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 1}, {X: 0, Y: 0, Z: 2}}, []uint16{10, 900}),
+		createConfidenceCamera("cam2", []r3.Vector{{X: 1, Y: 0, Z: 1}, {X: 1, Y: 0, Z: 2}}, []uint16{500, 50}),
+	}
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+	deps := createDependencies(cameras, fsService)
+
+	t.Run("confidence is preserved through the merge", func(t *testing.T) {
+		mergedCam := &mergedCamera{logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}, TagSource: true}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 4)
+		d, ok := pc.At(0, 0, 2)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, d.Intensity(), test.ShouldEqual, 900)
+		test.That(t, d.Value(), test.ShouldEqual, 0)
+		d, ok = pc.At(1, 0, 1)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, d.Intensity(), test.ShouldEqual, 500)
+		test.That(t, d.Value(), test.ShouldEqual, 1)
+	})
+
+	t.Run("low confidence points are dropped", func(t *testing.T) {
+		mergedCam := &mergedCamera{logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}, MinConfidence: 100}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+		_, ok := pc.At(0, 0, 1)
+		test.That(t, ok, test.ShouldBeFalse)
+		_, ok = pc.At(1, 0, 2)
+		test.That(t, ok, test.ShouldBeFalse)
+	})
+
+	t.Run("validate rejects out of range confidences", func(t *testing.T) {
+		for _, minConfidence := range []int{-1, 70000} {
+			cfg := &Config{Cameras: []string{"cam1", "cam2"}, MinConfidence: minConfidence}
+			_, err := cfg.Validate("path")
+			test.That(t, err, test.ShouldNotBeNil)
+			test.That(t, err.Error(), test.ShouldContainSubstring, "min_confidence")
+		}
+	})
+}
+
+// gridPoints returns n points spaced 1mm apart along X.
+func gridPoints(n int) []r3.Vector {
+	points := make([]r3.Vector, 0, n)
+	for i := 0; i < n; i++ {
+		points = append(points, r3.Vector{X: float64(i), Y: 0, Z: 1000})
+	}
+	return points
+}
+
+func TestMergeStrategy(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	cam1 := createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 10}, {X: 5, Y: 0, Z: 10}})
+	cam2 := createMockCamera("cam2", []r3.Vector{{X: 10, Y: 0, Z: 0}, {X: 10, Y: 5, Z: 0}})
+	cameras := []camera.Camera{cam1, cam2}
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+
+	mergeWith := func(strategy string, workers int) pointcloud.PointCloud {
+		mergedCam := &mergedCamera{logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{
+			Cameras:       []string{"cam1", "cam2"},
+			MergeStrategy: strategy,
+			MergeWorkers:  workers,
+			PoseOverride: map[string]*PoseConfig{
+				"cam2": {
+					Translation: r3.Vector{X: 100, Z: -20},
+					Orientation: &spatialmath.OrientationConfig{
+						Type:  spatialmath.OrientationVectorDegreesType,
+						Value: []byte(`{"x": 1, "z": 1, "th": 30}`),
+					},
+				},
+			},
+		}}
+		test.That(t, mergedCam.Reconfigure(ctx, createDependencies(cameras, fsService), conf), test.ShouldBeNil)
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		return pc
+	}
+	merge := func(strategy string) pointcloud.PointCloud {
+		return mergeWith(strategy, 0)
+	}
+
+	t.Run("append matches rdk", func(t *testing.T) {
+		expected := merge(mergeStrategyRDK)
+		appended := merge(mergeStrategyAppend)
+		test.That(t, appended.Size(), test.ShouldEqual, expected.Size())
+		appended.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+			found := false
+			expected.Iterate(0, 0, func(q r3.Vector, _ pointcloud.Data) bool {
+				found = p.Sub(q).Norm() < 1e-6
+				return !found
+			})
+			test.That(t, found, test.ShouldBeTrue)
+			return true
+		})
+	})
+
+	t.Run("identical inputs give identical point order", func(t *testing.T) {
+		points := func(pc pointcloud.PointCloud) []r3.Vector {
+			var points []r3.Vector
+			pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+				points = append(points, p)
+				return true
+			})
+			return points
+		}
+		expected := points(merge(mergeStrategyAppend))
+		for i := 0; i < 5; i++ {
+			test.That(t, points(merge(mergeStrategyAppend)), test.ShouldResemble, expected)
+		}
+		// append keeps the order of the cameras in the config
+		test.That(t, expected[:2], test.ShouldResemble, []r3.Vector{{X: 0, Y: 0, Z: 10}, {X: 5, Y: 0, Z: 10}})
+
+		// rdk interleaves the same points in the order MergePointClouds delivers them
+		for i := 0; i < 5; i++ {
+			merged := points(merge(mergeStrategyRDK))
+			test.That(t, len(merged), test.ShouldEqual, len(expected))
+			for _, p := range merged {
+				nearest := math.Inf(1)
+				for _, q := range expected {
+					nearest = math.Min(nearest, p.Distance(q))
+				}
+				test.That(t, nearest, test.ShouldBeLessThan, 1e-6)
+			}
+		}
+	})
+
+	t.Run("merge_workers matches append", func(t *testing.T) {
+		points := func(pc pointcloud.PointCloud) []r3.Vector {
+			var points []r3.Vector
+			pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+				points = append(points, p)
+				return true
+			})
+			return points
+		}
+		expected := points(merge(mergeStrategyAppend))
+		for _, workers := range []int{2, 3, 16} {
+			test.That(t, points(mergeWith("", workers)), test.ShouldResemble, expected)
+		}
+	})
+
+	t.Run("validate rejects merge_workers with a strategy or streaming", func(t *testing.T) {
+		_, err := (&Config{Cameras: []string{"cam1", "cam2"}, MergeWorkers: -1}).Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "merge_workers must be non-negative")
+
+		_, err = (&Config{Cameras: []string{"cam1", "cam2"}, MergeWorkers: 4, MergeStrategy: mergeStrategyAppend}).Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "merge_workers cannot be combined with merge_strategy")
+
+		_, err = (&Config{Cameras: []string{"cam1", "cam2"}, MergeWorkers: 4, StreamingMerge: true}).Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "streaming_merge cannot be combined with merge_workers")
+	})
+
+	t.Run("validate rejects unknown strategies", func(t *testing.T) {
+		cfg := &Config{Cameras: []string{"cam1", "cam2"}, MergeStrategy: "fast"}
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "merge_strategy")
+	})
+}
+
+func TestStreamingMerge(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	// every camera records how many fetches are in flight at once
+	var inFlight, maxInFlight int32
+	countingCamera := func(name string, points []r3.Vector) camera.Camera {
+		cam := createMockCamera(name, points).(*inject.Camera)
+		next := cam.NextPointCloudFunc
+		cam.NextPointCloudFunc = func(ctx context.Context) (pointcloud.PointCloud, error) {
+			if n := atomic.AddInt32(&inFlight, 1); n > atomic.LoadInt32(&maxInFlight) {
+				atomic.StoreInt32(&maxInFlight, n)
+			}
+			defer atomic.AddInt32(&inFlight, -1)
+			time.Sleep(time.Millisecond)
+			return next(ctx)
+		}
+		return cam
+	}
+	cam1 := countingCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 10}, {X: 5, Y: 0, Z: 10}})
+	cam2 := countingCamera("cam2", []r3.Vector{{X: 10, Y: 0, Z: 0}, {X: 10, Y: 5, Z: 0}})
+	badCam := createFailingCamera("cam3")
+	cameras := []camera.Camera{cam1, cam2, badCam}
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+	deps := createDependencies(cameras, fsService)
+	override := map[string]*PoseConfig{"cam2": {Translation: r3.Vector{X: 100, Z: -20}}}
+
+	points := func(pc pointcloud.PointCloud) []r3.Vector {
+		var points []r3.Vector
+		pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+			points = append(points, p)
+			return true
+		})
+		return points
+	}
+
+	t.Run("matches append one camera at a time", func(t *testing.T) {
+		mergedCam := &mergedCamera{logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{
+			Cameras: []string{"cam1", "cam2"}, MergeStrategy: mergeStrategyAppend, PoseOverride: override,
+		}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+		expected, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+
+		conf = resource.Config{ConvertedAttributes: &Config{
+			Cameras: []string{"cam1", "cam2"}, StreamingMerge: true, PoseOverride: override,
+		}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+		atomic.StoreInt32(&maxInFlight, 0)
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, points(pc), test.ShouldResemble, points(expected))
+		test.That(t, atomic.LoadInt32(&maxInFlight), test.ShouldEqual, 1)
+		test.That(t, mergedCam.cameraStatuses["cam2"].lastPointCount, test.ShouldEqual, 2)
+	})
+
+	t.Run("follows the error policy", func(t *testing.T) {
+		mergedCam := &mergedCamera{logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{
+			Cameras: []string{"cam1", "cam3", "cam2"}, StreamingMerge: true, ErrorPolicy: errorPolicySkip,
+		}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 4)
+
+		mergedCam = &mergedCamera{logger: logger}
+		conf = resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam3", "cam2"}, StreamingMerge: true}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+		_, err = mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "cam3")
+		// the merge ends at the failure without fetching the cameras after it
+		test.That(t, mergedCam.cameraStatuses["cam2"].lastCapturedAt.IsZero(), test.ShouldBeTrue)
+	})
+
+	t.Run("validate rejects settings that need every cloud at once", func(t *testing.T) {
+		for _, cfg := range []*Config{
+			{Cameras: []string{"cam1", "cam2"}, StreamingMerge: true, MergeStrategy: mergeStrategyAppend},
+			{Cameras: []string{"cam1", "cam2"}, StreamingMerge: true, SyncWindow: "10ms"},
+			{Cameras: []string{"cam1", "cam2"}, StreamingMerge: true, ICPRefine: &ICPConfig{}},
+		} {
+			_, err := cfg.Validate("path")
+			test.That(t, err, test.ShouldNotBeNil)
+			test.That(t, err.Error(), test.ShouldContainSubstring, "streaming_merge cannot be combined")
+		}
+	})
+}
+
+// BenchmarkStreamingMerge compares the peak heap of merging six 640x480 clouds all at once and streaming them. Every
+// fetch returns a new cloud, as real cameras do, so the clouds released by a streaming merge can be collected.
+func BenchmarkStreamingMerge(b *testing.B) {
+	ctx := context.Background()
+	logger := logging.NewLogger("benchmark")
+
+	const width, height = 640, 480
+	var cameras []camera.Camera
+	var names []string
+	for i := 0; i < 6; i++ {
+		name := "cam" + strconv.Itoa(i)
+		depth := float64(1000 + i)
+		cam := inject.NewCamera(name)
+		cam.NextPointCloudFunc = func(ctx context.Context) (pointcloud.PointCloud, error) {
+			pc := pointcloud.NewWithPrealloc(width * height)
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					if err := pc.Set(r3.Vector{X: float64(x), Y: float64(y), Z: depth}, pointcloud.NewBasicData()); err != nil {
+						return nil, err
+					}
+				}
+			}
+			return pc, nil
+		}
+		cam.PropertiesFunc = func(ctx context.Context) (camera.Properties, error) {
+			return camera.Properties{SupportsPCD: true}, nil
+		}
+		cameras = append(cameras, cam)
+		names = append(names, name)
+	}
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, streaming := range []bool{false, true} {
+		name := "all_at_once"
+		if streaming {
+			name = "streaming"
+		}
+		b.Run(name, func(b *testing.B) {
+			mergedCam := &mergedCamera{logger: logger}
+			conf := resource.Config{ConvertedAttributes: &Config{Cameras: names, StreamingMerge: streaming}}
+			if err := mergedCam.Reconfigure(ctx, createDependencies(cameras, fsService), conf); err != nil {
+				b.Fatal(err)
+			}
+
+			// sample the heap while merging since the peak is gone by the time a merge returns
+			var peak uint64
+			done := make(chan struct{})
+			sampled := make(chan struct{})
+			go func() {
+				defer close(sampled)
+				var stats runtime.MemStats
+				for {
+					runtime.ReadMemStats(&stats)
+					if stats.HeapAlloc > peak {
+						peak = stats.HeapAlloc
+					}
+					select {
+					case <-done:
+						return
+					case <-time.After(time.Millisecond):
+					}
+				}
+			}()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				runtime.GC()
+				if _, err := mergedCam.NextPointCloud(ctx); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StopTimer()
+			close(done)
+			<-sampled
+			b.ReportMetric(float64(peak)/(1<<20), "peak_heap_MiB")
+		})
+	}
+}
+
+func TestDescribeContributors(t *testing.T) {
+	large := pointcloud.New()
+	for _, p := range gridPoints(3) {
+		test.That(t, large.Set(p, pointcloud.NewBasicData()), test.ShouldBeNil)
+	}
+	results := []cameraResult{{name: "cam1", pc: pointcloud.New()}, {name: "cam2", pc: large}}
+	test.That(t, describeContributors(results), test.ShouldEqual, "cam1 (0 points), cam2 (3 points)")
+}
+
+func BenchmarkMergeStrategy(b *testing.B) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(b)
+
+	// many small clouds, each camera offset so that none of their points coincide
+	const cameraCount, cloudSize = 16, 500
+	var cameras []camera.Camera
+	var names []string
+	poses := make(map[string]spatialmath.Pose, cameraCount)
+	for i := 0; i < cameraCount; i++ {
+		name := "cam" + strconv.Itoa(i)
+		cameras = append(cameras, createMockCamera(name, gridPoints(cloudSize)))
+		names = append(names, name)
+		poses[name] = spatialmath.NewPoseFromPoint(r3.Vector{Y: float64(i) * 10})
+	}
+	fsService, err := createFrameSystemServiceWithPoses(ctx, cameras, poses, logger)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, strategy := range []string{mergeStrategyRDK, mergeStrategyAppend} {
+		b.Run(strategy, func(b *testing.B) {
+			mergedCam := &mergedCamera{logger: logger}
+			conf := resource.Config{ConvertedAttributes: &Config{Cameras: names, TargetFrame: "world", MergeStrategy: strategy}}
+			if err := mergedCam.Reconfigure(ctx, createDependencies(cameras, fsService), conf); err != nil {
+				b.Fatal(err)
+			}
+			pc, err := mergedCam.NextPointCloud(ctx)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if pc.Size() != cameraCount*cloudSize {
+				b.Fatalf("merged %v points, expected %v", pc.Size(), cameraCount*cloudSize)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := mergedCam.NextPointCloud(ctx); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestSourceVoxelSize(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	cameras := []camera.Camera{
+		createMockCamera("cam1", gridPoints(100)),
+		createMockCamera("cam2", []r3.Vector{{X: 0, Y: 0, Z: 500}}),
+	}
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+
+	t.Run("downsamples only the configured camera", func(t *testing.T) {
+		mergedCam := &mergedCamera{logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{
+			Cameras:         []string{"cam1", "cam2"},
+			SourceVoxelSize: map[string]float64{"cam1": 0.01},
+		}}
+		test.That(t, mergedCam.Reconfigure(ctx, createDependencies(cameras, fsService), conf), test.ShouldBeNil)
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 11)
+		_, ok := pc.At(4.5, 0, 1000)
+		test.That(t, ok, test.ShouldBeTrue)
+	})
+
+	t.Run("validate rejects bad sizes", func(t *testing.T) {
+		cfg := &Config{Cameras: []string{"cam1", "cam2"}, SourceVoxelSize: map[string]float64{"cam1": -0.01}}
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "non-negative")
+
+		cfg = &Config{Cameras: []string{"cam1", "cam2"}, SourceVoxelSize: map[string]float64{"cam3": 0.01}}
+		_, err = cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "unknown camera cam3")
+	})
+}
+
+func TestKeepInvalidPoints(t *testing.T) {
+	ctx := context.Background()
+
+	invalid := pointcloud.New()
+	test.That(t, invalid.Set(r3.Vector{Z: 10}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, invalid.Set(r3.Vector{X: math.NaN(), Z: 10}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, invalid.Set(r3.Vector{Y: math.NaN(), Z: math.NaN()}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	cam1 := newFakeCamera("cam1")
+	cam1.setPointCloud(invalid)
+	cam2 := newFakeCamera("cam2", r3.Vector{Z: 20})
+	cameras := []camera.Camera{cam1, cam2}
+	fsService, err := createFrameSystemService(ctx, cameras, logging.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+
+	countInvalid := func(pc pointcloud.PointCloud) int {
+		count := 0
+		pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+			if !isFinite(p) {
+				count++
+			}
+			return true
+		})
+		return count
+	}
+
+	mergedCam := newTestMergedCamera(t, &Config{Cameras: []string{"cam1", "cam2"}}, cameras, fsService)
+	pc, err := mergedCam.NextPointCloud(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pc.Size(), test.ShouldEqual, 2)
+	test.That(t, countInvalid(pc), test.ShouldEqual, 0)
+
+	keeping := newTestMergedCamera(t, &Config{Cameras: []string{"cam1", "cam2"}, KeepInvalidPoints: true}, cameras, fsService)
+	pc, err = keeping.NextPointCloud(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pc.Size(), test.ShouldEqual, 4)
+	test.That(t, countInvalid(pc), test.ShouldEqual, 2)
+}
+
+func TestAccumulateFrames(t *testing.T) {
+	ctx := context.Background()
+
+	cam1 := newFakeCamera("cam1")
+	cam2 := newFakeCamera("cam2", r3.Vector{Y: 100})
+	cameras := []camera.Camera{cam1, cam2}
+	fsService, err := createFrameSystemService(ctx, cameras, logging.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+
+	// cam1 is sparse, seeing a different point in every frame
+	mergeFrames := func(mergedCam *mergedCamera, frames int) pointcloud.PointCloud {
+		var pc pointcloud.PointCloud
+		for i := 0; i < frames; i++ {
+			cam1.setPoints(r3.Vector{X: float64(i)})
+			var err error
+			pc, err = mergedCam.NextPointCloud(ctx)
+			test.That(t, err, test.ShouldBeNil)
+		}
+		return pc
+	}
+
+	t.Run("accumulates the most recent frames", func(t *testing.T) {
+		mergedCam := newTestMergedCamera(t, &Config{
+			Cameras: []string{"cam1", "cam2"}, AccumulateFrames: 3, StaticRig: true, EnableCache: true,
+		}, cameras, fsService)
+		pc := mergeFrames(mergedCam, 2)
+		test.That(t, pc.Size(), test.ShouldEqual, 3)
+
+		pc = mergeFrames(mergedCam, 5)
+		test.That(t, pc.Size(), test.ShouldEqual, 4)
+		for _, x := range []float64{2, 3, 4} {
+			_, ok := pc.At(x, 0, 0)
+			test.That(t, ok, test.ShouldBeTrue)
+		}
+		_, ok := pc.At(1, 0, 0)
+		test.That(t, ok, test.ShouldBeFalse)
+
+		// the cache keeps the single merges
+		cached, ok := mergedCam.cloudCache.get(0)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, cached.pc.Size(), test.ShouldEqual, 2)
+	})
+
+	t.Run("decay drops old frames", func(t *testing.T) {
+		mergedCam := newTestMergedCamera(t, &Config{
+			Cameras: []string{"cam1", "cam2"}, AccumulateFrames: 3, AccumulateDecay: "1ns", StaticRig: true, EnableCache: true,
+		}, cameras, fsService)
+		pc := mergeFrames(mergedCam, 3)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		for _, tc := range []struct {
+			cfg      Config
+			expected string
+		}{
+			{Config{AccumulateFrames: -1}, "accumulate_frames must be non-negative"},
+			{Config{AccumulateFrames: 3, EnableCache: true}, "accumulate_frames requires static_rig"},
+			{Config{AccumulateFrames: 3, StaticRig: true}, "accumulate_frames requires enable_cache"},
+			{Config{AccumulateFrames: 3, StaticRig: true, EnableCache: true, CacheSize: 2}, "must not exceed cache_size"},
+			{Config{AccumulateDecay: "1s"}, "accumulate_decay requires accumulate_frames"},
+			{Config{AccumulateFrames: 3, StaticRig: true, EnableCache: true, AccumulateDecay: "soon"}, "invalid accumulate_decay"},
+			{Config{AccumulateFrames: 3, StaticRig: true, EnableCache: true, AccumulateDecay: "-1s"}, "accumulate_decay must be positive"},
+		} {
+			cfg := tc.cfg
+			cfg.Cameras = []string{"cam1", "cam2"}
+			_, err := cfg.Validate("path")
+			test.That(t, err, test.ShouldNotBeNil)
+			test.That(t, err.Error(), test.ShouldContainSubstring, tc.expected)
+		}
+	})
+}
+
+func TestTargetDensity(t *testing.T) {
+	ctx := context.Background()
+
+	// two cameras see the same 100mm cube with a point every 5mm, offset by 1mm, and a third sees sparse points
+	var cube, offsetCube, sparse []r3.Vector
+	for x := 0.0; x < 100; x += 5 {
+		for y := 0.0; y < 100; y += 5 {
+			for z := 0.0; z < 100; z += 5 {
+				cube = append(cube, r3.Vector{X: x, Y: y, Z: z})
+				offsetCube = append(offsetCube, r3.Vector{X: x + 1, Y: y + 1, Z: z + 1})
+			}
+		}
+	}
+	for x := 1000.0; x < 2000; x += 100 {
+		sparse = append(sparse, r3.Vector{X: x})
+	}
+	cameras := []camera.Camera{newFakeCamera("cam1", cube...), newFakeCamera("cam2", offsetCube...), newFakeCamera("cam3", sparse...)}
+	fsService, err := createFrameSystemService(ctx, cameras, logging.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+
+	mergedCam := newTestMergedCamera(t, &Config{Cameras: []string{"cam1", "cam2", "cam3"}, TargetDensity: 125000}, cameras, fsService)
+	pc, err := mergedCam.NextPointCloud(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	// the overlapping cubes are thinned to 125 points, one per 20mm voxel, and the sparse points are all kept
+	dense := 0
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		if p.X < 1000 {
+			dense++
+		}
+		return true
+	})
+	test.That(t, float64(dense), test.ShouldAlmostEqual, 125, 15)
+	test.That(t, pc.Size()-dense, test.ShouldEqual, len(sparse))
+
+	_, err = (&Config{Cameras: []string{"cam1", "cam2"}, TargetDensity: -1}).Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "target_density must be non-negative")
+}
+
+func TestAxisRemap(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	cameras := []camera.Camera{
+		createMockCamera("cam1", []r3.Vector{{X: 1, Y: 2, Z: 3}}),
+		createMockCamera("cam2", []r3.Vector{{X: 1, Y: 2, Z: 3}}),
+	}
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+
+	t.Run("remaps only the configured camera", func(t *testing.T) {
+		mergedCam := &mergedCamera{logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{
+			Cameras:   []string{"cam1", "cam2"},
+			AxisRemap: map[string]string{"cam2": "x,-z,y"},
+		}}
+		test.That(t, mergedCam.Reconfigure(ctx, createDependencies(cameras, fsService), conf), test.ShouldBeNil)
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+		_, ok := pc.At(1, 2, 3)
+		test.That(t, ok, test.ShouldBeTrue)
+		_, ok = pc.At(1, -3, 2)
+		test.That(t, ok, test.ShouldBeTrue)
+	})
+
+	t.Run("validate rejects bad remaps", func(t *testing.T) {
+		cfg := &Config{Cameras: []string{"cam1", "cam2"}, AxisRemap: map[string]string{"cam1": "x,x,z"}}
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "invalid axis_remap of camera cam1")
+
+		cfg = &Config{Cameras: []string{"cam1", "cam2"}, AxisRemap: map[string]string{"cam3": "x,y,z"}}
+		_, err = cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "unknown camera cam3")
+	})
+}
+
+func TestMinClusterSize(t *testing.T) {
+	ctx := context.Background()
+	cameras := []camera.Camera{
+		newFakeCamera("cam1", r3.Vector{X: 0}, r3.Vector{X: 10}, r3.Vector{X: 20}),
+		newFakeCamera("cam2", r3.Vector{X: 30}, r3.Vector{X: 500}),
+	}
+	fsService, err := newFakeFrameSystemService(map[string]spatialmath.Pose{
+		"cam1": spatialmath.NewZeroPose(),
+		"cam2": spatialmath.NewZeroPose(),
+	})
+	test.That(t, err, test.ShouldBeNil)
+
+	t.Run("drops clusters smaller than the minimum", func(t *testing.T) {
+		mergedCam := newTestMergedCamera(t, &Config{Cameras: []string{"cam1", "cam2"}, MinClusterSize: 2}, cameras, fsService)
+		defer mergedCam.Close(ctx)
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		// the points of both cameras 10mm apart form a single cluster within the default tolerance
+		test.That(t, pc.Size(), test.ShouldEqual, 4)
+		_, ok := pc.At(500, 0, 0)
+		test.That(t, ok, test.ShouldBeFalse)
+
+		tight := newTestMergedCamera(t, &Config{
+			Cameras: []string{"cam1", "cam2"}, MinClusterSize: 2, ClusterTolerance: 0.005,
+		}, cameras, fsService)
+		defer tight.Close(ctx)
+		pc, err = tight.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 0)
+	})
+
+	t.Run("validate rejects bad settings", func(t *testing.T) {
+		cfg := &Config{Cameras: []string{"cam1", "cam2"}, MinClusterSize: -1}
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "min_cluster_size must be non-negative")
+
+		cfg = &Config{Cameras: []string{"cam1", "cam2"}, MinClusterSize: 2, ClusterTolerance: -0.01}
+		_, err = cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "cluster_tolerance must be non-negative")
+
+		cfg = &Config{Cameras: []string{"cam1", "cam2"}, ClusterTolerance: 0.01}
+		_, err = cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "cluster_tolerance requires min_cluster_size")
+	})
+}
+
+func TestUniformSchema(t *testing.T) {
+	ctx := context.Background()
+	red := color.NRGBA{R: 255, A: 255}
+	cam1 := newFakeCamera("cam1")
+	colored := pointcloud.New()
+	d := pointcloud.NewColoredData(red)
+	d.SetValue(3)
+	test.That(t, colored.Set(r3.Vector{X: 1}, d), test.ShouldBeNil)
+	cam1.setPointCloud(colored)
+	cam2 := newFakeCamera("cam2")
+	plain := pointcloud.New()
+	test.That(t, plain.Set(r3.Vector{X: 2}, nil), test.ShouldBeNil)
+	cam2.setPointCloud(plain)
+	cam3 := newFakeCamera("cam3")
+	valued := pointcloud.New()
+	test.That(t, valued.Set(r3.Vector{X: 3}, pointcloud.NewValueData(7)), test.ShouldBeNil)
+	cam3.setPointCloud(valued)
+	cameras := []camera.Camera{cam1, cam2, cam3}
+	fsService, err := newFakeFrameSystemService(map[string]spatialmath.Pose{
+		"cam1": spatialmath.NewZeroPose(),
+		"cam2": spatialmath.NewZeroPose(),
+		"cam3": spatialmath.NewZeroPose(),
+	})
+	test.That(t, err, test.ShouldBeNil)
+
+	for _, strategy := range []string{"", mergeStrategyAppend} {
+		t.Run("merge strategy "+strategy, func(t *testing.T) {
+			cfg := &Config{
+				Cameras: []string{"cam1", "cam2", "cam3"}, MergeStrategy: strategy, UniformSchema: true, DefaultValue: -1,
+			}
+			merged := newTestMergedCamera(t, cfg, cameras, fsService)
+			pc, err := merged.NextPointCloud(ctx)
+			test.That(t, err, test.ShouldBeNil)
+			test.That(t, pc.Size(), test.ShouldEqual, 3)
+			for _, expected := range []struct {
+				x     float64
+				color *color.NRGBA
+				value int
+			}{
+				{1, &red, 3},
+				{2, &color.NRGBA{A: 255}, -1},
+				{3, &color.NRGBA{A: 255}, 7},
+			} {
+				d, ok := pc.At(expected.x, 0, 0)
+				test.That(t, ok, test.ShouldBeTrue)
+				test.That(t, d, test.ShouldNotBeNil)
+				test.That(t, d.HasColor(), test.ShouldBeTrue)
+				test.That(t, d.Color(), test.ShouldResemble, expected.color)
+				test.That(t, d.HasValue(), test.ShouldBeTrue)
+				test.That(t, d.Value(), test.ShouldEqual, expected.value)
+			}
+		})
+	}
+
+	t.Run("validate", func(t *testing.T) {
+		cfg := &Config{Cameras: []string{"cam1", "cam2"}, DefaultValue: 1}
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "default_value requires uniform_schema")
+
+		cfg = &Config{Cameras: []string{"cam1", "cam2"}, UniformSchema: true, StreamingMerge: true}
+		_, err = cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "streaming_merge cannot be combined with uniform_schema")
+	})
+}
+
+func TestRandomSeed(t *testing.T) {
+	ctx := context.Background()
+	// a bumpy floor, so that every plane sampled by RANSAC keeps a different set of points
+	r := rand.New(rand.NewSource(0))
+	var floor []r3.Vector
+	for x := 0.0; x < 500; x += 50 {
+		for y := 0.0; y < 500; y += 50 {
+			floor = append(floor, r3.Vector{X: x, Y: y, Z: r.Float64() * 20})
+		}
+	}
+	cam1 := newFakeCamera("cam1", floor...)
+	cam2 := newFakeCamera("cam2", r3.Vector{X: 250, Y: 250, Z: 500})
+	cameras := []camera.Camera{cam1, cam2}
+	fsService, err := newFakeFrameSystemService(map[string]spatialmath.Pose{
+		"cam1": spatialmath.NewZeroPose(),
+		"cam2": spatialmath.NewZeroPose(),
+	})
+	test.That(t, err, test.ShouldBeNil)
+
+	merge := func(seed int64) []r3.Vector {
+		cfg := &Config{
+			Cameras:       []string{"cam1", "cam2"},
+			GroundRemoval: &GroundRemovalConfig{DistanceThreshold: 0.005, MaxIterations: 1},
+			RandomSeed:    &seed,
+			MergeStrategy: mergeStrategyAppend,
+		}
+		merged := newTestMergedCamera(t, cfg, cameras, fsService)
+		pc, err := merged.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		var points []r3.Vector
+		pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+			points = append(points, p)
+			return true
+		})
+		return points
+	}
+
+	first := merge(7)
+	test.That(t, len(first), test.ShouldBeLessThan, len(floor)+1)
+	test.That(t, merge(7), test.ShouldResemble, first)
+	test.That(t, merge(8), test.ShouldNotResemble, first)
+}
+
+func TestROSFrame(t *testing.T) {
+	ctx := context.Background()
+	cameras := []camera.Camera{
+		newFakeCamera("cam1", r3.Vector{X: 1, Y: 2, Z: 3}),
+		newFakeCamera("cam2", r3.Vector{X: 4, Y: 5, Z: 6}),
+	}
+	fsService, err := newFakeFrameSystemService(map[string]spatialmath.Pose{
+		"cam1": spatialmath.NewZeroPose(),
+		"cam2": spatialmath.NewZeroPose(),
+	})
+	test.That(t, err, test.ShouldBeNil)
+
+	mergedCam := newTestMergedCamera(t, &Config{
+		Cameras:      []string{"cam1", "cam2"},
+		OutputOffset: &PoseConfig{Translation: r3.Vector{Z: 10}},
+		ROSFrame:     true,
+	}, cameras, fsService)
+	defer mergedCam.Close(ctx)
+	pc, err := mergedCam.NextPointCloud(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pc.Size(), test.ShouldEqual, 2)
+	// the offset moves the points forward along the optical z axis, which becomes the ROS x axis
+	_, ok := pc.At(13, -1, -2)
+	test.That(t, ok, test.ShouldBeTrue)
+	_, ok = pc.At(16, -4, -5)
+	test.That(t, ok, test.ShouldBeTrue)
+
+	resp, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: frameCommand})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["ros_frame"], test.ShouldBeTrue)
+
+	// the remapped cloud cannot be rendered along the optical axes
+	_, _, err = mergedCam.Images(ctx)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "converted to the ROS axes by ros_frame")
+	_, err = mergedCam.Projector(ctx)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "converted to the ROS axes by ros_frame")
+
+	intrinsics := &transform.PinholeCameraIntrinsics{Width: 10, Height: 10, Fx: 10, Fy: 10, Ppx: 5, Ppy: 5}
+	cfg := &Config{Cameras: []string{"cam1", "cam2"}, ROSFrame: true, Projection: &ProjectionConfig{Intrinsics: intrinsics}}
+	_, err = cfg.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "ros_frame cannot be combined with projection")
+}
+
+func TestOutputUnits(t *testing.T) {
+	ctx := context.Background()
+	poses := map[string]spatialmath.Pose{"cam1": spatialmath.NewZeroPose(), "cam2": spatialmath.NewZeroPose()}
+
+	t.Run("validates the units", func(t *testing.T) {
+		cfg := &Config{Cameras: []string{"cam1", "cam2"}, OutputUnits: "km"}
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, `invalid output_units "km"`)
+
+		intrinsics := &transform.PinholeCameraIntrinsics{Width: 10, Height: 10, Fx: 10, Fy: 10, Ppx: 5, Ppy: 5}
+		cfg = &Config{
+			Cameras:     []string{"cam1", "cam2"},
+			OutputUnits: outputUnitsMeters,
+			Projection:  &ProjectionConfig{Intrinsics: intrinsics},
+		}
+		_, err = cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "cannot be combined with projection")
+	})
+
+	t.Run("round trips a camera reporting meters", func(t *testing.T) {
+		cameras := []camera.Camera{
+			// cam1 reports meters and is scaled to millimeters, cam2 reports millimeters
+			newFakeCamera("cam1", r3.Vector{X: 1, Y: 2, Z: 3}),
+			newFakeCamera("cam2", r3.Vector{X: 4000, Y: 5000, Z: 6000}),
+		}
+		fsService, err := newFakeFrameSystemService(poses)
+		test.That(t, err, test.ShouldBeNil)
+
+		mergedCam := newTestMergedCamera(t, &Config{
+			Cameras:     []string{"cam1", "cam2"},
+			Scale:       map[string]float64{"cam1": 1000},
+			OutputUnits: outputUnitsMeters,
+		}, cameras, fsService)
+		defer mergedCam.Close(ctx)
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+		for _, want := range []r3.Vector{{X: 1, Y: 2, Z: 3}, {X: 4, Y: 5, Z: 6}} {
+			test.That(t, nearestDistance(pc, want), test.ShouldBeLessThan, 1e-9)
+		}
+
+		resp, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: frameCommand})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp["output_units"], test.ShouldEqual, outputUnitsMeters)
+
+		// millimeters are the default and leave the cloud as merged
+		millimeters := newTestMergedCamera(t, &Config{
+			Cameras:     []string{"cam1", "cam2"},
+			Scale:       map[string]float64{"cam1": 1000},
+			OutputUnits: outputUnitsMillimeters,
+		}, cameras, fsService)
+		defer millimeters.Close(ctx)
+		pc, err = millimeters.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		_, ok := pc.At(1000, 2000, 3000)
+		test.That(t, ok, test.ShouldBeTrue)
+	})
+
+	t.Run("round trips a cloud emitted in meters", func(t *testing.T) {
+		// the cloud of a merge in meters, read back by a camera with a scale of 1000, is the cloud in millimeters
+		cam1 := newFakeCamera("cam1", r3.Vector{X: 1000, Y: 2000, Z: 3000})
+		cam2 := newFakeCamera("cam2", r3.Vector{X: 4000, Y: 5000, Z: 6000})
+		cameras := []camera.Camera{cam1, cam2}
+		fsService, err := newFakeFrameSystemService(poses)
+		test.That(t, err, test.ShouldBeNil)
+
+		meters := newTestMergedCamera(t, &Config{Cameras: []string{"cam1", "cam2"}, OutputUnits: outputUnitsMeters}, cameras, fsService)
+		defer meters.Close(ctx)
+		pc, err := meters.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		cam1.setPointCloud(pc)
+		cam2.setPoints()
+
+		scaled := newTestMergedCamera(t, &Config{Cameras: []string{"cam1", "cam2"}, Scale: map[string]float64{"cam1": 1000}},
+			cameras, fsService)
+		defer scaled.Close(ctx)
+		pc, err = scaled.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+		for _, want := range []r3.Vector{{X: 1000, Y: 2000, Z: 3000}, {X: 4000, Y: 5000, Z: 6000}} {
+			test.That(t, nearestDistance(pc, want), test.ShouldBeLessThan, 1e-9)
+		}
+	})
+}
+
+// nearestDistance returns the distance from p to the nearest point of the cloud.
+func nearestDistance(pc pointcloud.PointCloud, p r3.Vector) float64 {
+	nearest := math.Inf(1)
+	pc.Iterate(0, 0, func(q r3.Vector, d pointcloud.Data) bool {
+		nearest = math.Min(nearest, q.Distance(p))
+		return true
+	})
+	return nearest
+}
+
+func TestExclusionBoxes(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	cameras := []camera.Camera{
+		createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 100}, {X: 0, Y: 0, Z: 500}}),
+		createMockCamera("cam2", []r3.Vector{{X: 0, Y: 0, Z: 100}}),
+	}
+	poses := map[string]spatialmath.Pose{"cam1": spatialmath.NewPoseFromPoint(r3.Vector{X: 1000})}
+	fsService, err := createFrameSystemServiceWithPoses(ctx, cameras, poses, logger)
+	test.That(t, err, test.ShouldBeNil)
+
+	t.Run("drops points of the configured camera within its boxes", func(t *testing.T) {
+		// the box is in the frame of cam1, so it does not contain the point at 1000, 0, 100 in the target frame
+		box := &BoxConfig{Min: r3.Vector{X: -10, Y: -10, Z: 50}, Max: r3.Vector{X: 10, Y: 10, Z: 150}}
+		mergedCam := &mergedCamera{logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{
+			Cameras:        []string{"cam1", "cam2"},
+			TargetFrame:    "world",
+			ExclusionBoxes: map[string][]*BoxConfig{"cam1": {box}},
+		}}
+		test.That(t, mergedCam.Reconfigure(ctx, createDependencies(cameras, fsService), conf), test.ShouldBeNil)
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+		_, ok := pc.At(1000, 0, 100)
+		test.That(t, ok, test.ShouldBeFalse)
+		_, ok = pc.At(1000, 0, 500)
+		test.That(t, ok, test.ShouldBeTrue)
+		_, ok = pc.At(0, 0, 100)
+		test.That(t, ok, test.ShouldBeTrue)
+	})
+
+	t.Run("validate rejects bad boxes", func(t *testing.T) {
+		flat := &BoxConfig{Min: r3.Vector{X: -1, Y: -1, Z: 1}, Max: r3.Vector{X: 1, Y: 1, Z: 1}}
+		cfg := &Config{Cameras: []string{"cam1", "cam2"}, ExclusionBoxes: map[string][]*BoxConfig{"cam1": {flat}}}
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "invalid exclusion box 0 of camera cam1")
+
+		cfg = &Config{Cameras: []string{"cam1", "cam2"}, ExclusionBoxes: map[string][]*BoxConfig{"cam1": {nil}}}
+		_, err = cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "is empty")
+
+		cfg = &Config{Cameras: []string{"cam1", "cam2"}, ExclusionBoxes: map[string][]*BoxConfig{"cam3": {}}}
+		_, err = cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "unknown camera cam3")
+	})
+}
+
+func BenchmarkSourceVoxelSize(b *testing.B) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(b)
+
+	// a high resolution camera alongside a low resolution one
+	cameras := []camera.Camera{
+		createMockCamera("cam1", gridPoints(100000)),
+		createMockCamera("cam2", gridPoints(1000)),
+	}
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, bm := range []struct {
+		name            string
+		sourceVoxelSize map[string]float64
+	}{
+		{"full resolution", nil},
+		{"source voxel size", map[string]float64{"cam1": 0.01}},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			mergedCam := &mergedCamera{logger: logger}
+			conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}, SourceVoxelSize: bm.sourceVoxelSize}}
+			if err := mergedCam.Reconfigure(ctx, createDependencies(cameras, fsService), conf); err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := mergedCam.NextPointCloud(ctx); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestConsecutiveEmptyClouds(t *testing.T) {
+	ctx := context.Background()
+	logger, logs := logging.NewObservedTestLogger(t)
+
+	var empty int32 = 1
+	cam2 := createMockCamera("cam2", []r3.Vector{{X: 0, Y: 0, Z: 2}}).(*inject.Camera)
+	next := cam2.NextPointCloudFunc
+	cam2.NextPointCloudFunc = func(ctx context.Context) (pointcloud.PointCloud, error) {
+		if atomic.LoadInt32(&empty) == 1 {
+			return pointcloud.New(), nil
+		}
+		return next(ctx)
+	}
+	cameras := []camera.Camera{createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 1}}), cam2}
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+
+	mergedCam := &mergedCamera{logger: logger}
+	conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}}}
+	test.That(t, mergedCam.Reconfigure(ctx, createDependencies(cameras, fsService), conf), test.ShouldBeNil)
+
+	consecutiveEmpty := func() int {
+		resp, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: statusCommand})
+		test.That(t, err, test.ShouldBeNil)
+		return resp["cameras"].([]interface{})[1].(map[string]interface{})["consecutive_empty"].(int)
+	}
+
+	for i := 0; i < emptyCloudWarnThreshold+2; i++ {
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 1)
+	}
+	test.That(t, consecutiveEmpty(), test.ShouldEqual, emptyCloudWarnThreshold+2)
+	test.That(t, logs.FilterMessageSnippet("camera cam2 has returned 10 empty point clouds in a row").Len(), test.ShouldEqual, 1)
+	test.That(t, logs.FilterMessageSnippet("camera cam1 has returned").Len(), test.ShouldEqual, 0)
+
+	atomic.StoreInt32(&empty, 0)
+	_, err = mergedCam.NextPointCloud(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, consecutiveEmpty(), test.ShouldEqual, 0)
+	test.That(t, logs.FilterMessageSnippet("camera cam2 returned points again").Len(), test.ShouldEqual, 1)
+}
+
+func TestICPRefine(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	misalignment := &spatialmath.OrientationVectorDegrees{OZ: 1, Theta: 1}
+	var misaligned []r3.Vector
+	for _, p := range latticePoints() {
+		misaligned = append(misaligned, spatialmath.Compose(spatialmath.NewPoseFromOrientation(misalignment), spatialmath.NewPoseFromPoint(p)).Point())
+	}
+	cameras := []camera.Camera{createMockCamera("cam1", latticePoints()), createMockCamera("cam2", misaligned)}
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+
+	reference := pointcloud.NewKDTree()
+	for _, p := range latticePoints() {
+		test.That(t, reference.Set(p, nil), test.ShouldBeNil)
+	}
+	// maxError returns how far the furthest point of cam2 is from the point of cam1 it should coincide with
+	maxError := func(pc pointcloud.PointCloud) float64 {
+		worst := 0.0
+		pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+			if d.Value() == 1 {
+				_, _, dist, _ := reference.NearestNeighbor(p)
+				worst = math.Max(worst, dist)
+			}
+			return true
+		})
+		return worst
+	}
+
+	for _, tc := range []struct {
+		name      string
+		icpRefine *ICPConfig
+		check     func(float64) bool
+	}{
+		{"misaligned cameras produce double points", nil, func(e float64) bool { return e > 10 }},
+		{"refined cameras overlap", &ICPConfig{}, func(e float64) bool { return e < 1e-6 }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mergedCam := &mergedCamera{logger: logger}
+			conf := resource.Config{ConvertedAttributes: &Config{
+				Cameras: []string{"cam1", "cam2"}, TagSource: true, ICPRefine: tc.icpRefine,
+			}}
+			test.That(t, mergedCam.Reconfigure(ctx, createDependencies(cameras, fsService), conf), test.ShouldBeNil)
+			pc, err := mergedCam.NextPointCloud(ctx)
+			test.That(t, err, test.ShouldBeNil)
+			test.That(t, tc.check(maxError(pc)), test.ShouldBeTrue)
+		})
+	}
+}
+
+func TestFrameSystemRetries(t *testing.T) {
+	ctx := context.Background()
+	cam1 := newFakeCamera("cam1", r3.Vector{Z: 10})
+	cam2 := newFakeCamera("cam2", r3.Vector{Z: 10})
+	cameras := []camera.Camera{cam1, cam2}
+
+	// newFlakyFrameSystem returns a frame system service whose calls fail while failures is positive, counting down
+	// with every failed call
+	newFlakyFrameSystem := func(t *testing.T) (*inject.FrameSystemService, *int32) {
+		fsService, err := newFakeFrameSystemService(map[string]spatialmath.Pose{
+			"cam1": spatialmath.NewZeroPose(),
+			"cam2": spatialmath.NewPoseFromPoint(r3.Vector{X: 100}),
+		})
+		test.That(t, err, test.ShouldBeNil)
+		var failures int32
+		fail := func() error {
+			if atomic.AddInt32(&failures, -1) >= 0 {
+				return errors.New("frame system timed out")
+			}
+			atomic.StoreInt32(&failures, 0)
+			return nil
+		}
+		transformPose, frameSystem := fsService.TransformPoseFunc, fsService.FrameSystemFunc
+		fsService.TransformPoseFunc = func(
+			ctx context.Context,
+			pose *referenceframe.PoseInFrame,
+			dst string,
+			additionalTransforms []*referenceframe.LinkInFrame,
+		) (*referenceframe.PoseInFrame, error) {
+			if err := fail(); err != nil {
+				return nil, err
+			}
+			return transformPose(ctx, pose, dst, additionalTransforms)
+		}
+		fsService.FrameSystemFunc = func(
+			ctx context.Context, additionalTransforms []*referenceframe.LinkInFrame,
+		) (referenceframe.FrameSystem, error) {
+			if err := fail(); err != nil {
+				return nil, err
+			}
+			return frameSystem(ctx, additionalTransforms)
+		}
+		return fsService, &failures
+	}
+
+	t.Run("retries transient failures", func(t *testing.T) {
+		fsService, failures := newFlakyFrameSystem(t)
+		merged := newTestMergedCamera(t, &Config{Cameras: []string{"cam1", "cam2"}, FrameSystemRetries: 2}, cameras, fsService)
+		// the first two transform calls fail
+		atomic.StoreInt32(failures, 2)
+		pc, err := merged.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+
+		// without last known transforms to fall back to, the merge fails once the retries run out
+		atomic.StoreInt32(failures, 100)
+		merged.transformCache = newTransformCache()
+		_, err = merged.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "frame system timed out")
+	})
+
+	t.Run("falls back to the last known transforms", func(t *testing.T) {
+		logger, logs := logging.NewObservedTestLogger(t)
+		fsService, failures := newFlakyFrameSystem(t)
+		merged := newTestMergedCameraWithLogger(t, &Config{Cameras: []string{"cam1", "cam2"}}, cameras, fsService, logger)
+		_, err := merged.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+
+		// once cleared, the transforms are resolved again and the last known ones are used while that fails
+		_, err = merged.DoCommand(ctx, map[string]interface{}{commandKey: clearTransformCacheCommand})
+		test.That(t, err, test.ShouldBeNil)
+		atomic.StoreInt32(failures, 100)
+		pc, err := merged.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+		_, ok := pc.At(100, 0, 10)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, logs.FilterMessageSnippet("using the last known transform of camera cam2").Len(), test.ShouldEqual, 1)
+	})
+
+	t.Run("does not fall back with dynamic frames", func(t *testing.T) {
+		fsService, failures := newFlakyFrameSystem(t)
+		cfg := &Config{Cameras: []string{"cam1", "cam2"}, DynamicFrames: true, FrameSystemRetries: 1}
+		merged := newTestMergedCamera(t, cfg, cameras, fsService)
+		_, err := merged.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+
+		// a single failure is retried
+		atomic.StoreInt32(failures, 1)
+		_, err = merged.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+
+		atomic.StoreInt32(failures, 100)
+		_, err = merged.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "frame system timed out")
+	})
+
+	t.Run("validate rejects negative retries", func(t *testing.T) {
+		cfg := &Config{Cameras: []string{"cam1", "cam2"}, FrameSystemRetries: -1}
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "frame_system_retries")
+	})
+}
+
+func TestTransformFailurePolicy(t *testing.T) {
+	ctx := context.Background()
+
+	cam1 := createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 1}})
+	dataFailure := createFailingCamera("cam2")
+	// cam3 returns data but has no frame, as for a camera whose frame has not been configured yet
+	transformFailure := createMockCamera("cam3", []r3.Vector{{X: 0, Y: 0, Z: 3}})
+	cameras := []camera.Camera{cam1, dataFailure, transformFailure}
+
+	reconfigured := func(t *testing.T, logger logging.Logger, names []string, errorPolicy string) *mergedCamera {
+		fsService, err := createFrameSystemService(ctx, cameras[:2], logger)
+		test.That(t, err, test.ShouldBeNil)
+		mergedCam := &mergedCamera{logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{Cameras: names, ErrorPolicy: errorPolicy}}
+		test.That(t, mergedCam.Reconfigure(ctx, createDependencies(cameras, fsService), conf), test.ShouldBeNil)
+		return mergedCam
+	}
+
+	t.Run("strict fails on a data failure", func(t *testing.T) {
+		mergedCam := reconfigured(t, logging.NewTestLogger(t), []string{"cam1", "cam2"}, errorPolicyStrict)
+		_, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "error getting point cloud from camera cam2")
+	})
+
+	t.Run("strict fails on a transform failure", func(t *testing.T) {
+		mergedCam := reconfigured(t, logging.NewTestLogger(t), []string{"cam1", "cam3"}, errorPolicyStrict)
+		_, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "issue getting tranform from camera cam3")
+		test.That(t, mergedCam.cameraStatuses["cam3"].lastError, test.ShouldNotBeNil)
+	})
+
+	t.Run("skip omits both failures and logs which is which", func(t *testing.T) {
+		logger, logs := logging.NewObservedTestLogger(t)
+		mergedCam := reconfigured(t, logger, []string{"cam1", "cam2", "cam3"}, errorPolicySkip)
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 1)
+		test.That(t, logs.FilterMessageSnippet("skipping camera cam2 after a data failure").Len(), test.ShouldEqual, 1)
+		test.That(t, logs.FilterMessageSnippet("skipping camera cam3 after a transform failure").Len(), test.ShouldEqual, 1)
+	})
+}
+
+func TestLogPipeline(t *testing.T) {
+	ctx := context.Background()
+	logger, logs := logging.NewObservedTestLogger(t)
+
+	cam1 := createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 1}, {X: 0, Y: 0, Z: 1.5}})
+	cam2 := createMockCamera("cam2", []r3.Vector{{X: 0, Y: 0, Z: 2}})
+	cameras := []camera.Camera{cam1, cam2}
+
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+	deps := createDependencies(cameras, fsService)
+
+	for _, logPipeline := range []bool{false, true} {
+		mergedCam := &mergedCamera{logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}, VoxelSize: 0.01, LogPipeline: logPipeline}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+		_, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+	}
+
+	fetched := logs.FilterMessage("merge pipeline fetched").AllUntimed()
+	test.That(t, len(fetched), test.ShouldEqual, 2)
+	test.That(t, fetched[0].ContextMap()["camera"], test.ShouldEqual, "cam1")
+	test.That(t, fetched[0].ContextMap()["points"], test.ShouldEqual, 2)
+	test.That(t, fetched[1].ContextMap()["camera"], test.ShouldEqual, "cam2")
+	test.That(t, fetched[1].ContextMap()["translation"], test.ShouldNotBeNil)
+
+	merges := logs.FilterMessage("merge pipeline merged").AllUntimed()
+	test.That(t, len(merges), test.ShouldEqual, 1)
+	test.That(t, merges[0].ContextMap()["strategy"], test.ShouldEqual, mergeStrategyRDK)
+	test.That(t, merges[0].ContextMap()["points"], test.ShouldEqual, 3)
+
+	filtered := logs.FilterMessage("merge pipeline filtered").AllUntimed()
+	test.That(t, len(filtered), test.ShouldEqual, 1)
+	test.That(t, filtered[0].ContextMap()["filter"], test.ShouldEqual, "voxel_size")
+
+	finished := logs.FilterMessage("merge pipeline finished").AllUntimed()
+	test.That(t, len(finished), test.ShouldEqual, 1)
+	// every point lies within a single voxel
+	test.That(t, finished[0].ContextMap()["points"], test.ShouldEqual, 1)
+}
+
+func TestMergeInFrame(t *testing.T) {
+	ctx := context.Background()
+	cam1 := newFakeCamera("cam1", r3.Vector{Z: 10})
+	cam2 := newFakeCamera("cam2", r3.Vector{Z: 10})
+	fsService, err := newFakeFrameSystemService(map[string]spatialmath.Pose{
+		"cam1":   spatialmath.NewZeroPose(),
+		"cam2":   spatialmath.NewPoseFromPoint(r3.Vector{X: 100}),
+		"object": spatialmath.NewPoseFromPoint(r3.Vector{X: 1000}),
+	})
+	test.That(t, err, test.ShouldBeNil)
+
+	t.Run("transforms every camera into the frame", func(t *testing.T) {
+		cfg := &Config{Cameras: []string{"cam1", "cam2"}, TargetFrame: "world", EnableCache: true}
+		merged := newTestMergedCamera(t, cfg, []camera.Camera{cam1, cam2}, fsService)
+		defer merged.Close(ctx)
+
+		pc, _, err := merged.mergeCameras(ctx, nil, "object")
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+		_, ok := pc.At(-1000, 0, 10)
+		test.That(t, ok, test.ShouldBeTrue)
+		_, ok = pc.At(-900, 0, 10)
+		test.That(t, ok, test.ShouldBeTrue)
+		// merges in another frame are not kept, and their transforms are not cached
+		test.That(t, merged.lastPointCloud, test.ShouldBeNil)
+		_, ok = merged.transformCache.get("cam2", "object")
+		test.That(t, ok, test.ShouldBeFalse)
+
+		pc, err = merged.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		_, ok = pc.At(100, 0, 10)
+		test.That(t, ok, test.ShouldBeTrue)
+	})
+
+	t.Run("places pose overrides in the frame", func(t *testing.T) {
+		cfg := &Config{
+			Cameras:      []string{"cam1", "cam2"},
+			TargetFrame:  "world",
+			PoseOverride: map[string]*PoseConfig{"cam2": {Translation: r3.Vector{X: 200}}},
+		}
+		merged := newTestMergedCamera(t, cfg, []camera.Camera{cam1, cam2}, fsService)
+		defer merged.Close(ctx)
+
+		pc, _, err := merged.mergeCameras(ctx, nil, "object")
+		test.That(t, err, test.ShouldBeNil)
+		_, ok := pc.At(-800, 0, 10)
+		test.That(t, ok, test.ShouldBeTrue)
+	})
+}
+
+func TestAllCamerasSkipped(t *testing.T) {
+	ctx := context.Background()
+	cam1 := newFakeCamera("cam1", r3.Vector{Z: 10})
+	cam2 := newFakeCamera("cam2", r3.Vector{Z: 10})
+	cam1.setError(errors.New("no data"))
+	cam2.setError(errors.New("no data"))
+	fsService, err := newFakeFrameSystemService(map[string]spatialmath.Pose{
+		"cam1": spatialmath.NewZeroPose(),
+		"cam2": spatialmath.NewZeroPose(),
+	})
+	test.That(t, err, test.ShouldBeNil)
+
+	cfg := &Config{Cameras: []string{"cam1", "cam2"}, ErrorPolicy: errorPolicySkip}
+	merged := newTestMergedCamera(t, cfg, []camera.Camera{cam1, cam2}, fsService)
+	defer merged.Close(ctx)
+
+	_, err = merged.NextPointCloud(ctx)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, errors.Is(err, ErrNoCameraData), test.ShouldBeTrue)
+	test.That(t, err.Error(), test.ShouldEqual, "skipped cameras [cam1 cam2]: all cameras failed or produced no data")
+
+	_, err = merged.mergeWithRDK(ctx, nil)
+	test.That(t, errors.Is(err, ErrNoCameraData), test.ShouldBeTrue)
+}
+
+func TestDegenerateTransform(t *testing.T) {
+	ctx := context.Background()
+	cam1 := newFakeCamera("cam1", r3.Vector{Z: 10})
+	cam2 := newFakeCamera("cam2", r3.Vector{Z: 10})
+	fsService, err := newFakeFrameSystemService(map[string]spatialmath.Pose{
+		"cam1": spatialmath.NewZeroPose(),
+		"cam2": spatialmath.NewZeroPose(),
+	})
+	test.That(t, err, test.ShouldBeNil)
+	transformPose := fsService.TransformPoseFunc
+	fsService.TransformPoseFunc = func(
+		ctx context.Context,
+		pose *referenceframe.PoseInFrame,
+		dst string,
+		additionalTransforms []*referenceframe.LinkInFrame,
+	) (*referenceframe.PoseInFrame, error) {
+		if pose.Parent() == "cam2" {
+			// a miscalibrated frame whose rotation scales every point
+			return referenceframe.NewPoseInFrame(dst, spatialmath.NewPose(r3.Vector{X: 100}, &spatialmath.Quaternion{Real: 2})), nil
+		}
+		return transformPose(ctx, pose, dst, additionalTransforms)
+	}
+
+	t.Run("strict fails naming the camera", func(t *testing.T) {
+		cfg := &Config{Cameras: []string{"cam1", "cam2"}, TargetFrame: "world"}
+		merged := newTestMergedCamera(t, cfg, []camera.Camera{cam1, cam2}, fsService)
+		defer merged.Close(ctx)
+
+		_, err := merged.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "invalid transform from camera cam2")
+		var mergeErr *MergeError
+		test.That(t, errors.As(err, &mergeErr), test.ShouldBeTrue)
+		failure, ok := mergeErr.Failed("cam2")
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, failure.Stage, test.ShouldEqual, stageTransform)
+	})
+
+	t.Run("skip omits the camera", func(t *testing.T) {
+		cfg := &Config{Cameras: []string{"cam1", "cam2"}, TargetFrame: "world", ErrorPolicy: errorPolicySkip}
+		merged := newTestMergedCamera(t, cfg, []camera.Camera{cam1, cam2}, fsService)
+		defer merged.Close(ctx)
+
+		pc, err := merged.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 1)
+		_, ok := pc.At(0, 0, 10)
+		test.That(t, ok, test.ShouldBeTrue)
+	})
+}
+
+func TestReconfigureUnchanged(t *testing.T) {
+	ctx := context.Background()
+	logger, logs := logging.NewObservedTestLogger(t)
+	cam1 := newFakeCamera("cam1", r3.Vector{Z: 10})
+	cam2 := newFakeCamera("cam2", r3.Vector{Z: 20})
+	fsService, err := newFakeFrameSystemService(map[string]spatialmath.Pose{
+		"cam1": spatialmath.NewZeroPose(),
+		"cam2": spatialmath.NewZeroPose(),
+	})
+	test.That(t, err, test.ShouldBeNil)
+	reconfigure := func(merged *mergedCamera, cameras []camera.Camera, cfg *Config) {
+		t.Helper()
+		conf := resource.Config{ConvertedAttributes: cfg}
+		test.That(t, merged.Reconfigure(ctx, createDependencies(cameras, fsService), conf), test.ShouldBeNil)
+	}
+
+	merged := &mergedCamera{Named: resource.NewName(camera.API, "merged").AsNamed(), logger: logger}
+	defer merged.Close(ctx)
+	reconfigure(merged, []camera.Camera{cam1, cam2}, &Config{Cameras: []string{"cam1", "cam2"}, VoxelSize: 0.01})
+	handles, cache := merged.cameraHandles, merged.transformCache
+
+	// an identical config in a new struct is not applied again
+	reconfigure(merged, []camera.Camera{cam1, cam2}, &Config{Cameras: []string{"cam1", "cam2"}, VoxelSize: 0.01})
+	test.That(t, logs.FilterMessage("config is unchanged, skipping reconfigure").Len(), test.ShouldEqual, 1)
+	test.That(t, logs.FilterMessageSnippet("reusing unchanged camera").Len(), test.ShouldEqual, 0)
+	test.That(t, merged.cameraHandles["cam1"], test.ShouldResemble, handles["cam1"])
+	test.That(t, merged.cameraHandles["cam2"].cam, test.ShouldEqual, cam2)
+	test.That(t, merged.transformCache, test.ShouldEqual, cache)
+	pc, err := merged.NextPointCloud(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pc.Size(), test.ShouldEqual, 2)
+
+	// a changed config is applied, reusing the unchanged cameras
+	reconfigure(merged, []camera.Camera{cam1, cam2}, &Config{Cameras: []string{"cam1", "cam2"}})
+	test.That(t, logs.FilterMessage("config is unchanged, skipping reconfigure").Len(), test.ShouldEqual, 1)
+	test.That(t, logs.FilterMessageSnippet("reusing unchanged camera").Len(), test.ShouldEqual, 2)
+	test.That(t, merged.transformCache, test.ShouldNotEqual, cache)
 
+	// so is an identical config whose dependencies hold a new camera
+	replaced := newFakeCamera("cam2", r3.Vector{Z: 30})
+	reconfigure(merged, []camera.Camera{cam1, replaced}, &Config{Cameras: []string{"cam1", "cam2"}})
+	test.That(t, logs.FilterMessage("config is unchanged, skipping reconfigure").Len(), test.ShouldEqual, 1)
+	test.That(t, merged.cameraHandles["cam2"].cam, test.ShouldEqual, replaced)
 }
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"time"
+
+	"go.viam.com/rdk/pointcloud"
+)
+
+const (
+	// bytesPerPosition is the size of the three float64 coordinates of a point.
+	bytesPerPosition = 24
+	// bytesPerColor is the size of the RGBA color of a point.
+	bytesPerColor = 4
+	// bytesPerValue is the size of the int value of a point.
+	bytesPerValue = 8
+)
+
+// durationWindow keeps the most recent maxCacheSize durations of an operation.
+type durationWindow struct {
+	samples []time.Duration
+	next    int
+}
+
+// add records a duration, replacing the oldest one once the window is full.
+func (w *durationWindow) add(d time.Duration) {
+	if len(w.samples) < maxCacheSize {
+		w.samples = append(w.samples, d)
+		return
+	}
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % maxCacheSize
+}
+
+// summary reports the last, min, avg and max durations of the window in milliseconds.
+func (w *durationWindow) summary() map[string]interface{} {
+	if len(w.samples) == 0 {
+		return map[string]interface{}{"count": 0}
+	}
+	last := w.samples[len(w.samples)-1]
+	if len(w.samples) == maxCacheSize {
+		last = w.samples[(w.next+maxCacheSize-1)%maxCacheSize]
+	}
+	minimum, maximum, total := w.samples[0], w.samples[0], time.Duration(0)
+	for _, d := range w.samples {
+		if d < minimum {
+			minimum = d
+		}
+		if d > maximum {
+			maximum = d
+		}
+		total += d
+	}
+	return map[string]interface{}{
+		"count":   len(w.samples),
+		"last_ms": milliseconds(last),
+		"min_ms":  milliseconds(minimum),
+		"avg_ms":  milliseconds(total / time.Duration(len(w.samples))),
+		"max_ms":  milliseconds(maximum),
+	}
+}
+
+// sizeWindow keeps the most recent maxCacheSize sizes in bytes.
+type sizeWindow struct {
+	samples []int
+	next    int
+}
+
+// add records a size, replacing the oldest one once the window is full.
+func (w *sizeWindow) add(size int) {
+	if len(w.samples) < maxCacheSize {
+		w.samples = append(w.samples, size)
+		return
+	}
+	w.samples[w.next] = size
+	w.next = (w.next + 1) % maxCacheSize
+}
+
+// peak returns the largest size of the window, zero when it is empty.
+func (w *sizeWindow) peak() int {
+	peak := 0
+	for _, size := range w.samples {
+		if size > peak {
+			peak = size
+		}
+	}
+	return peak
+}
+
+// estimatedBytes estimates the memory held by the points of the cloud from the data channels it carries. Overhead
+// of the cloud's own structure, such as map buckets or octree nodes, is not included.
+func estimatedBytes(pc pointcloud.PointCloud) int {
+	perPoint := bytesPerPosition
+	meta := pc.MetaData()
+	if meta.HasColor {
+		perPoint += bytesPerColor
+	}
+	if meta.HasValue {
+		perPoint += bytesPerValue
+	}
+	return pc.Size() * perPoint
+}
+
+// milliseconds converts the duration to fractional milliseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// cameraMetrics holds the timings of a single camera over recent merges.
+type cameraMetrics struct {
+	pointCount int
+	fetch      durationWindow
+	transform  durationWindow
+}
+
+// mergeMetrics holds the timings and sizes of recent successful merges.
+type mergeMetrics struct {
+	cameras    map[string]*cameraMetrics
+	merge      durationWindow
+	pointCount int
+	// estimatedBytes is the estimated size of the most recent merged cloud, see estimatedBytes.
+	estimatedBytes int
+	sizes          sizeWindow
+}
+
+func newMergeMetrics() *mergeMetrics {
+	return &mergeMetrics{cameras: make(map[string]*cameraMetrics)}
+}
+
+// record adds the timings and size of a successful merge. Only cameras that contributed to the merge are recorded.
+func (m *mergeMetrics) record(results []cameraResult, mergeDuration time.Duration, merged pointcloud.PointCloud) {
+	for _, result := range results {
+		if result.err != nil {
+			continue
+		}
+		camMetrics, ok := m.cameras[result.name]
+		if !ok {
+			camMetrics = &cameraMetrics{}
+			m.cameras[result.name] = camMetrics
+		}
+		camMetrics.pointCount = result.pointCount()
+		camMetrics.fetch.add(result.fetchDuration)
+		camMetrics.transform.add(result.transformDuration)
+	}
+	m.merge.add(mergeDuration)
+	m.pointCount = merged.Size()
+	m.estimatedBytes = estimatedBytes(merged)
+	m.sizes.add(m.estimatedBytes)
+}
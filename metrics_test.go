@@ -0,0 +1,61 @@
+package main
+
+import (
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/test"
+)
+
+func TestDurationWindow(t *testing.T) {
+	var w durationWindow
+	test.That(t, w.summary(), test.ShouldResemble, map[string]interface{}{"count": 0})
+
+	w.add(2 * time.Millisecond)
+	w.add(4 * time.Millisecond)
+	summary := w.summary()
+	test.That(t, summary["count"], test.ShouldEqual, 2)
+	test.That(t, summary["last_ms"], test.ShouldEqual, 4.0)
+	test.That(t, summary["min_ms"], test.ShouldEqual, 2.0)
+	test.That(t, summary["avg_ms"], test.ShouldEqual, 3.0)
+	test.That(t, summary["max_ms"], test.ShouldEqual, 4.0)
+
+	// once full the oldest durations are replaced
+	for i := 0; i < maxCacheSize; i++ {
+		w.add(time.Duration(10+i) * time.Millisecond)
+	}
+	summary = w.summary()
+	test.That(t, summary["count"], test.ShouldEqual, maxCacheSize)
+	test.That(t, summary["last_ms"], test.ShouldEqual, float64(10+maxCacheSize-1))
+	test.That(t, summary["min_ms"], test.ShouldEqual, 10.0)
+	test.That(t, summary["max_ms"], test.ShouldEqual, float64(10+maxCacheSize-1))
+}
+
+func TestEstimatedBytes(t *testing.T) {
+	pc := pointcloud.New()
+	test.That(t, estimatedBytes(pc), test.ShouldEqual, 0)
+	test.That(t, pc.Set(r3.Vector{X: 1}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, estimatedBytes(pc), test.ShouldEqual, bytesPerPosition)
+
+	test.That(t, pc.Set(r3.Vector{X: 2}, pointcloud.NewColoredData(color.NRGBA{R: 1, A: 255})), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 3}, pointcloud.NewValueData(1)), test.ShouldBeNil)
+	test.That(t, estimatedBytes(pc), test.ShouldEqual, 3*(bytesPerPosition+bytesPerColor+bytesPerValue))
+}
+
+func TestSizeWindow(t *testing.T) {
+	var w sizeWindow
+	test.That(t, w.peak(), test.ShouldEqual, 0)
+	w.add(10)
+	w.add(30)
+	w.add(20)
+	test.That(t, w.peak(), test.ShouldEqual, 30)
+
+	// the peak falls once it leaves the window
+	for i := 0; i < maxCacheSize; i++ {
+		w.add(5)
+	}
+	test.That(t, w.peak(), test.ShouldEqual, 5)
+}
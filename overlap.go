@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"math"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/pointcloud"
+)
+
+const (
+	// defaultOverlapDistance is the distance in millimeters beyond which a point is taken to have no counterpart in
+	// the other camera's cloud when checking overlap.
+	defaultOverlapDistance = 50.0
+	// overlapSamplePoints bounds the points of each camera queried against the other camera of a pair.
+	overlapSamplePoints = 5000
+	// minOverlapPoints is the number of points with a counterpart two cameras need to be reported as overlapping.
+	minOverlapPoints = 10
+)
+
+// overlapCloud is the cloud of a camera transformed into the target frame, with its points hashed into voxels of
+// the maximum distance so that every neighbor within that distance is in one of the 27 voxels around a point.
+type overlapCloud struct {
+	name      string
+	points    []r3.Vector
+	voxelSize float64
+	voxels    map[voxelKey][]r3.Vector
+	min       r3.Vector
+	max       r3.Vector
+}
+
+// newOverlapCloud transforms the cloud of the result into the target frame, hashing it for neighbor queries up to
+// maxDistance.
+func newOverlapCloud(result cameraResult, maxDistance float64) (*overlapCloud, error) {
+	transformed := pointcloud.NewWithPrealloc(result.pc.Size())
+	if err := appendTransformed(transformed, result.pc, result.pose); err != nil {
+		return nil, err
+	}
+	cloud := &overlapCloud{
+		name:      result.name,
+		points:    make([]r3.Vector, 0, transformed.Size()),
+		voxelSize: maxDistance,
+		voxels:    make(map[voxelKey][]r3.Vector),
+	}
+	transformed.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		cloud.points = append(cloud.points, p)
+		key := newVoxelKey(p, maxDistance)
+		cloud.voxels[key] = append(cloud.voxels[key], p)
+		return true
+	})
+	meta := transformed.MetaData()
+	cloud.min = r3.Vector{X: meta.MinX, Y: meta.MinY, Z: meta.MinZ}
+	cloud.max = r3.Vector{X: meta.MaxX, Y: meta.MaxY, Z: meta.MaxZ}
+	return cloud, nil
+}
+
+// nearest returns the distance from p to the nearest point of the cloud, false when no point is within the voxel
+// size.
+func (cloud *overlapCloud) nearest(p r3.Vector) (float64, bool) {
+	center := newVoxelKey(p, cloud.voxelSize)
+	best := math.Inf(1)
+	for dx := int64(-1); dx <= 1; dx++ {
+		for dy := int64(-1); dy <= 1; dy++ {
+			for dz := int64(-1); dz <= 1; dz++ {
+				for _, q := range cloud.voxels[voxelKey{x: center.x + dx, y: center.y + dy, z: center.z + dz}] {
+					if dist := p.Distance(q); dist < best {
+						best = dist
+					}
+				}
+			}
+		}
+	}
+	return best, best <= cloud.voxelSize
+}
+
+// distancesTo returns the distance from the points of the cloud inside the box to their nearest neighbor in the
+// other cloud, leaving out those beyond the voxel size of the other cloud. At most overlapSamplePoints points, picked
+// at even intervals, are queried.
+func (cloud *overlapCloud) distancesTo(other *overlapCloud, boxMin, boxMax r3.Vector) []float64 {
+	var inside []r3.Vector
+	for _, p := range cloud.points {
+		if p.X >= boxMin.X && p.X <= boxMax.X && p.Y >= boxMin.Y && p.Y <= boxMax.Y && p.Z >= boxMin.Z && p.Z <= boxMax.Z {
+			inside = append(inside, p)
+		}
+	}
+	step := 1.0
+	if len(inside) > overlapSamplePoints {
+		step = float64(len(inside)) / overlapSamplePoints
+	}
+	var distances []float64
+	for i := 0.0; int(i) < len(inside); i += step {
+		if dist, ok := other.nearest(inside[int(i)]); ok {
+			distances = append(distances, dist)
+		}
+	}
+	return distances
+}
+
+// overlapAgreement measures how well two clouds, hashed for maxDistance, agree where they overlap. The overlap region
+// is the intersection of their bounding boxes, and within it every sampled point of either cloud with a counterpart
+// in the other cloud within maxDistance contributes its distance to that counterpart. It returns the number of such
+// points and their mean distance. Along an axis where the boxes are apart by at most maxDistance, as for two views of
+// a wall that disagree on its depth, the region spans the gap between them instead. Growing the region any further
+// would count points near its edges, whose nearest neighbors lie off to the side rather than across the gap.
+func overlapAgreement(a, b *overlapCloud, maxDistance float64) (int, float64) {
+	lo := [3]float64{math.Max(a.min.X, b.min.X), math.Max(a.min.Y, b.min.Y), math.Max(a.min.Z, b.min.Z)}
+	hi := [3]float64{math.Min(a.max.X, b.max.X), math.Min(a.max.Y, b.max.Y), math.Min(a.max.Z, b.max.Z)}
+	for axis := range lo {
+		if lo[axis] > hi[axis] {
+			if lo[axis]-hi[axis] > maxDistance {
+				return 0, 0
+			}
+			lo[axis], hi[axis] = hi[axis], lo[axis]
+		}
+	}
+	boxMin := r3.Vector{X: lo[0], Y: lo[1], Z: lo[2]}
+	boxMax := r3.Vector{X: hi[0], Y: hi[1], Z: hi[2]}
+	distances := append(a.distancesTo(b, boxMin, boxMax), b.distancesTo(a, boxMin, boxMax)...)
+	if len(distances) == 0 {
+		return 0, 0
+	}
+	total := 0.0
+	for _, dist := range distances {
+		total += dist
+	}
+	return len(distances), total / float64(len(distances))
+}
+
+// checkOverlap fetches and transforms the cloud of every enabled camera like a merge would, then reports the
+// agreement of every pair of cameras, in config order, as computed by overlapAgreement. Pairs with fewer than
+// minOverlapPoints points in common are reported as not overlapping.
+func (merged *mergedCamera) checkOverlap(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	maxDistance := defaultOverlapDistance
+	if raw, ok := cmd[maxDistanceKey]; ok {
+		value, ok := raw.(float64)
+		if !ok || value <= 0 {
+			return nil, errors.Errorf("%q must be a positive number", maxDistanceKey)
+		}
+		maxDistance = value
+	}
+
+	merged.mu.RLock()
+	defer merged.mu.RUnlock()
+	if merged.closed {
+		return nil, errors.Wrap(ErrClosed, "cannot check overlap")
+	}
+
+	results := merged.fetchPointClouds(ctx, nil)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, errors.New("every camera is disabled")
+	}
+	merged.resolveTransforms(ctx, results, maxConcurrentFetches, merged.targetFrame)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if merged.errorPolicy != errorPolicySkip {
+		if mergeErr := newMergeError(results); mergeErr != nil {
+			return nil, mergeErr
+		}
+	}
+
+	var clouds []*overlapCloud
+	skipped := []interface{}{}
+	for _, result := range results {
+		if result.err != nil {
+			skipped = append(skipped, result.name)
+			continue
+		}
+		cloud, err := newOverlapCloud(result, maxDistance)
+		if err != nil {
+			return nil, errors.Wrapf(err, "issue transforming pointcloud from camera %v", result.name)
+		}
+		clouds = append(clouds, cloud)
+	}
+
+	pairs := []interface{}{}
+	for i := range clouds {
+		for j := i + 1; j < len(clouds); j++ {
+			count, mean := overlapAgreement(clouds[i], clouds[j], maxDistance)
+			pair := map[string]interface{}{
+				"cameras":        []interface{}{clouds[i].name, clouds[j].name},
+				"overlapping":    count >= minOverlapPoints,
+				"overlap_points": count,
+			}
+			if count >= minOverlapPoints {
+				pair["mean_distance_mm"] = mean
+			}
+			pairs = append(pairs, pair)
+		}
+	}
+	return map[string]interface{}{maxDistanceKey: maxDistance, "pairs": pairs, "skipped": skipped}, nil
+}
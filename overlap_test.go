@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/test"
+)
+
+// wallPoints returns a 200mm square grid of points 5mm apart in the plane z = depth, offset along x.
+func wallPoints(offsetX, depth float64) []r3.Vector {
+	var points []r3.Vector
+	for x := 0.0; x < 200; x += 5 {
+		for y := 0.0; y < 200; y += 5 {
+			points = append(points, r3.Vector{X: x + offsetX, Y: y, Z: depth})
+		}
+	}
+	return points
+}
+
+func TestOverlapAgreement(t *testing.T) {
+	cloud := func(name string, points []r3.Vector, pose spatialmath.Pose) *overlapCloud {
+		cam := newFakeCamera(name, points...)
+		overlap, err := newOverlapCloud(cameraResult{name: name, pc: cam.pc, pose: pose}, 50)
+		test.That(t, err, test.ShouldBeNil)
+		return overlap
+	}
+	reference := cloud("a", wallPoints(0, 1000), spatialmath.NewZeroPose())
+
+	// the same wall seen 3mm deeper agrees to within 3mm everywhere
+	count, mean := overlapAgreement(reference, cloud("b", wallPoints(100, 1003), spatialmath.NewZeroPose()), 50)
+	test.That(t, count, test.ShouldBeGreaterThan, minOverlapPoints)
+	test.That(t, mean, test.ShouldAlmostEqual, 3, 1e-6)
+
+	// a wall 40mm away is flagged by a larger distance
+	_, mean = overlapAgreement(reference, cloud("c", wallPoints(100, 960), spatialmath.NewZeroPose()), 50)
+	test.That(t, mean, test.ShouldAlmostEqual, 40, 1e-6)
+
+	// beyond the maximum distance the clouds do not overlap at all
+	count, _ = overlapAgreement(reference, cloud("d", wallPoints(0, 1100), spatialmath.NewZeroPose()), 50)
+	test.That(t, count, test.ShouldEqual, 0)
+	count, _ = overlapAgreement(reference, cloud("e", wallPoints(1000, 1000), spatialmath.NewZeroPose()), 50)
+	test.That(t, count, test.ShouldEqual, 0)
+}
+
+func TestCheckOverlap(t *testing.T) {
+	ctx := context.Background()
+
+	// cam2 is mounted 100mm along x and sees the same wall, but its pose override is off by 10mm in depth
+	cam1 := newFakeCamera("cam1", wallPoints(0, 1000)...)
+	cam2 := newFakeCamera("cam2", wallPoints(0, 1000)...)
+	cam3 := newFakeCamera("cam3", wallPoints(0, 1000)...)
+	fsService, err := newFakeFrameSystemService(map[string]spatialmath.Pose{"cam1": spatialmath.NewZeroPose()})
+	test.That(t, err, test.ShouldBeNil)
+	cameras := []camera.Camera{cam1, cam2, cam3}
+	mergedCam := newTestMergedCamera(t, &Config{
+		Cameras: []string{"cam1", "cam2", "cam3"},
+		PoseOverride: map[string]*PoseConfig{
+			"cam2": {Translation: r3.Vector{X: 100, Z: 10}},
+			"cam3": {Translation: r3.Vector{X: 5000}},
+		},
+	}, cameras, fsService)
+
+	resp, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: checkOverlapCommand})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp[maxDistanceKey], test.ShouldEqual, defaultOverlapDistance)
+	test.That(t, resp["skipped"], test.ShouldBeEmpty)
+	pairs := resp["pairs"].([]interface{})
+	test.That(t, pairs, test.ShouldHaveLength, 3)
+
+	first := pairs[0].(map[string]interface{})
+	test.That(t, first["cameras"], test.ShouldResemble, []interface{}{"cam1", "cam2"})
+	test.That(t, first["overlapping"], test.ShouldBeTrue)
+	test.That(t, first["mean_distance_mm"], test.ShouldAlmostEqual, 10, 1e-6)
+	for _, pair := range pairs[1:] {
+		test.That(t, pair.(map[string]interface{})["overlapping"], test.ShouldBeFalse)
+		_, ok := pair.(map[string]interface{})["mean_distance_mm"]
+		test.That(t, ok, test.ShouldBeFalse)
+	}
+
+	// a tighter maximum distance leaves the miscalibrated cameras without any overlap
+	resp, err = mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: checkOverlapCommand, maxDistanceKey: 5.0})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["pairs"].([]interface{})[0].(map[string]interface{})["overlapping"], test.ShouldBeFalse)
+
+	_, err = mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: checkOverlapCommand, maxDistanceKey: -1.0})
+	test.That(t, err, test.ShouldNotBeNil)
+
+	// failed cameras fail the command under the strict policy
+	cam3.setError(errors.New("boom"))
+	_, err = mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: checkOverlapCommand})
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "boom")
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// pipelineStage is a step of a merge along with the point counts it saw and how long it took.
+type pipelineStage struct {
+	name      string
+	pointsIn  int
+	pointsOut int
+	duration  time.Duration
+}
+
+// pipelineTrace records the stages a merge went through. Every stage starts when the previous one ended, so the
+// durations of the stages add up to the duration of the merge.
+type pipelineTrace struct {
+	stages     []pipelineStage
+	cameras    []cameraResult
+	capturedAt time.Time
+	last       time.Time
+	points     int
+}
+
+// newPipelineTrace returns a trace whose first stage starts at the given time.
+func newPipelineTrace(start time.Time) *pipelineTrace {
+	return &pipelineTrace{last: start}
+}
+
+// record ends a stage that left the given number of points, taking the points left by the previous stage as its input.
+func (trace *pipelineTrace) record(name string, points int) {
+	now := time.Now()
+	trace.stages = append(trace.stages, pipelineStage{
+		name:      name,
+		pointsIn:  trace.points,
+		pointsOut: points,
+		duration:  now.Sub(trace.last),
+	})
+	trace.last = now
+	trace.points = points
+}
+
+// finish records the cameras fetched by the merge, without their point clouds so that the trace holds no points,
+// and the capture time of the merged cloud.
+func (trace *pipelineTrace) finish(results []cameraResult, capturedAt time.Time) {
+	trace.cameras = make([]cameraResult, len(results))
+	for i, result := range results {
+		result.streamedPoints = result.pointCount()
+		result.pc, result.pose = nil, nil
+		trace.cameras[i] = result
+	}
+	trace.capturedAt = capturedAt
+}
+
+// response returns the trace as reported by "last_pipeline".
+func (trace *pipelineTrace) response() map[string]interface{} {
+	stages := make([]interface{}, 0, len(trace.stages))
+	var total time.Duration
+	for _, stage := range trace.stages {
+		stages = append(stages, map[string]interface{}{
+			"stage":       stage.name,
+			"points_in":   stage.pointsIn,
+			"points_out":  stage.pointsOut,
+			"duration_ms": milliseconds(stage.duration),
+		})
+		total += stage.duration
+	}
+	cameras := make([]interface{}, 0, len(trace.cameras))
+	for _, result := range trace.cameras {
+		entry := map[string]interface{}{
+			"name":         result.name,
+			"points":       result.pointCount(),
+			"fetch_ms":     milliseconds(result.fetchDuration),
+			"transform_ms": milliseconds(result.transformDuration),
+		}
+		if result.err != nil {
+			entry["error"] = result.err.Error()
+			entry["stage"] = result.stage()
+		}
+		cameras = append(cameras, entry)
+	}
+	return map[string]interface{}{
+		"stages":      stages,
+		"cameras":     cameras,
+		"points":      trace.points,
+		"total_ms":    milliseconds(total),
+		"captured_at": formatTime(trace.capturedAt),
+	}
+}
+
+// lastPipeline reports the stages of the most recent merge of every camera.
+func (merged *mergedCamera) lastPipeline() (map[string]interface{}, error) {
+	merged.stateMu.Lock()
+	defer merged.stateMu.Unlock()
+	if merged.lastTrace == nil {
+		return nil, errors.New("no merged point cloud has been produced yet")
+	}
+	return merged.lastTrace.response(), nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/test"
+)
+
+func TestPipelineTrace(t *testing.T) {
+	trace := newPipelineTrace(time.Now().Add(-time.Second))
+	trace.record("fetch", 10)
+	trace.record("merge", 10)
+	trace.record("voxel_size", 4)
+	test.That(t, len(trace.stages), test.ShouldEqual, 3)
+	test.That(t, trace.stages[0].duration, test.ShouldBeGreaterThanOrEqualTo, time.Second)
+	test.That(t, trace.stages[2].pointsIn, test.ShouldEqual, 10)
+	test.That(t, trace.stages[2].pointsOut, test.ShouldEqual, 4)
+
+	resp := trace.response()
+	test.That(t, resp["points"], test.ShouldEqual, 4)
+	test.That(t, resp["total_ms"], test.ShouldBeGreaterThanOrEqualTo, 1000.0)
+	stages := resp["stages"].([]interface{})
+	test.That(t, stages[0].(map[string]interface{})["stage"], test.ShouldEqual, "fetch")
+	test.That(t, stages[0].(map[string]interface{})["points_in"], test.ShouldEqual, 0)
+}
+
+func TestLastPipeline(t *testing.T) {
+	ctx := context.Background()
+	cameras := []camera.Camera{
+		newFakeCamera("cam1", r3.Vector{X: 0}, r3.Vector{X: 1}, r3.Vector{X: 2}),
+		newFakeCamera("cam2", r3.Vector{X: 500}),
+	}
+	fsService, err := newFakeFrameSystemService(map[string]spatialmath.Pose{
+		"cam1": spatialmath.NewZeroPose(),
+		"cam2": spatialmath.NewZeroPose(),
+	})
+	test.That(t, err, test.ShouldBeNil)
+
+	mergedCam := newTestMergedCamera(t, &Config{Cameras: []string{"cam1", "cam2"}, VoxelSize: 0.01}, cameras, fsService)
+	defer mergedCam.Close(ctx)
+	_, err = mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: lastPipelineCommand})
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "no merged point cloud")
+
+	pc, err := mergedCam.NextPointCloud(ctx)
+	test.That(t, err, test.ShouldBeNil)
+
+	resp, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: lastPipelineCommand})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["points"], test.ShouldEqual, pc.Size())
+	stages := resp["stages"].([]interface{})
+	var names []string
+	for _, stage := range stages {
+		names = append(names, stage.(map[string]interface{})["stage"].(string))
+	}
+	test.That(t, names, test.ShouldResemble, []string{"fetch", "merge", "voxel_size"})
+	voxel := stages[2].(map[string]interface{})
+	test.That(t, voxel["points_in"], test.ShouldEqual, 4)
+	test.That(t, voxel["points_out"], test.ShouldEqual, 2)
+	test.That(t, voxel["duration_ms"], test.ShouldBeGreaterThanOrEqualTo, 0.0)
+	cams := resp["cameras"].([]interface{})
+	test.That(t, len(cams), test.ShouldEqual, 2)
+	test.That(t, cams[0].(map[string]interface{})["points"], test.ShouldEqual, 3)
+
+	// merges of a subset of the cameras do not replace the pipeline of the last full merge
+	_, err = mergedCam.DoCommand(ctx, map[string]interface{}{
+		commandKey: nextSubsetCommand, camerasKey: []interface{}{"cam2"},
+	})
+	test.That(t, err, test.ShouldBeNil)
+	again, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: lastPipelineCommand})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, again["captured_at"], test.ShouldEqual, resp["captured_at"])
+}
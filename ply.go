@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/pointcloud"
+)
+
+// writePLY encodes the cloud as an ASCII PLY file. The RDK has no PLY encoder, so this writes the minimal subset of
+// the format covering positions and colors. Like pointcloud.ToPCD, positions are converted to meters.
+func writePLY(pc pointcloud.PointCloud, out io.Writer) error {
+	w := bufio.NewWriter(out)
+	hasColor := pc.MetaData().HasColor
+
+	fmt.Fprintf(w, "ply\nformat ascii 1.0\nelement vertex %d\n", pc.Size())
+	fmt.Fprint(w, "property float x\nproperty float y\nproperty float z\n")
+	if hasColor {
+		fmt.Fprint(w, "property uchar red\nproperty uchar green\nproperty uchar blue\n")
+	}
+	fmt.Fprint(w, "end_header\n")
+
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		fmt.Fprintf(w, "%g %g %g", p.X/mmPerMeter, p.Y/mmPerMeter, p.Z/mmPerMeter)
+		if hasColor {
+			var r, g, b uint8
+			if d != nil && d.HasColor() {
+				r, g, b = d.RGB255()
+			}
+			fmt.Fprintf(w, " %d %d %d", r, g, b)
+		}
+		fmt.Fprint(w, "\n")
+		return true
+	})
+	return w.Flush()
+}
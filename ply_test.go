@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"image/color"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/test"
+)
+
+func TestWritePLY(t *testing.T) {
+	pc := pointcloud.New()
+	test.That(t, pc.Set(r3.Vector{X: 1000, Y: 500, Z: -250}, pointcloud.NewColoredData(color.NRGBA{R: 255, G: 10, B: 1, A: 255})),
+		test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 0, Y: 0, Z: 2000}, pointcloud.NewBasicData()), test.ShouldBeNil)
+
+	var buf bytes.Buffer
+	test.That(t, writePLY(pc, &buf), test.ShouldBeNil)
+	test.That(t, buf.String(), test.ShouldEqual, "ply\n"+
+		"format ascii 1.0\n"+
+		"element vertex 2\n"+
+		"property float x\n"+
+		"property float y\n"+
+		"property float z\n"+
+		"property uchar red\n"+
+		"property uchar green\n"+
+		"property uchar blue\n"+
+		"end_header\n"+
+		"1 0.5 -0.25 255 10 1\n"+
+		"0 0 2 0 0 0\n")
+
+	uncolored := pointcloud.New()
+	test.That(t, uncolored.Set(r3.Vector{X: 1}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	buf.Reset()
+	test.That(t, writePLY(uncolored, &buf), test.ShouldBeNil)
+	test.That(t, buf.String(), test.ShouldNotContainSubstring, "red")
+	test.That(t, buf.String(), test.ShouldEndWith, "end_header\n0.001 0 0\n")
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// unitQuaternionTolerance is how far from one the length of a configured or resolved quaternion may be.
+const unitQuaternionTolerance = 1e-3
+
+// PoseConfig describes a pose by a translation in millimeters and an orientation, in the same format used by
+// frame configs.
+type PoseConfig struct {
+	Translation r3.Vector                      `json:"translation"`
+	Orientation *spatialmath.OrientationConfig `json:"orientation,omitempty"`
+}
+
+// Pose parses the config into a pose. A missing orientation is treated as the zero orientation. Quaternions must
+// have unit length rather than being normalized, since a far from unit quaternion is usually a typo, and axis
+// angles must have a nonzero axis.
+func (cfg *PoseConfig) Pose() (spatialmath.Pose, error) {
+	if cfg.Orientation == nil {
+		return spatialmath.NewPoseFromPoint(cfg.Translation), nil
+	}
+	switch cfg.Orientation.Type {
+	case spatialmath.QuaternionType:
+		var q struct{ W, X, Y, Z float64 }
+		if err := json.Unmarshal(cfg.Orientation.Value, &q); err != nil {
+			return nil, errors.Wrap(err, "invalid orientation")
+		}
+		if norm := math.Sqrt(q.W*q.W + q.X*q.X + q.Y*q.Y + q.Z*q.Z); math.Abs(norm-1) > unitQuaternionTolerance {
+			return nil, errors.Errorf("orientation quaternion must have unit length, got length %v", norm)
+		}
+	case spatialmath.AxisAnglesType:
+		// spatialmath panics when converting an axis angle about a zero axis
+		var aa spatialmath.R4AA
+		if err := json.Unmarshal(cfg.Orientation.Value, &aa); err != nil {
+			return nil, errors.Wrap(err, "invalid orientation")
+		}
+		if aa.RX == 0 && aa.RY == 0 && aa.RZ == 0 {
+			return nil, errors.Errorf("orientation %s does not describe a rotation since its axis is zero", cfg.Orientation.Value)
+		}
+	}
+	orientation, err := cfg.Orientation.ParseConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid orientation")
+	}
+	return spatialmath.NewPose(cfg.Translation, orientation), nil
+}
+
+// validatePose checks that the translation of a resolved pose is finite and that its orientation quaternion has unit
+// length, as a rotation does. A miscalibrated frame can produce either, which would corrupt every point it transforms.
+func validatePose(pose spatialmath.Pose) error {
+	if !isFinite(pose.Point()) {
+		return errors.Errorf("translation %v is not finite", pose.Point())
+	}
+	q := pose.Orientation().Quaternion()
+	norm := math.Sqrt(q.Real*q.Real + q.Imag*q.Imag + q.Jmag*q.Jmag + q.Kmag*q.Kmag)
+	if math.IsNaN(norm) || math.Abs(norm-1) > unitQuaternionTolerance {
+		return errors.Errorf("orientation quaternion must have unit length, got length %v", norm)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/test"
+)
+
+func TestPoseConfig(t *testing.T) {
+	t.Run("translation only", func(t *testing.T) {
+		cfg := &PoseConfig{Translation: r3.Vector{X: 1, Y: 2, Z: 3}}
+		pose, err := cfg.Pose()
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, spatialmath.PoseAlmostEqual(pose, spatialmath.NewPoseFromPoint(r3.Vector{X: 1, Y: 2, Z: 3})), test.ShouldBeTrue)
+	})
+
+	t.Run("orientation vector", func(t *testing.T) {
+		cfg := &PoseConfig{Orientation: &spatialmath.OrientationConfig{
+			Type:  spatialmath.OrientationVectorDegreesType,
+			Value: json.RawMessage(`{"x": 0, "y": 0, "z": 1, "th": 90}`),
+		}}
+		pose, err := cfg.Pose()
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pose.Orientation().OrientationVectorDegrees().Theta, test.ShouldAlmostEqual, 90)
+	})
+
+	t.Run("quaternions must have unit length", func(t *testing.T) {
+		cfg := &PoseConfig{Orientation: &spatialmath.OrientationConfig{
+			Type:  spatialmath.QuaternionType,
+			Value: json.RawMessage(`{"w": 0.7071068, "x": 0, "y": 0, "z": 0.7071068}`),
+		}}
+		pose, err := cfg.Pose()
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pose.Orientation().OrientationVectorDegrees().Theta, test.ShouldAlmostEqual, 90, 1e-3)
+
+		cfg.Orientation.Value = json.RawMessage(`{"w": 1, "x": 0, "y": 0, "z": 1}`)
+		_, err = cfg.Pose()
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "unit length")
+	})
+
+	t.Run("orientations must describe a rotation", func(t *testing.T) {
+		cfg := &PoseConfig{Orientation: &spatialmath.OrientationConfig{
+			Type:  spatialmath.AxisAnglesType,
+			Value: json.RawMessage(`{"x": 0, "y": 0, "z": 0, "th": 1}`),
+		}}
+		_, err := cfg.Pose()
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "does not describe a rotation")
+	})
+
+	t.Run("invalid orientation", func(t *testing.T) {
+		cfg := &PoseConfig{Orientation: &spatialmath.OrientationConfig{Type: "fake"}}
+		_, err := cfg.Pose()
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+}
+
+func TestValidatePose(t *testing.T) {
+	test.That(t, validatePose(spatialmath.NewPoseFromPoint(r3.Vector{X: 1})), test.ShouldBeNil)
+	test.That(t, validatePose(spatialmath.NewPose(r3.Vector{}, &spatialmath.OrientationVectorDegrees{OZ: 1, Theta: 90})),
+		test.ShouldBeNil)
+
+	err := validatePose(spatialmath.NewPoseFromPoint(r3.Vector{X: math.NaN()}))
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "is not finite")
+
+	err = validatePose(spatialmath.NewPose(r3.Vector{}, &spatialmath.Quaternion{Real: 2}))
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "got length 2")
+
+	err = validatePose(spatialmath.NewPose(r3.Vector{}, &spatialmath.Quaternion{Real: math.NaN()}))
+	test.That(t, err, test.ShouldNotBeNil)
+}
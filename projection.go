@@ -0,0 +1,360 @@
+package main
+
+import (
+	"context"
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/rimage/transform"
+)
+
+const (
+	// projectionModePerspective renders a depth image seen by a pinhole camera at the origin of the target frame.
+	projectionModePerspective = "perspective"
+	// projectionModeTopDown renders a height image looking down the Z axis of the target frame.
+	projectionModeTopDown = "orthographic_topdown"
+
+	// defaultFrameRate is the rate in frames per second of Stream when frame_rate is unset.
+	defaultFrameRate = 5.0
+)
+
+// ProjectionConfig describes how the merged point cloud is rendered into images.
+type ProjectionConfig struct {
+	// Mode is either "perspective" (default) or "orthographic_topdown".
+	Mode string `json:"mode,omitempty"`
+	// Intrinsics of the virtual pinhole camera placed at the origin of the target frame. The width and height
+	// set the resolution of the rendered image.
+	Intrinsics *transform.PinholeCameraIntrinsics `json:"intrinsics,omitempty"`
+	// Resolution is the size of a top-down image pixel in millimeters.
+	Resolution float64 `json:"resolution,omitempty"`
+	// Extent is the region of the target frame rendered top-down. Its X and Y bounds set the image size and points
+	// outside its Z bounds are ignored.
+	Extent *BoxConfig `json:"extent,omitempty"`
+	// IntrinsicsCamera is the source camera whose intrinsics are used when none are configured, the camera whose
+	// frame is the target frame if unset.
+	IntrinsicsCamera string `json:"intrinsics_camera,omitempty"`
+	// FrameRate is the rate in frames per second at which Stream renders the merged point cloud, 5 if unset.
+	FrameRate float64 `json:"frame_rate,omitempty"`
+}
+
+// Validate checks that the config describes a valid projection.
+func (cfg *ProjectionConfig) Validate() error {
+	if cfg.FrameRate < 0 {
+		return errors.Errorf("frame_rate must be non-negative, got %v", cfg.FrameRate)
+	}
+	switch cfg.Mode {
+	case "", projectionModePerspective:
+		if cfg.Intrinsics != nil && cfg.IntrinsicsCamera != "" {
+			return errors.New("intrinsics and intrinsics_camera are mutually exclusive")
+		}
+		if cfg.Intrinsics != nil {
+			if err := cfg.Intrinsics.CheckValid(); err != nil {
+				return errors.Wrap(err, "invalid intrinsics")
+			}
+		}
+	case projectionModeTopDown:
+		if cfg.Resolution <= 0 {
+			return errors.Errorf("resolution must be positive, got %v", cfg.Resolution)
+		}
+		if cfg.Intrinsics != nil || cfg.IntrinsicsCamera != "" {
+			return errors.Errorf("%q takes no intrinsics", projectionModeTopDown)
+		}
+		if cfg.Extent == nil {
+			return errors.Errorf("extent is required for %q", projectionModeTopDown)
+		}
+		if err := cfg.Extent.Validate(); err != nil {
+			return errors.Wrap(err, "invalid extent")
+		}
+		if cfg.Extent.Max.Z-cfg.Extent.Min.Z > math.MaxUint16 {
+			return errors.Errorf("extent height must not exceed %vmm", math.MaxUint16)
+		}
+	default:
+		return errors.Errorf("invalid mode %q, must be %q or %q", cfg.Mode, projectionModePerspective, projectionModeTopDown)
+	}
+	return nil
+}
+
+// resolveProjectionIntrinsics returns the intrinsics used to render perspective images. They are resolved in order
+// from:
+//  1. the intrinsics of the projection config,
+//  2. the properties of the intrinsics camera of the projection config, or else of the source camera whose frame is
+//     the target frame,
+//  3. the projector of that same camera when it is a pinhole camera model, which is only queried when a projection
+//     is configured.
+//
+// Nil is returned for top-down projections, which need no intrinsics, and an error explaining why when no intrinsics
+// are available.
+func resolveProjectionIntrinsics(
+	ctx context.Context,
+	cfg *ProjectionConfig,
+	targetFrame string,
+	cameras []camera.Camera,
+	cameraProperties []camera.Properties,
+) (*transform.PinholeCameraIntrinsics, error) {
+	if cfg != nil && cfg.Mode == projectionModeTopDown {
+		return nil, nil
+	}
+	if cfg != nil && cfg.Intrinsics != nil {
+		return cfg.Intrinsics, nil
+	}
+
+	source := targetFrame
+	if cfg != nil && cfg.IntrinsicsCamera != "" {
+		source = cfg.IntrinsicsCamera
+	}
+	index := -1
+	for i, cam := range cameras {
+		if cam.Name().ShortName() == source && i < len(cameraProperties) {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, errors.Errorf("no intrinsics are configured and no source camera is in frame %v", source)
+	}
+	if intrinsics := cameraProperties[index].IntrinsicParams; intrinsics != nil {
+		return intrinsics, nil
+	}
+	if cfg == nil {
+		return nil, errors.Errorf("no intrinsics are configured and camera %v reports none", source)
+	}
+
+	proj, err := cameras[index].Projector(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "no intrinsics are configured, camera %v reports none and its projector failed", source)
+	}
+	model, ok := proj.(*transform.PinholeCameraModel)
+	if !ok || model.PinholeCameraIntrinsics == nil {
+		return nil, errors.Errorf("no intrinsics are configured, camera %v reports none and its projector is a %T", source, proj)
+	}
+	if err := model.PinholeCameraIntrinsics.CheckValid(); err != nil {
+		return nil, errors.Wrapf(err, "camera %v has a projector with invalid intrinsics", source)
+	}
+	return model.PinholeCameraIntrinsics, nil
+}
+
+// Projector returns a pinhole projector for the virtual camera at the origin of the target frame, using the same
+// intrinsics as Images. It is unimplemented if no intrinsics are available for the projection.
+func (merged *mergedCamera) Projector(ctx context.Context) (transform.Projector, error) {
+	merged.mu.RLock()
+	defer merged.mu.RUnlock()
+	if merged.closed {
+		return nil, errors.Wrap(ErrClosed, "cannot get projector")
+	}
+	if err := merged.checkOutputProjectable(); err != nil {
+		var proj transform.Projector
+		return proj, errors.Wrap(err, "Projector is unimplemented")
+	}
+	if merged.projectionIntrinsics == nil {
+		var proj transform.Projector
+		return proj, errors.Wrap(merged.missingIntrinsics(), "Projector is unimplemented")
+	}
+	return &transform.PinholeCameraModel{PinholeCameraIntrinsics: merged.projectionIntrinsics}, nil
+}
+
+// Images renders the merged point cloud into a single image. In the default perspective mode it is a depth image as
+// seen by a pinhole camera at the origin of the target frame, which is unimplemented if no intrinsics are available.
+// In top-down mode it is a height image of the configured extent. With background_rate_hz set the most recent
+// background merge is rendered.
+func (merged *mergedCamera) Images(ctx context.Context) ([]camera.NamedImage, resource.ResponseMetadata, error) {
+	merged.mu.RLock()
+	if merged.closed {
+		merged.mu.RUnlock()
+		return nil, resource.ResponseMetadata{}, errors.Wrap(ErrClosed, "cannot get images")
+	}
+	settings, err := merged.renderSettings()
+	merged.mu.RUnlock()
+	if err != nil {
+		return nil, resource.ResponseMetadata{}, errors.Wrap(err, "Images is unimplemented")
+	}
+
+	dm, capturedAt, err := merged.render(ctx, settings)
+	if err != nil {
+		return nil, resource.ResponseMetadata{}, err
+	}
+	images := []camera.NamedImage{{Image: dm, SourceName: merged.Name().ShortName()}}
+	return images, resource.ResponseMetadata{CapturedAt: capturedAt}, nil
+}
+
+// projectionSettings is a snapshot of the projection config, taken so that rendering does not hold mu while merging.
+type projectionSettings struct {
+	intrinsics *transform.PinholeCameraIntrinsics
+	topDown    bool
+	resolution float64
+	extent     *BoxConfig
+	frameRate  float64
+}
+
+// renderSettings returns the current projection settings, or the reason no intrinsics are available when rendering
+// a perspective projection. The caller must hold mu.
+func (merged *mergedCamera) renderSettings() (projectionSettings, error) {
+	if err := merged.checkOutputProjectable(); err != nil {
+		return projectionSettings{}, err
+	}
+	settings := projectionSettings{intrinsics: merged.projectionIntrinsics, frameRate: defaultFrameRate}
+	if merged.projection != nil {
+		settings.topDown = merged.projection.Mode == projectionModeTopDown
+		if settings.topDown {
+			settings.resolution, settings.extent = merged.projection.Resolution, merged.projection.Extent
+		}
+		if merged.projection.FrameRate > 0 {
+			settings.frameRate = merged.projection.FrameRate
+		}
+	}
+	if settings.intrinsics == nil && !settings.topDown {
+		return projectionSettings{}, merged.missingIntrinsics()
+	}
+	return settings, nil
+}
+
+// checkOutputProjectable returns why the merged cloud cannot be projected through pinhole intrinsics, which assume the
+// optical axes and millimeters of the target frame, nil when it can. Validate rejects a projection config with
+// either conversion, but intrinsics inferred from the cameras need this check. The caller must hold mu.
+func (merged *mergedCamera) checkOutputProjectable() error {
+	if merged.rosFrame {
+		return errors.New("the merged cloud is converted to the ROS axes by ros_frame")
+	}
+	if merged.outputScale > 0 {
+		return errors.New("the merged cloud is converted to meters by output_units")
+	}
+	return nil
+}
+
+// missingIntrinsics returns why no intrinsics are available for a perspective projection. The caller must hold mu.
+func (merged *mergedCamera) missingIntrinsics() error {
+	if merged.projectionErr != nil {
+		return merged.projectionErr
+	}
+	return errors.New("no intrinsics are available for the projection")
+}
+
+// render merges the point clouds, or takes the most recent background merge, and renders the result.
+func (merged *mergedCamera) render(ctx context.Context, settings projectionSettings) (*rimage.DepthMap, time.Time, error) {
+	var pc pointcloud.PointCloud
+	var capturedAt time.Time
+	var err error
+	if bg := merged.backgroundMerges(); bg != nil {
+		pc, capturedAt, err = bg.latest(ctx)
+	} else {
+		pc, capturedAt, err = merged.nextMergedPointCloud(ctx)
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if settings.topDown {
+		return renderTopDown(pc, settings.resolution, settings.extent), capturedAt, nil
+	}
+	return renderDepthMap(pc, settings.intrinsics), capturedAt, nil
+}
+
+// renderDepthMap projects the point cloud into a depth map using the given intrinsics. When several points land on
+// the same pixel the closest one is kept. Points behind the camera are ignored.
+func renderDepthMap(pc pointcloud.PointCloud, intrinsics *transform.PinholeCameraIntrinsics) *rimage.DepthMap {
+	dm := rimage.NewEmptyDepthMap(intrinsics.Width, intrinsics.Height)
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		if p.Z <= 0 || p.Z > math.MaxUint16 {
+			return true
+		}
+		px, py := intrinsics.PointToPixel(p.X, p.Y, p.Z)
+		x, y := int(px), int(py)
+		if !dm.Contains(x, y) {
+			return true
+		}
+		depth := rimage.Depth(math.Round(p.Z))
+		if current := dm.GetDepth(x, y); current == 0 || depth < current {
+			dm.Set(x, y, depth)
+		}
+		return true
+	})
+	return dm
+}
+
+// renderTopDown rasterizes the points within the extent into a height image looking down the Z axis, with X
+// increasing to the right and Y increasing upwards. Each pixel holds the height in millimeters of its highest point
+// above the bottom of the extent, so empty pixels and points on the bottom of the extent are both zero.
+func renderTopDown(pc pointcloud.PointCloud, resolution float64, extent *BoxConfig) *rimage.DepthMap {
+	width := int(math.Ceil((extent.Max.X - extent.Min.X) / resolution))
+	height := int(math.Ceil((extent.Max.Y - extent.Min.Y) / resolution))
+	dm := rimage.NewEmptyDepthMap(width, height)
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		if !extent.contains(p) {
+			return true
+		}
+		x := int((p.X - extent.Min.X) / resolution)
+		y := int((extent.Max.Y - p.Y) / resolution)
+		// points on the max X or min Y boundary fall just outside the last pixel
+		if x == width {
+			x--
+		}
+		if y == height {
+			y--
+		}
+		if !dm.Contains(x, y) {
+			return true
+		}
+		h := rimage.Depth(math.Round(p.Z - extent.Min.Z))
+		if h > dm.GetDepth(x, y) {
+			dm.Set(x, y, h)
+		}
+		return true
+	})
+	return dm
+}
+
+// projectDepthImages builds a point cloud in millimeters from the depth image returned by a camera that does not
+// support point clouds. Points are colored by the first other image the camera returns if it has the same size as
+// the depth image. Pixels without depth are skipped.
+func projectDepthImages(
+	ctx context.Context, cam camera.Camera, intrinsics *transform.PinholeCameraIntrinsics,
+) (pointcloud.PointCloud, error) {
+	images, _, err := cam.Images(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting images to project")
+	}
+	var dm *rimage.DepthMap
+	var colorImg *rimage.Image
+	for _, img := range images {
+		if dm == nil {
+			if depth, err := rimage.ConvertImageToDepthMap(ctx, img.Image); err == nil {
+				dm = depth
+				continue
+			}
+		}
+		if colorImg == nil {
+			colorImg = rimage.ConvertImage(img.Image)
+		}
+	}
+	if dm == nil {
+		return nil, errors.New("camera returned no depth image to project")
+	}
+	if colorImg != nil && colorImg.Bounds() != dm.Bounds() {
+		colorImg = nil
+	}
+
+	pc := pointcloud.NewWithPrealloc(dm.Width() * dm.Height())
+	for y := 0; y < dm.Height(); y++ {
+		for x := 0; x < dm.Width(); x++ {
+			depth := dm.GetDepth(x, y)
+			if depth == 0 {
+				continue
+			}
+			px, py, pz := intrinsics.PixelToPoint(float64(x), float64(y), float64(depth))
+			data := pointcloud.NewBasicData()
+			if colorImg != nil {
+				r, g, b := colorImg.GetXY(x, y).RGB255()
+				data = pointcloud.NewColoredData(color.NRGBA{R: r, G: g, B: b, A: 255})
+			}
+			if err := pc.Set(r3.Vector{X: px, Y: py, Z: pz}, data); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return pc, nil
+}
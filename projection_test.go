@@ -0,0 +1,378 @@
+package main
+
+import (
+	"context"
+	"image"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/test"
+)
+
+func TestRenderDepthMap(t *testing.T) {
+	intrinsics := &transform.PinholeCameraIntrinsics{Width: 10, Height: 10, Fx: 10, Fy: 10, Ppx: 5, Ppy: 5}
+
+	pc := pointcloud.New()
+	test.That(t, pc.Set(r3.Vector{X: 0, Y: 0, Z: 1000}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 0, Y: 0, Z: 500}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 100, Y: 200, Z: 1000}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 0, Y: 0, Z: -1000}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 5000, Y: 0, Z: 1000}, pointcloud.NewBasicData()), test.ShouldBeNil)
+
+	dm := renderDepthMap(pc, intrinsics)
+	test.That(t, dm.Width(), test.ShouldEqual, 10)
+	test.That(t, dm.Height(), test.ShouldEqual, 10)
+	test.That(t, dm.GetDepth(5, 5), test.ShouldEqual, rimage.Depth(500))
+	test.That(t, dm.GetDepth(6, 7), test.ShouldEqual, rimage.Depth(1000))
+	test.That(t, dm.GetDepth(0, 0), test.ShouldEqual, rimage.Depth(0))
+}
+
+func TestRenderTopDown(t *testing.T) {
+	extent := &BoxConfig{Min: r3.Vector{X: -50, Y: -50, Z: -100}, Max: r3.Vector{X: 50, Y: 50, Z: 100}}
+
+	pc := pointcloud.New()
+	test.That(t, pc.Set(r3.Vector{X: -45, Y: 45, Z: 0}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: -41, Y: 41, Z: 50}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 50, Y: -50, Z: -90}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 0, Y: 0, Z: 150}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	test.That(t, pc.Set(r3.Vector{X: 60, Y: 0, Z: 0}, pointcloud.NewBasicData()), test.ShouldBeNil)
+
+	dm := renderTopDown(pc, 10, extent)
+	test.That(t, dm.Width(), test.ShouldEqual, 10)
+	test.That(t, dm.Height(), test.ShouldEqual, 10)
+	// the highest point of the top left pixel is kept
+	test.That(t, dm.GetDepth(0, 0), test.ShouldEqual, rimage.Depth(150))
+	// points on the far boundary land in the last pixel
+	test.That(t, dm.GetDepth(9, 9), test.ShouldEqual, rimage.Depth(10))
+	// points outside the extent are ignored
+	test.That(t, dm.GetDepth(5, 5), test.ShouldEqual, rimage.Depth(0))
+}
+
+func TestProjectionConfigValidate(t *testing.T) {
+	test.That(t, (&ProjectionConfig{}).Validate(), test.ShouldBeNil)
+	test.That(t, (&ProjectionConfig{Mode: "fisheye"}).Validate(), test.ShouldNotBeNil)
+	test.That(t, (&ProjectionConfig{Intrinsics: &transform.PinholeCameraIntrinsics{}}).Validate(), test.ShouldNotBeNil)
+
+	extent := &BoxConfig{Min: r3.Vector{X: -1, Y: -1}, Max: r3.Vector{X: 1, Y: 1, Z: 1}}
+	test.That(t, (&ProjectionConfig{Mode: projectionModeTopDown, Resolution: 1, Extent: extent}).Validate(), test.ShouldBeNil)
+	test.That(t, (&ProjectionConfig{Mode: projectionModeTopDown, Extent: extent}).Validate(), test.ShouldNotBeNil)
+	test.That(t, (&ProjectionConfig{Mode: projectionModeTopDown, Resolution: 1}).Validate(), test.ShouldNotBeNil)
+	test.That(t, (&ProjectionConfig{FrameRate: -1}).Validate(), test.ShouldNotBeNil)
+	intrinsics := &transform.PinholeCameraIntrinsics{Width: 10, Height: 10, Fx: 10, Fy: 10}
+	test.That(t, (&ProjectionConfig{IntrinsicsCamera: "cam1"}).Validate(), test.ShouldBeNil)
+	test.That(t, (&ProjectionConfig{Intrinsics: intrinsics, IntrinsicsCamera: "cam1"}).Validate(), test.ShouldNotBeNil)
+	test.That(t, (&ProjectionConfig{Mode: projectionModeTopDown, Resolution: 1, Extent: extent, IntrinsicsCamera: "cam1"}).Validate(),
+		test.ShouldNotBeNil)
+}
+
+func TestResolveProjectionIntrinsics(t *testing.T) {
+	ctx := context.Background()
+	configured := &transform.PinholeCameraIntrinsics{Width: 10, Height: 10, Fx: 10, Fy: 10, Ppx: 5, Ppy: 5}
+	reported := &transform.PinholeCameraIntrinsics{Width: 20, Height: 20, Fx: 20, Fy: 20, Ppx: 10, Ppy: 10}
+	projected := &transform.PinholeCameraIntrinsics{Width: 30, Height: 30, Fx: 30, Fy: 30, Ppx: 15, Ppy: 15}
+
+	cam1 := inject.NewCamera("cam1")
+	projectorCalls := 0
+	cam1.ProjectorFunc = func(ctx context.Context) (transform.Projector, error) {
+		projectorCalls++
+		return &transform.PinholeCameraModel{PinholeCameraIntrinsics: projected}, nil
+	}
+	cam2 := inject.NewCamera("cam2")
+	cam2.ProjectorFunc = func(ctx context.Context) (transform.Projector, error) {
+		return nil, errors.New("no projector")
+	}
+	cameras := []camera.Camera{cam1, cam2}
+	without := []camera.Properties{{}, {}}
+	with := []camera.Properties{{IntrinsicParams: reported}, {IntrinsicParams: reported}}
+
+	t.Run("configured intrinsics come first", func(t *testing.T) {
+		intrinsics, err := resolveProjectionIntrinsics(ctx, &ProjectionConfig{Intrinsics: configured}, "cam1", cameras, with)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, intrinsics, test.ShouldEqual, configured)
+	})
+
+	t.Run("then the properties of the camera in the target frame", func(t *testing.T) {
+		intrinsics, err := resolveProjectionIntrinsics(ctx, nil, "cam1", cameras, with)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, intrinsics, test.ShouldEqual, reported)
+	})
+
+	t.Run("then its projector when a projection is configured", func(t *testing.T) {
+		intrinsics, err := resolveProjectionIntrinsics(ctx, &ProjectionConfig{}, "cam1", cameras, without)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, intrinsics, test.ShouldEqual, projected)
+
+		projectorCalls = 0
+		_, err = resolveProjectionIntrinsics(ctx, nil, "cam1", cameras, without)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "camera cam1 reports none")
+		test.That(t, projectorCalls, test.ShouldEqual, 0)
+	})
+
+	t.Run("the intrinsics camera replaces the camera in the target frame", func(t *testing.T) {
+		_, err := resolveProjectionIntrinsics(ctx, &ProjectionConfig{IntrinsicsCamera: "cam2"}, "cam1", cameras, without)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "camera cam2 reports none and its projector failed")
+	})
+
+	t.Run("errors without a camera in the target frame", func(t *testing.T) {
+		_, err := resolveProjectionIntrinsics(ctx, &ProjectionConfig{}, "world", cameras, with)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "no source camera is in frame world")
+	})
+
+	t.Run("top-down projections need none", func(t *testing.T) {
+		intrinsics, err := resolveProjectionIntrinsics(ctx, &ProjectionConfig{Mode: projectionModeTopDown}, "world", cameras, without)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, intrinsics, test.ShouldBeNil)
+	})
+}
+
+func TestImages(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	cam1 := createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 1000}})
+	cam2 := createMockCamera("cam2", []r3.Vector{{X: 100, Y: 200, Z: 1000}})
+	cameras := []camera.Camera{cam1, cam2}
+
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+	deps := createDependencies(cameras, fsService)
+
+	mergedCam := &mergedCamera{Named: resource.NewName(camera.API, "merged").AsNamed(), logger: logger}
+
+	t.Run("unimplemented without intrinsics", func(t *testing.T) {
+		conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+
+		_, _, err := mergedCam.Images(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "Images is unimplemented: no intrinsics are configured")
+
+		props, err := mergedCam.Properties(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, props.ImageType, test.ShouldEqual, camera.UnspecifiedStream)
+	})
+
+	t.Run("renders a depth image with configured intrinsics", func(t *testing.T) {
+		intrinsics := &transform.PinholeCameraIntrinsics{Width: 10, Height: 10, Fx: 10, Fy: 10, Ppx: 5, Ppy: 5}
+		conf := resource.Config{ConvertedAttributes: &Config{
+			Cameras:    []string{"cam1", "cam2"},
+			Projection: &ProjectionConfig{Intrinsics: intrinsics},
+		}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+
+		images, metadata, err := mergedCam.Images(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		// the capture time defaults to that of the earliest camera
+		earliest := mergedCam.cameraStatuses["cam1"].lastCapturedAt
+		if other := mergedCam.cameraStatuses["cam2"].lastCapturedAt; other.Before(earliest) {
+			earliest = other
+		}
+		test.That(t, metadata.CapturedAt, test.ShouldEqual, earliest)
+		test.That(t, len(images), test.ShouldEqual, 1)
+		test.That(t, images[0].SourceName, test.ShouldEqual, "merged")
+
+		dm, ok := images[0].Image.(*rimage.DepthMap)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, dm.GetDepth(5, 5), test.ShouldEqual, rimage.Depth(1000))
+		test.That(t, dm.GetDepth(6, 7), test.ShouldEqual, rimage.Depth(1000))
+
+		props, err := mergedCam.Properties(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, props.SupportsPCD, test.ShouldBeTrue)
+		test.That(t, props.ImageType, test.ShouldEqual, camera.DepthStream)
+		test.That(t, props.IntrinsicParams, test.ShouldEqual, intrinsics)
+	})
+
+	t.Run("renders a top-down height image", func(t *testing.T) {
+		conf := resource.Config{ConvertedAttributes: &Config{
+			Cameras: []string{"cam1", "cam2"},
+			Projection: &ProjectionConfig{
+				Mode:       projectionModeTopDown,
+				Resolution: 100,
+				Extent:     &BoxConfig{Min: r3.Vector{X: -500, Y: -500}, Max: r3.Vector{X: 500, Y: 500, Z: 2000}},
+			},
+		}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+
+		images, _, err := mergedCam.Images(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		dm, ok := images[0].Image.(*rimage.DepthMap)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, dm.Width(), test.ShouldEqual, 10)
+		test.That(t, dm.GetDepth(5, 5), test.ShouldEqual, rimage.Depth(1000))
+		test.That(t, dm.GetDepth(6, 3), test.ShouldEqual, rimage.Depth(1000))
+
+		_, err = mergedCam.Projector(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+}
+
+func TestProjector(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	cameras := []camera.Camera{
+		createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 1000}}),
+		createMockCamera("cam2", []r3.Vector{{X: 100, Y: 200, Z: 1000}}),
+	}
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+	deps := createDependencies(cameras, fsService)
+
+	mergedCam := &mergedCamera{logger: logger}
+
+	t.Run("unimplemented without intrinsics", func(t *testing.T) {
+		conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1", "cam2"}}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+
+		_, err := mergedCam.Projector(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "unimplemented")
+	})
+
+	t.Run("projects with configured intrinsics", func(t *testing.T) {
+		intrinsics := &transform.PinholeCameraIntrinsics{Width: 10, Height: 10, Fx: 10, Fy: 10, Ppx: 5, Ppy: 5}
+		conf := resource.Config{ConvertedAttributes: &Config{
+			Cameras:    []string{"cam1", "cam2"},
+			Projection: &ProjectionConfig{Intrinsics: intrinsics},
+		}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+
+		proj, err := mergedCam.Projector(ctx)
+		test.That(t, err, test.ShouldBeNil)
+
+		// round trip a pixel of the rendered depth map back into the target frame
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		dm := renderDepthMap(pc, intrinsics)
+		pt, err := proj.ImagePointTo3DPoint(image.Point{X: 6, Y: 7}, dm.GetDepth(6, 7))
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pt.X, test.ShouldAlmostEqual, 100)
+		test.That(t, pt.Y, test.ShouldAlmostEqual, 200)
+		test.That(t, pt.Z, test.ShouldAlmostEqual, 1000)
+	})
+}
+
+// createDepthCamera returns a camera that does not support point clouds but returns the given images.
+func createDepthCamera(
+	name string, intrinsics *transform.PinholeCameraIntrinsics, images ...image.Image,
+) camera.Camera {
+	cam := inject.NewCamera(name)
+	cam.ImagesFunc = func(ctx context.Context) ([]camera.NamedImage, resource.ResponseMetadata, error) {
+		namedImages := make([]camera.NamedImage, 0, len(images))
+		for _, img := range images {
+			namedImages = append(namedImages, camera.NamedImage{Image: img})
+		}
+		return namedImages, resource.ResponseMetadata{}, nil
+	}
+	cam.PropertiesFunc = func(ctx context.Context) (camera.Properties, error) {
+		return camera.Properties{IntrinsicParams: intrinsics}, nil
+	}
+	return cam
+}
+
+func TestProjectDepthImages(t *testing.T) {
+	ctx := context.Background()
+	intrinsics := &transform.PinholeCameraIntrinsics{Width: 2, Height: 2, Fx: 1, Fy: 1}
+	dm := rimage.NewEmptyDepthMap(2, 2)
+	dm.Set(0, 0, 1000)
+	dm.Set(1, 1, 2000)
+	red := rimage.NewImage(2, 2)
+	red.SetXY(1, 1, rimage.NewColor(255, 0, 0))
+
+	t.Run("skips pixels without depth", func(t *testing.T) {
+		pc, err := projectDepthImages(ctx, createDepthCamera("cam", intrinsics, dm), intrinsics)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+		_, ok := pc.At(0, 0, 1000)
+		test.That(t, ok, test.ShouldBeTrue)
+		d, ok := pc.At(2000, 2000, 2000)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, d.HasColor(), test.ShouldBeFalse)
+	})
+
+	t.Run("colors points by the color image", func(t *testing.T) {
+		pc, err := projectDepthImages(ctx, createDepthCamera("cam", intrinsics, red, dm), intrinsics)
+		test.That(t, err, test.ShouldBeNil)
+		d, ok := pc.At(2000, 2000, 2000)
+		test.That(t, ok, test.ShouldBeTrue)
+		r, g, b := d.RGB255()
+		test.That(t, []uint8{r, g, b}, test.ShouldResemble, []uint8{255, 0, 0})
+	})
+
+	t.Run("ignores a color image of another size", func(t *testing.T) {
+		pc, err := projectDepthImages(ctx, createDepthCamera("cam", intrinsics, rimage.NewImage(4, 4), dm), intrinsics)
+		test.That(t, err, test.ShouldBeNil)
+		d, ok := pc.At(2000, 2000, 2000)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, d.HasColor(), test.ShouldBeFalse)
+	})
+
+	t.Run("errors without a depth image", func(t *testing.T) {
+		_, err := projectDepthImages(ctx, createDepthCamera("cam", intrinsics, red), intrinsics)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "no depth image")
+	})
+}
+
+func TestAllowDepthProjection(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	intrinsics := &transform.PinholeCameraIntrinsics{Width: 2, Height: 2, Fx: 1, Fy: 1}
+	dm := rimage.NewEmptyDepthMap(2, 2)
+	dm.Set(1, 1, 2000)
+	cam1 := createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 1000}})
+	cam2 := createDepthCamera("cam2", intrinsics, dm)
+	cam3 := createDepthCamera("cam3", nil, dm)
+	cameras := []camera.Camera{cam1, cam2, cam3}
+
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+	deps := createDependencies(cameras, fsService)
+
+	reconfigure := func(cameraNames []string, allow bool) (*mergedCamera, error) {
+		mergedCam := &mergedCamera{logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{Cameras: cameraNames, AllowDepthProjection: allow, FailFast: true}}
+		return mergedCam, mergedCam.Reconfigure(ctx, deps, conf)
+	}
+
+	t.Run("cameras without PCD support are rejected by default", func(t *testing.T) {
+		_, err := reconfigure([]string{"cam1", "cam2"}, false)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "does not support PCDs")
+	})
+
+	t.Run("depth images are projected and merged", func(t *testing.T) {
+		mergedCam, err := reconfigure([]string{"cam1", "cam2"}, true)
+		test.That(t, err, test.ShouldBeNil)
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+		_, ok := pc.At(2000, 2000, 2000)
+		test.That(t, ok, test.ShouldBeTrue)
+
+		resp, err := mergedCam.DoCommand(ctx, map[string]interface{}{commandKey: statusCommand})
+		test.That(t, err, test.ShouldBeNil)
+		statuses := resp["cameras"].([]interface{})
+		test.That(t, statuses[0].(map[string]interface{})["depth_projection"], test.ShouldBeFalse)
+		test.That(t, statuses[1].(map[string]interface{})["depth_projection"], test.ShouldBeTrue)
+	})
+
+	t.Run("cameras without intrinsics cannot be projected", func(t *testing.T) {
+		_, err := reconfigure([]string{"cam1", "cam3"}, true)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "neither PCDs nor depth projection")
+	})
+}
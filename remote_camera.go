@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/utils/rpc"
+)
+
+// RemoteCameraConfig describes a camera outside the robot config that the merged camera dials directly over gRPC.
+type RemoteCameraConfig struct {
+	// Address is the host and port of the gRPC server serving the camera.
+	Address string `json:"address"`
+	// Name is the name of the camera on that server. Defaults to the name the camera is listed under in cameras.
+	Name string `json:"name,omitempty"`
+	// Insecure dials without transport security, which the server must then also disable.
+	Insecure bool `json:"insecure,omitempty"`
+}
+
+// Validate checks that the address is a host and a valid port.
+func (cfg *RemoteCameraConfig) Validate() error {
+	host, port, err := net.SplitHostPort(cfg.Address)
+	if err != nil {
+		return errors.Wrapf(err, "invalid address %q", cfg.Address)
+	}
+	if host == "" {
+		return errors.Errorf("invalid address %q, missing host", cfg.Address)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return errors.Errorf("invalid address %q, port must be between 1 and 65535", cfg.Address)
+	}
+	return nil
+}
+
+// remoteCamera is a camera client on a connection the merged camera dialed itself. It is named after the camera's
+// entry in cameras, rather than its name on the server, so that frames, statuses and per camera config find it.
+type remoteCamera struct {
+	camera.Camera
+	name resource.Name
+	cfg  RemoteCameraConfig
+	conn rpc.ClientConn
+}
+
+// Name returns the name the camera is listed under in cameras.
+func (cam *remoteCamera) Name() resource.Name {
+	return cam.name
+}
+
+// Close closes the camera client and its connection.
+func (cam *remoteCamera) Close(ctx context.Context) error {
+	return multierr.Combine(cam.Camera.Close(ctx), cam.conn.Close())
+}
+
+// dialRemoteCamera dials the server of the camera listed under name, giving up after grpcConnectionTimeout.
+func (merged *mergedCamera) dialRemoteCamera(ctx context.Context, name string, cfg RemoteCameraConfig) (*remoteCamera, error) {
+	ctx, cancel := context.WithTimeout(ctx, grpcConnectionTimeout)
+	defer cancel()
+	var opts []rpc.DialOption
+	if cfg.Insecure {
+		opts = append(opts, rpc.WithInsecure())
+	}
+	conn, err := rpc.DialDirectGRPC(ctx, cfg.Address, merged.logger.AsZap(), opts...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error dialing remote camera %v at %v", name, cfg.Address)
+	}
+
+	remoteName := cfg.Name
+	if remoteName == "" {
+		remoteName = name
+	}
+	client, err := camera.NewClientFromConn(ctx, conn, "", camera.Named(remoteName), merged.logger)
+	if err != nil {
+		return nil, multierr.Combine(errors.Wrapf(err, "error creating client for remote camera %v", name), conn.Close())
+	}
+	merged.logger.Debugf("connected to remote camera %v at %v", name, cfg.Address)
+	return &remoteCamera{Camera: client, name: camera.Named(name), cfg: cfg, conn: conn}, nil
+}
+
+// remoteCameraFor returns the connected remote camera listed under name, dialing it if it is not yet connected.
+func (merged *mergedCamera) remoteCameraFor(ctx context.Context, name string, cfg RemoteCameraConfig) (camera.Camera, error) {
+	if cam, ok := merged.remoteCameras[name]; ok {
+		return cam, nil
+	}
+	cam, err := merged.dialRemoteCamera(ctx, name, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if merged.remoteCameras == nil {
+		merged.remoteCameras = make(map[string]*remoteCamera)
+	}
+	merged.remoteCameras[name] = cam
+	return cam, nil
+}
+
+// closeRemoteCameras closes the connections of the remote cameras that are no longer configured, or configured
+// differently, keeping every other connection for reuse. A nil configs closes every connection.
+func (merged *mergedCamera) closeRemoteCameras(ctx context.Context, configs map[string]*RemoteCameraConfig) error {
+	var err error
+	for name, cam := range merged.remoteCameras {
+		if cfg, ok := configs[name]; ok && *cfg == cam.cfg {
+			continue
+		}
+		if closeErr := cam.Close(ctx); closeErr != nil {
+			err = multierr.Append(err, errors.Wrapf(closeErr, "error closing remote camera %v", name))
+		}
+		delete(merged.remoteCameras, name)
+	}
+	return err
+}
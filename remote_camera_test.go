@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/test"
+	"go.viam.com/utils/rpc"
+)
+
+func TestRemoteCameraConfigValidate(t *testing.T) {
+	for _, tc := range []struct {
+		address string
+		valid   bool
+	}{
+		{"localhost:8080", true},
+		{"10.0.0.2:443", true},
+		{"[::1]:8080", true},
+		{"localhost", false},
+		{":8080", false},
+		{"localhost:http", false},
+		{"localhost:0", false},
+		{"localhost:70000", false},
+	} {
+		err := (&RemoteCameraConfig{Address: tc.address}).Validate()
+		if tc.valid {
+			test.That(t, err, test.ShouldBeNil)
+		} else {
+			test.That(t, err, test.ShouldNotBeNil)
+			test.That(t, err.Error(), test.ShouldContainSubstring, tc.address)
+		}
+	}
+
+	cfg := &Config{
+		Cameras:       []string{"cam1", "far"},
+		RemoteCameras: map[string]*RemoteCameraConfig{"far": {Address: "localhost:8080"}},
+	}
+	deps, err := cfg.Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, deps, test.ShouldResemble, []string{"cam1", "rdk-internal:service:frame_system/builtin"})
+
+	cfg.RemoteCameras["other"] = &RemoteCameraConfig{Address: "localhost:8080"}
+	_, err = cfg.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "remote_cameras given for unknown camera other")
+
+	delete(cfg.RemoteCameras, "other")
+	cfg.RemoteCameras["far"] = &RemoteCameraConfig{Address: "localhost"}
+	_, err = cfg.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "invalid remote camera far")
+}
+
+// serveCameras serves the cameras over insecure gRPC until the test ends, returning the address of the server.
+func serveCameras(t *testing.T, cameras map[resource.Name]camera.Camera) string {
+	t.Helper()
+	logger := logging.NewTestLogger(t)
+	listener, err := net.Listen("tcp", "localhost:0")
+	test.That(t, err, test.ShouldBeNil)
+	rpcServer, err := rpc.NewServer(logger.AsZap(), rpc.WithUnauthenticated())
+	test.That(t, err, test.ShouldBeNil)
+
+	cameraSvc, err := resource.NewAPIResourceCollection(camera.API, cameras)
+	test.That(t, err, test.ShouldBeNil)
+	resourceAPI, ok, err := resource.LookupAPIRegistration[camera.Camera](camera.API)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, resourceAPI.RegisterRPCService(context.Background(), rpcServer, cameraSvc), test.ShouldBeNil)
+
+	go rpcServer.Serve(listener)
+	t.Cleanup(func() {
+		test.That(t, rpcServer.Stop(), test.ShouldBeNil)
+	})
+	return listener.Addr().String()
+}
+
+func TestRemoteCamera(t *testing.T) {
+	ctx := context.Background()
+	address := serveCameras(t, map[resource.Name]camera.Camera{
+		camera.Named("served"): newFakeCamera("served", r3.Vector{X: 1, Z: 100}, r3.Vector{X: 2, Z: 100}),
+	})
+
+	cam1 := newFakeCamera("cam1", r3.Vector{Z: 10})
+	fsService, err := newFakeFrameSystemService(map[string]spatialmath.Pose{"cam1": spatialmath.NewZeroPose()})
+	test.That(t, err, test.ShouldBeNil)
+	cfg := &Config{
+		Cameras:       []string{"cam1", "far"},
+		RemoteCameras: map[string]*RemoteCameraConfig{"far": {Address: address, Name: "served", Insecure: true}},
+		PoseOverride:  map[string]*PoseConfig{"far": {Translation: r3.Vector{Y: 50}}},
+	}
+	mergedCam := newTestMergedCamera(t, cfg, []camera.Camera{cam1}, fsService)
+
+	pc, err := mergedCam.NextPointCloud(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pc.Size(), test.ShouldEqual, 3)
+	_, ok := pc.At(1, 50, 100)
+	test.That(t, ok, test.ShouldBeTrue)
+
+	remote := mergedCam.remoteCameras["far"]
+	test.That(t, remote, test.ShouldNotBeNil)
+	test.That(t, remote.Name().ShortName(), test.ShouldEqual, "far")
+
+	t.Run("reconfiguring keeps an unchanged connection", func(t *testing.T) {
+		conf := resource.Config{ConvertedAttributes: cfg}
+		deps := createDependencies([]camera.Camera{cam1}, fsService)
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+		test.That(t, mergedCam.remoteCameras["far"], test.ShouldEqual, remote)
+
+		// a changed config dials again
+		changed := *cfg
+		changed.RemoteCameras = map[string]*RemoteCameraConfig{"far": {Address: address, Name: "served", Insecure: true}}
+		changed.RemoteCameras["far"].Name = "missing"
+		test.That(t, mergedCam.Reconfigure(ctx, deps, resource.Config{ConvertedAttributes: &changed}), test.ShouldNotBeNil)
+		test.That(t, mergedCam.remoteCameras["far"], test.ShouldNotEqual, remote)
+
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+		pc, err := mergedCam.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 3)
+	})
+
+	t.Run("close closes the connections", func(t *testing.T) {
+		test.That(t, mergedCam.Close(ctx), test.ShouldBeNil)
+		test.That(t, mergedCam.remoteCameras, test.ShouldBeEmpty)
+	})
+}
+
+func TestDialRemoteCameraTimeout(t *testing.T) {
+	// nothing listens on the address once the listener is closed
+	listener, err := net.Listen("tcp", "localhost:0")
+	test.That(t, err, test.ShouldBeNil)
+	address := listener.Addr().String()
+	test.That(t, listener.Close(), test.ShouldBeNil)
+
+	mergedCam := &mergedCamera{logger: logging.NewTestLogger(t)}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = mergedCam.dialRemoteCamera(ctx, "far", RemoteCameraConfig{Address: address, Insecure: true})
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "error dialing remote camera far")
+	test.That(t, time.Since(start), test.ShouldBeLessThan, grpcConnectionTimeout)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"image"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/gostream"
+)
+
+// Stream renders the merged point cloud into a live video stream at the frame rate of the projection config, using
+// the same rendering as Images. Every frame merges the point clouds anew, or takes the most recent background merge
+// with background_rate_hz set. Errors are passed to the error handlers and returned by the stream in place of the
+// frame. Stream is unimplemented if Images is, and the stream ends once the merged camera is closed. Frames follow
+// reconfigured projections while the frame rate is fixed when the stream starts.
+func (merged *mergedCamera) Stream(ctx context.Context, errHandlers ...gostream.ErrorHandler) (gostream.VideoStream, error) {
+	var stream gostream.VideoStream
+	merged.mu.RLock()
+	if merged.closed {
+		merged.mu.RUnlock()
+		return stream, errors.Wrap(ErrClosed, "cannot stream")
+	}
+	settings, err := merged.renderSettings()
+	merged.mu.RUnlock()
+	if err != nil {
+		return stream, errors.Wrap(err, "Stream is unimplemented")
+	}
+
+	// the stream outlives the request that opened it, so it is only bound to the MIME type requested
+	streamCtx, stream, frames := gostream.NewMediaStreamForChannel[image.Image](
+		gostream.WithMIMETypeHint(context.Background(), gostream.MIMETypeHint(ctx, "")))
+	go func() {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / settings.frameRate))
+		defer ticker.Stop()
+		for {
+			frame, err := merged.streamFrame(streamCtx)
+			if streamCtx.Err() != nil {
+				return
+			}
+			if err != nil {
+				for _, handler := range errHandlers {
+					handler(streamCtx, err)
+				}
+			}
+
+			select {
+			case frames <- gostream.MediaReleasePairWithError[image.Image]{Media: frame, Release: func() {}, Err: err}:
+			case <-streamCtx.Done():
+				return
+			}
+			if errors.Is(err, ErrClosed) {
+				return
+			}
+			select {
+			case <-ticker.C:
+			case <-streamCtx.Done():
+				return
+			}
+		}
+	}()
+	return stream, nil
+}
+
+// streamFrame renders a single frame of the stream with the current projection settings.
+func (merged *mergedCamera) streamFrame(ctx context.Context) (image.Image, error) {
+	merged.mu.RLock()
+	if merged.closed {
+		merged.mu.RUnlock()
+		return nil, errors.Wrap(ErrClosed, "cannot stream")
+	}
+	settings, err := merged.renderSettings()
+	merged.mu.RUnlock()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot render frame")
+	}
+	dm, _, err := merged.render(ctx, settings)
+	if err != nil {
+		return nil, err
+	}
+	return dm, nil
+}
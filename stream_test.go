@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/test"
+)
+
+func TestStream(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	cam1 := createMockCamera("cam1", []r3.Vector{{X: 0, Y: 0, Z: 1000}})
+	cam2 := createFailingCamera("cam2")
+	cameras := []camera.Camera{cam1, cam2}
+	fsService, err := createFrameSystemService(ctx, cameras, logger)
+	test.That(t, err, test.ShouldBeNil)
+	deps := createDependencies(cameras, fsService)
+	intrinsics := &transform.PinholeCameraIntrinsics{Width: 10, Height: 10, Fx: 10, Fy: 10, Ppx: 5, Ppy: 5}
+
+	t.Run("unimplemented without a projection", func(t *testing.T) {
+		mergedCam := &mergedCamera{Named: resource.NewName(camera.API, "merged").AsNamed(), logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{Cameras: []string{"cam1"}}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+
+		_, err := mergedCam.Stream(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "unimplemented")
+	})
+
+	t.Run("streams rendered frames", func(t *testing.T) {
+		mergedCam := &mergedCamera{Named: resource.NewName(camera.API, "merged").AsNamed(), logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{
+			Cameras:    []string{"cam1"},
+			Projection: &ProjectionConfig{Intrinsics: intrinsics, FrameRate: 1000},
+		}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+
+		stream, err := mergedCam.Stream(ctx, func(ctx context.Context, err error) {
+			t.Errorf("unexpected stream error: %v", err)
+		})
+		test.That(t, err, test.ShouldBeNil)
+		for i := 0; i < 3; i++ {
+			img, release, err := stream.Next(ctx)
+			test.That(t, err, test.ShouldBeNil)
+			release()
+			dm, ok := img.(*rimage.DepthMap)
+			test.That(t, ok, test.ShouldBeTrue)
+			test.That(t, dm.GetDepth(5, 5), test.ShouldEqual, rimage.Depth(1000))
+		}
+		test.That(t, stream.Close(ctx), test.ShouldBeNil)
+
+		_, _, err = stream.Next(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+
+	t.Run("errors reach the handlers and the stream", func(t *testing.T) {
+		mergedCam := &mergedCamera{Named: resource.NewName(camera.API, "merged").AsNamed(), logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{
+			Cameras:    []string{"cam1", "cam2"},
+			Projection: &ProjectionConfig{Intrinsics: intrinsics, FrameRate: 1000},
+		}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+
+		handled := make(chan error, 10)
+		stream, err := mergedCam.Stream(ctx, func(ctx context.Context, err error) {
+			select {
+			case handled <- err:
+			default:
+			}
+		})
+		test.That(t, err, test.ShouldBeNil)
+		defer stream.Close(ctx)
+
+		img, _, err := stream.Next(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "cam2")
+		test.That(t, img, test.ShouldBeNil)
+		test.That(t, <-handled, test.ShouldEqual, err)
+	})
+
+	t.Run("ends once the camera is closed", func(t *testing.T) {
+		mergedCam := &mergedCamera{Named: resource.NewName(camera.API, "merged").AsNamed(), logger: logger}
+		conf := resource.Config{ConvertedAttributes: &Config{
+			Cameras:    []string{"cam1"},
+			Projection: &ProjectionConfig{Intrinsics: intrinsics, FrameRate: 1000},
+		}}
+		test.That(t, mergedCam.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+
+		stream, err := mergedCam.Stream(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		defer stream.Close(ctx)
+		_, _, err = stream.Next(ctx)
+		test.That(t, err, test.ShouldBeNil)
+
+		test.That(t, mergedCam.Close(ctx), test.ShouldBeNil)
+		for !errors.Is(err, ErrClosed) {
+			_, _, err = stream.Next(ctx)
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"sync"
+
+	"go.viam.com/rdk/spatialmath"
+)
+
+// frameKey identifies the transform from a source frame to a target frame.
+type frameKey struct {
+	source string
+	target string
+}
+
+// transformCache stores transforms between frames that are static so that they only have to be resolved
+// through the frame system once. Clearing it keeps the last known transforms as a fallback for when the frame
+// system cannot resolve them again. It is safe for concurrent use.
+type transformCache struct {
+	mu        sync.RWMutex
+	poses     map[frameKey]spatialmath.Pose
+	lastKnown map[frameKey]spatialmath.Pose
+}
+
+func newTransformCache() *transformCache {
+	return &transformCache{poses: make(map[frameKey]spatialmath.Pose), lastKnown: make(map[frameKey]spatialmath.Pose)}
+}
+
+// get returns the cached transform from source to target, if present.
+func (cache *transformCache) get(source, target string) (spatialmath.Pose, bool) {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+	pose, ok := cache.poses[frameKey{source: source, target: target}]
+	return pose, ok
+}
+
+// set stores the transform from source to target.
+func (cache *transformCache) set(source, target string, pose spatialmath.Pose) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.poses[frameKey{source: source, target: target}] = pose
+	cache.lastKnown[frameKey{source: source, target: target}] = pose
+}
+
+// lastKnownPose returns the transform from source to target most recently stored, even if the cache was cleared
+// since.
+func (cache *transformCache) lastKnownPose(source, target string) (spatialmath.Pose, bool) {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+	pose, ok := cache.lastKnown[frameKey{source: source, target: target}]
+	return pose, ok
+}
+
+// clear removes every cached transform, keeping them only as last known transforms.
+func (cache *transformCache) clear() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.poses = make(map[frameKey]spatialmath.Pose)
+}